@@ -0,0 +1,92 @@
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Baseline comparison for the benchmark suite. A baseline maps benchmark
+// names to their recorded ns/op; SaveBaseline and LoadBaseline round-trip
+// it through a plain text file (one "name ns_per_op" line per benchmark)
+// that can be checked in or stashed in CI, and Compare flags benchmarks
+// that have slowed down past a tolerance factor. The regression gate test
+// wires these to the suite's benchmarks.
+
+// Baseline maps benchmark names to ns/op.
+type Baseline map[string]float64
+
+// SaveBaseline writes b to path, one benchmark per line, sorted by name.
+func SaveBaseline(path string, b Baseline) error {
+	names := make([]string, 0, len(b))
+	for name := range b {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s %.1f\n", name, b[name])
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+// LoadBaseline reads a baseline written by SaveBaseline.
+func LoadBaseline(path string) (Baseline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b := make(Baseline)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed baseline line %q", line)
+		}
+		ns, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed baseline line %q: %v", line, err)
+		}
+		b[fields[0]] = ns
+	}
+	return b, sc.Err()
+}
+
+// Regression is one benchmark that slowed down past the tolerance.
+type Regression struct {
+	Name     string
+	Baseline float64 // ns/op recorded in the baseline
+	Current  float64 // ns/op measured now
+	Ratio    float64 // Current / Baseline
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s: %.0f ns/op vs baseline %.0f ns/op (%.2fx)", r.Name, r.Current, r.Baseline, r.Ratio)
+}
+
+// Compare returns the benchmarks in current that run slower than
+// tolerance times their baseline value, sorted by name. Benchmarks
+// missing from either side are ignored — new benchmarks have no baseline
+// to regress against.
+func (b Baseline) Compare(current Baseline, tolerance float64) []Regression {
+	var out []Regression
+	for name, now := range current {
+		base, ok := b[name]
+		if !ok || base <= 0 {
+			continue
+		}
+		if now > base*tolerance {
+			out = append(out, Regression{Name: name, Baseline: base, Current: now, Ratio: now / base})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,37 @@
+package bench
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBaselineRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "base.txt")
+	b := Baseline{"BenchmarkHeapInsert": 1500.5, "BenchmarkJoin": 250000}
+	if err := SaveBaseline(path, b); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["BenchmarkHeapInsert"] != 1500.5 || got["BenchmarkJoin"] != 250000 {
+		t.Fatalf("round trip mismatch: %v", got)
+	}
+}
+
+func TestBaselineCompare(t *testing.T) {
+	base := Baseline{"a": 1000, "b": 1000, "c": 1000}
+	current := Baseline{
+		"a": 1100, // within tolerance
+		"b": 2000, // regression
+		"d": 5000, // no baseline: ignored
+	}
+	regs := base.Compare(current, 1.5)
+	if len(regs) != 1 {
+		t.Fatalf("got %d regressions, want 1: %v", len(regs), regs)
+	}
+	if regs[0].Name != "b" || regs[0].Ratio != 2.0 {
+		t.Fatalf("unexpected regression: %+v", regs[0])
+	}
+}
@@ -0,0 +1,183 @@
+// Package bench provides deterministic data generators and canned query
+// plans over both heap and column files, so storage and operator
+// performance can be measured with standard Go benchmarks instead of
+// ad-hoc timing printouts.
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"strings"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// OrdersDesc is the schema of the generated fact table: an order id, a
+// customer id drawn from a small domain (so joins and group-bys have
+// realistic fan-out), a price, and a padded comment string.
+func OrdersDesc() godb.TupleDesc {
+	return godb.TupleDesc{Fields: []godb.FieldType{
+		{Fname: "o_id", Ftype: godb.IntType},
+		{Fname: "o_custid", Ftype: godb.IntType},
+		{Fname: "o_price", Ftype: godb.IntType},
+		{Fname: "o_comment", Ftype: godb.StringType},
+	}}
+}
+
+// CustomersDesc is the schema of the generated dimension table.
+func CustomersDesc() godb.TupleDesc {
+	return godb.TupleDesc{Fields: []godb.FieldType{
+		{Fname: "c_id", Ftype: godb.IntType},
+		{Fname: "c_name", Ftype: godb.StringType},
+	}}
+}
+
+// NumCustomers is the size of the customer id domain used by the generators.
+const NumCustomers = 100
+
+// GenOrders yields nrows deterministic order tuples; the same seed always
+// produces the same data.
+func GenOrders(nrows int, seed int64) []*godb.Tuple {
+	rng := rand.New(rand.NewSource(seed))
+	desc := OrdersDesc()
+	tuples := make([]*godb.Tuple, nrows)
+	for i := 0; i < nrows; i++ {
+		tuples[i] = &godb.Tuple{Desc: desc, Fields: []godb.DBValue{
+			godb.IntField{Value: int64(i)},
+			godb.IntField{Value: rng.Int63n(NumCustomers)},
+			godb.IntField{Value: rng.Int63n(10000)},
+			godb.StringField{Value: fmt.Sprintf("order-%d", i)},
+		}}
+	}
+	return tuples
+}
+
+// GenCustomers yields one tuple per customer id.
+func GenCustomers() []*godb.Tuple {
+	desc := CustomersDesc()
+	tuples := make([]*godb.Tuple, NumCustomers)
+	for i := 0; i < NumCustomers; i++ {
+		tuples[i] = &godb.Tuple{Desc: desc, Fields: []godb.DBValue{
+			godb.IntField{Value: int64(i)},
+			godb.StringField{Value: fmt.Sprintf("customer-%d", i)},
+		}}
+	}
+	return tuples
+}
+
+// OrdersCSV renders nrows generated order rows as CSV text for the bulk
+// load benchmarks, so no fixture files need to be checked in.
+func OrdersCSV(nrows int, seed int64) string {
+	var sb strings.Builder
+	for _, t := range GenOrders(nrows, seed) {
+		fmt.Fprintf(&sb, "%d,%d,%d,%s\n",
+			t.Fields[0].(godb.IntField).Value,
+			t.Fields[1].(godb.IntField).Value,
+			t.Fields[2].(godb.IntField).Value,
+			t.Fields[3].(godb.StringField).Value)
+	}
+	return sb.String()
+}
+
+// LoadHeap creates a heap file under dir and bulk inserts tuples into it.
+func LoadHeap(dir, name string, desc godb.TupleDesc, tuples []*godb.Tuple, bp *godb.BufferPool) (*godb.HeapFile, error) {
+	hf, err := godb.NewHeapFile(filepath.Join(dir, name+".dat"), &desc, bp)
+	if err != nil {
+		return nil, err
+	}
+	if err := load(hf, tuples, bp); err != nil {
+		return nil, err
+	}
+	return hf, nil
+}
+
+// LoadColumn creates a column file under dir and bulk inserts tuples into it.
+func LoadColumn(dir, name string, desc godb.TupleDesc, tuples []*godb.Tuple, bp *godb.BufferPool) (*godb.ColumnFile, error) {
+	cf, err := godb.NewColumnFile(filepath.Join(dir, name), &desc, bp)
+	if err != nil {
+		return nil, err
+	}
+	if err := load(cf, tuples, bp); err != nil {
+		return nil, err
+	}
+	return cf, nil
+}
+
+func load(f godb.DBFile, tuples []*godb.Tuple, bp *godb.BufferPool) error {
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		return err
+	}
+	for _, t := range tuples {
+		if err := godb.InsertTuple(f, t, tid); err != nil {
+			bp.AbortTransaction(tid)
+			return err
+		}
+	}
+	bp.CommitTransaction(tid)
+	return nil
+}
+
+// ScanFilterPlan returns a plan scanning table and keeping rows with
+// o_price < priceBound.
+func ScanFilterPlan(table godb.DBFile, priceBound int64) (godb.Operator, error) {
+	return godb.NewFilter(
+		godb.NewConstExpr(godb.IntField{Value: priceBound}, godb.IntType),
+		godb.OpLt,
+		godb.NewFieldExpr(godb.FieldType{Fname: "o_price", Ftype: godb.IntType}),
+		table)
+}
+
+// JoinPlan returns a plan joining orders to customers on customer id.
+func JoinPlan(orders, customers godb.DBFile) (godb.Operator, error) {
+	return godb.NewJoin(
+		orders,
+		godb.NewFieldExpr(godb.FieldType{Fname: "o_custid", Ftype: godb.IntType}),
+		customers,
+		godb.NewFieldExpr(godb.FieldType{Fname: "c_id", Ftype: godb.IntType}),
+		10000)
+}
+
+// GroupByPlan returns a plan computing the count and total price of orders
+// per customer id.
+func GroupByPlan(orders godb.DBFile) (godb.Operator, error) {
+	priceExpr := godb.NewFieldExpr(godb.FieldType{Fname: "o_price", Ftype: godb.IntType})
+	var cnt godb.CountAggState
+	if err := cnt.Init("cnt", priceExpr); err != nil {
+		return nil, err
+	}
+	var sum godb.SumAggState
+	if err := sum.Init("total", priceExpr); err != nil {
+		return nil, err
+	}
+	return godb.NewGroupedAggregator(
+		[]godb.AggState{&cnt, &sum},
+		[]godb.Expr{godb.NewFieldExpr(godb.FieldType{Fname: "o_custid", Ftype: godb.IntType})},
+		orders), nil
+}
+
+// Drain runs plan to completion under a fresh transaction and returns the
+// number of tuples produced.
+func Drain(plan godb.Operator, bp *godb.BufferPool) (int, error) {
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		return 0, err
+	}
+	defer bp.CommitTransaction(tid)
+	iter, err := plan.Iterator(tid)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for {
+		t, err := iter()
+		if err != nil {
+			return n, err
+		}
+		if t == nil {
+			return n, nil
+		}
+		n++
+	}
+}
@@ -0,0 +1,114 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+const benchRows = 5000
+
+// setup loads the generated tables into both storage formats under a
+// per-benchmark temp dir.
+func setup(b *testing.B) (*godb.BufferPool, *godb.HeapFile, *godb.ColumnFile, *godb.HeapFile) {
+	b.Helper()
+	dir := b.TempDir()
+	bp := godb.NewBufferPool(200)
+	orders := GenOrders(benchRows, 42)
+	heapOrders, err := LoadHeap(dir, "orders", OrdersDesc(), orders, bp)
+	if err != nil {
+		b.Fatal(err)
+	}
+	colOrders, err := LoadColumn(dir, "orders_col", OrdersDesc(), orders, bp)
+	if err != nil {
+		b.Fatal(err)
+	}
+	customers, err := LoadHeap(dir, "customers", CustomersDesc(), GenCustomers(), bp)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return bp, heapOrders, colOrders, customers
+}
+
+func runPlan(b *testing.B, plan godb.Operator, bp *godb.BufferPool) {
+	b.Helper()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Drain(plan, bp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHeapScanFilter(b *testing.B) {
+	bp, heapOrders, _, _ := setup(b)
+	plan, err := ScanFilterPlan(heapOrders, 1000)
+	if err != nil {
+		b.Fatal(err)
+	}
+	runPlan(b, plan, bp)
+}
+
+func BenchmarkColumnScanFilter(b *testing.B) {
+	bp, _, colOrders, _ := setup(b)
+	plan, err := ScanFilterPlan(colOrders, 1000)
+	if err != nil {
+		b.Fatal(err)
+	}
+	runPlan(b, plan, bp)
+}
+
+func BenchmarkJoin(b *testing.B) {
+	bp, heapOrders, _, customers := setup(b)
+	plan, err := JoinPlan(heapOrders, customers)
+	if err != nil {
+		b.Fatal(err)
+	}
+	runPlan(b, plan, bp)
+}
+
+func BenchmarkGroupByHeap(b *testing.B) {
+	bp, heapOrders, _, _ := setup(b)
+	plan, err := GroupByPlan(heapOrders)
+	if err != nil {
+		b.Fatal(err)
+	}
+	runPlan(b, plan, bp)
+}
+
+func BenchmarkGroupByColumn(b *testing.B) {
+	bp, _, colOrders, _ := setup(b)
+	plan, err := GroupByPlan(colOrders)
+	if err != nil {
+		b.Fatal(err)
+	}
+	runPlan(b, plan, bp)
+}
+
+// benchConcurrentScans measures parallel scan-filter queries against a
+// pool with the given page cache shard count; nShards = 1 is the old
+// single-mutex design.
+func benchConcurrentScans(b *testing.B, nShards int) {
+	dir := b.TempDir()
+	bp := godb.NewShardedBufferPool(200, nShards)
+	heapOrders, err := LoadHeap(dir, "orders", OrdersDesc(), GenOrders(benchRows, 42), bp)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			plan, err := ScanFilterPlan(heapOrders, 1000)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := Drain(plan, bp); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkConcurrentScansUnsharded(b *testing.B) { benchConcurrentScans(b, 1) }
+
+func BenchmarkConcurrentScansSharded(b *testing.B) { benchConcurrentScans(b, 8) }
@@ -0,0 +1,66 @@
+package bench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// TestColumnInsertAtomicity forces a column-file insert to fail partway
+// through — another transaction holds the write lock on a later column's
+// tail page, and the inserter's lock wait times out — and checks that no
+// torn row is left behind: every surviving row is complete, and the next
+// insert lands cleanly.
+func TestColumnInsertAtomicity(t *testing.T) {
+	dir := t.TempDir()
+	bp := godb.NewBufferPool(100)
+	cf, err := LoadColumn(dir, "orders_col", OrdersDesc(), GenOrders(10, 42), bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numCols := len(OrdersDesc().Fields)
+
+	// Hold the write lock on the last column's tail page (column pages are
+	// packed as colPageNo*numCols + colNo; 10 rows fit on one page).
+	blocker := godb.NewTID()
+	if err := bp.BeginTransaction(blocker); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bp.GetPage(cf, 0*numCols+(numCols-1), blocker, godb.WritePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	ins := godb.NewTID()
+	if err := bp.BeginTransaction(ins); err != nil {
+		t.Fatal(err)
+	}
+	godb.SetTransactionLimits(ins, godb.TransactionLimits{LockWaitTimeout: 50 * time.Millisecond})
+	row := GenOrders(1, 7)[0]
+	if err := godb.InsertTuple(cf, row, ins); err == nil {
+		t.Fatal("expected insert to fail while tail page is locked")
+	}
+	bp.AbortTransaction(ins)
+	godb.ClearTransactionLimits(ins)
+	bp.CommitTransaction(blocker)
+
+	// No torn row: the failed insert must not have lengthened any column.
+	plan, err := ScanFilterPlan(cf, 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := Drain(plan, bp); err != nil || n != 10 {
+		t.Fatalf("after failed insert: got %d rows, err %v; want 10", n, err)
+	}
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		t.Fatal(err)
+	}
+	if err := godb.InsertTuple(cf, row, tid); err != nil {
+		t.Fatal(err)
+	}
+	bp.CommitTransaction(tid)
+	if n, err := Drain(plan, bp); err != nil || n != 11 {
+		t.Fatalf("after clean insert: got %d rows, err %v; want 11", n, err)
+	}
+}
@@ -0,0 +1,102 @@
+package bench
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// TestConcurrentInserts stresses the heap file insert path with many
+// goroutines appending at once. Transactions that lose a deadlock are
+// retried; every row that commits must survive, appear exactly once, and
+// no page may be allocated twice (which would silently overwrite rows).
+func TestConcurrentInserts(t *testing.T) {
+	dir := t.TempDir()
+	bp := godb.NewBufferPool(300)
+	hf, err := LoadHeap(dir, "orders", OrdersDesc(), nil, bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		goroutines  = 16
+		perTx       = 10
+		txPerWorker = 5
+	)
+	var committed atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for txn := 0; txn < txPerWorker; txn++ {
+				base := int64((worker*txPerWorker + txn) * perTx)
+				rows := GenOrders(perTx, base+1)
+				for i, r := range rows {
+					r.Fields[0] = godb.IntField{Value: base + int64(i)}
+				}
+				for attempt := 0; attempt < 100; attempt++ {
+					tid := godb.NewTID()
+					if err := bp.BeginTransaction(tid); err != nil {
+						t.Error(err)
+						return
+					}
+					ok := true
+					for _, r := range rows {
+						cp := *r
+						if err := godb.InsertTuple(hf, &cp, tid); err != nil {
+							ok = false
+							break
+						}
+					}
+					if !ok {
+						bp.AbortTransaction(tid)
+						continue
+					}
+					bp.CommitTransaction(tid)
+					committed.Add(int64(len(rows)))
+					break
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		t.Fatal(err)
+	}
+	defer bp.CommitTransaction(tid)
+	iter, err := hf.Iterator(tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int64]bool)
+	rids := make(map[godb.RecordID]bool)
+	n := int64(0)
+	for {
+		tp, err := iter()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tp == nil {
+			break
+		}
+		n++
+		id := tp.Fields[0].(godb.IntField).Value
+		if seen[id] {
+			t.Fatalf("row %d appears twice", id)
+		}
+		seen[id] = true
+		rid := tp.Rid.(godb.RecordID)
+		if rids[rid] {
+			t.Fatalf("rid %v appears twice", rid)
+		}
+		rids[rid] = true
+	}
+	if n != committed.Load() {
+		t.Fatalf("committed %d rows but scan found %d", committed.Load(), n)
+	}
+}
@@ -0,0 +1,115 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// countOrders scans hf under a fresh transaction and returns the row count.
+func countOrders(t *testing.T, hf *godb.HeapFile, bp *godb.BufferPool) int {
+	t.Helper()
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		t.Fatal(err)
+	}
+	defer bp.CommitTransaction(tid)
+	iter, err := hf.Iterator(tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for {
+		tp, err := iter()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tp == nil {
+			return n
+		}
+		n++
+	}
+}
+
+// TestFileLockWriteDurability inserts under an explicit file X lock, where
+// GetPage takes the covered fast path instead of page locks. Committed rows
+// must survive a reopen with a fresh buffer pool, and an aborted
+// transaction's rows must not be visible afterwards.
+func TestFileLockWriteDurability(t *testing.T) {
+	dir := t.TempDir()
+	bp := godb.NewBufferPool(100)
+	hf, err := LoadHeap(dir, "orders", OrdersDesc(), nil, bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		t.Fatal(err)
+	}
+	if err := bp.LockFile(hf, tid, godb.WritePerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range GenOrders(500, 1) {
+		if err := godb.InsertTuple(hf, r, tid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bp.CommitTransaction(tid)
+
+	bp2 := godb.NewBufferPool(100)
+	hf2, err := LoadHeap(dir, "orders", OrdersDesc(), nil, bp2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countOrders(t, hf2, bp2); n != 500 {
+		t.Fatalf("after reopen got %d rows, want 500", n)
+	}
+
+	tid2 := godb.NewTID()
+	if err := bp2.BeginTransaction(tid2); err != nil {
+		t.Fatal(err)
+	}
+	if err := bp2.LockFile(hf2, tid2, godb.WritePerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range GenOrders(100, 2) {
+		if err := godb.InsertTuple(hf2, r, tid2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bp2.AbortTransaction(tid2)
+	if n := countOrders(t, hf2, bp2); n != 500 {
+		t.Fatalf("after abort got %d rows, want 500", n)
+	}
+}
+
+// TestEscalatedWriteDurability is the same check with the covering lock
+// arriving via escalation rather than an explicit LockFile call.
+func TestEscalatedWriteDurability(t *testing.T) {
+	dir := t.TempDir()
+	bp := godb.NewBufferPool(500)
+	bp.SetLockEscalationThreshold(1)
+	hf, err := LoadHeap(dir, "orders", OrdersDesc(), nil, bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range GenOrders(2000, 1) {
+		if err := godb.InsertTuple(hf, r, tid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bp.CommitTransaction(tid)
+
+	bp2 := godb.NewBufferPool(500)
+	hf2, err := LoadHeap(dir, "orders", OrdersDesc(), nil, bp2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countOrders(t, hf2, bp2); n != 2000 {
+		t.Fatalf("after reopen got %d rows, want 2000", n)
+	}
+}
@@ -0,0 +1,99 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// TestCompositeIndexTransactional checks that composite index maintenance —
+// including an expression index over a virtual computed column — follows
+// the writing transaction's outcome: aborted inserts and deletes leave the
+// index untouched, committed ones apply.
+func TestCompositeIndexTransactional(t *testing.T) {
+	dir := t.TempDir()
+	bp := godb.NewBufferPool(100)
+	cat := godb.NewCatalog(bp, dir)
+	if _, err := cat.AddTable("orders", OrdersDesc()); err != nil {
+		t.Fatal(err)
+	}
+	// double_price is a virtual computed column, so the composite index on
+	// it is an expression index.
+	price := godb.NewFieldExpr(godb.FieldType{Fname: "o_price", Ftype: godb.IntType})
+	two := godb.NewConstExpr(godb.IntField{Value: 2}, godb.IntType)
+	if err := cat.AddComputedColumn("orders", "double_price", godb.NewArithExpr(price, godb.OpMul, two), false); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := cat.CreateCompositeIndex("orders", []string{"double_price", "o_id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hf, err := cat.GetTable("orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	insert := func(tid godb.TransactionID, id, price int64) {
+		t.Helper()
+		tp := &godb.Tuple{Desc: OrdersDesc(), Fields: []godb.DBValue{
+			godb.IntField{Value: id},
+			godb.IntField{Value: id % 10},
+			godb.IntField{Value: price},
+			godb.StringField{Value: "x"},
+		}}
+		if err := godb.InsertTuple(hf, tp, tid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries := func(doubled int64) int {
+		t.Helper()
+		rids, err := idx.LookupPrefix([]godb.DBValue{godb.IntField{Value: doubled}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(rids)
+	}
+
+	// Aborted insert: no entry appears.
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		t.Fatal(err)
+	}
+	insert(tid, 1, 50)
+	bp.AbortTransaction(tid)
+	if n := entries(100); n != 0 {
+		t.Fatalf("after aborted insert index has %d entries, want 0", n)
+	}
+
+	// Committed insert: entry appears under the computed key.
+	tid = godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		t.Fatal(err)
+	}
+	insert(tid, 1, 50)
+	bp.CommitTransaction(tid)
+	if n := entries(100); n != 1 {
+		t.Fatalf("after committed insert index has %d entries, want 1", n)
+	}
+
+	// Aborted delete: entry survives.
+	tid = godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		t.Fatal(err)
+	}
+	iter, err := hf.Iterator(tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err := iter()
+	if err != nil || row == nil {
+		t.Fatalf("scan: %v %v", row, err)
+	}
+	if err := godb.DeleteTuple(hf, row, tid); err != nil {
+		t.Fatal(err)
+	}
+	bp.AbortTransaction(tid)
+	if n := entries(100); n != 1 {
+		t.Fatalf("after aborted delete index has %d entries, want 1", n)
+	}
+}
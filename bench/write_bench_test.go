@@ -0,0 +1,116 @@
+package bench
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// Write path benchmarks: per-tuple insert cost into each storage format,
+// a full column scan, and CSV bulk load throughput.
+
+func benchInsert(b *testing.B, column bool) {
+	dir := b.TempDir()
+	bp := godb.NewBufferPool(200)
+	desc := OrdersDesc()
+	var f godb.DBFile
+	var err error
+	if column {
+		f, err = godb.NewColumnFile(filepath.Join(dir, "orders"), &desc, bp)
+	} else {
+		f, err = godb.NewHeapFile(filepath.Join(dir, "orders.dat"), &desc, bp)
+	}
+	if err != nil {
+		b.Fatal(err)
+	}
+	tuples := GenOrders(b.N, 42)
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := godb.InsertTuple(f, tuples[i], tid); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	bp.CommitTransaction(tid)
+}
+
+func BenchmarkHeapInsert(b *testing.B) { benchInsert(b, false) }
+
+func BenchmarkColumnInsert(b *testing.B) { benchInsert(b, true) }
+
+func BenchmarkColumnScan(b *testing.B) {
+	bp, _, colOrders, _ := setup(b)
+	runPlan(b, colOrders, bp)
+}
+
+func BenchmarkCSVLoad(b *testing.B) {
+	csvData := OrdersCSV(benchRows, 42)
+	dir := b.TempDir()
+	bp := godb.NewBufferPool(200)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		desc := OrdersDesc()
+		hf, err := godb.NewHeapFile(filepath.Join(dir, fmt.Sprintf("load%d.dat", i)), &desc, bp)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		if err := hf.LoadFromCSV(strings.NewReader(csvData), false, ",", false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// gateBenchmarks are the benchmarks the regression gate measures.
+var gateBenchmarks = map[string]func(*testing.B){
+	"BenchmarkHeapInsert":   BenchmarkHeapInsert,
+	"BenchmarkColumnInsert": BenchmarkColumnInsert,
+	"BenchmarkColumnScan":   BenchmarkColumnScan,
+	"BenchmarkJoin":         BenchmarkJoin,
+	"BenchmarkCSVLoad":      BenchmarkCSVLoad,
+}
+
+// gateTolerance is how much slower than the baseline a benchmark may run
+// before the gate fails; individual runs are noisy, so the bar is loose.
+const gateTolerance = 1.5
+
+// TestBenchmarkRegressionGate measures the gate benchmarks and compares
+// them against the baseline file named by GODB_BENCH_BASELINE, failing on
+// any benchmark more than gateTolerance times slower. With
+// GODB_BENCH_BASELINE_UPDATE=1 the file is (re)written instead. Without
+// the environment variable the gate is skipped, so ordinary test runs do
+// not pay for benchmarking.
+func TestBenchmarkRegressionGate(t *testing.T) {
+	path := os.Getenv("GODB_BENCH_BASELINE")
+	if path == "" {
+		t.Skip("set GODB_BENCH_BASELINE to a baseline file to run the regression gate")
+	}
+	current := make(Baseline, len(gateBenchmarks))
+	for name, fn := range gateBenchmarks {
+		r := testing.Benchmark(fn)
+		current[name] = float64(r.NsPerOp())
+	}
+	if os.Getenv("GODB_BENCH_BASELINE_UPDATE") == "1" {
+		if err := SaveBaseline(path, current); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("baseline written to %s", path)
+		return
+	}
+	base, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range base.Compare(current, gateTolerance) {
+		t.Errorf("regression: %s", r)
+	}
+}
@@ -0,0 +1,379 @@
+// Package client is a small GoDB client speaking the PostgreSQL simple
+// query protocol, with role-aware routing over multiple endpoints. Writes
+// always go to the primary; read-only statements are spread round-robin
+// over the replicas (fed by the replication package) and fall back to the
+// primary when no replica is reachable. Replicas trail the leader, so the
+// client bounds staleness the only way it can from its side of the wire:
+// for MaxStaleness after any write through this client, reads are pinned
+// to the primary, giving read-your-writes within one client. Set
+// MaxStaleness to zero to always read from replicas regardless.
+package client
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxStaleness is the read-your-writes window applied by
+// NewClient. Replicas apply one transaction per change record, so they
+// catch up quickly; a second is generous.
+const DefaultMaxStaleness = time.Second
+
+// maxMessageSize caps the declared length of a server frame. The length
+// field comes off the wire and each frame is read fully into memory, so a
+// malformed or hostile server could otherwise demand an arbitrarily large
+// allocation; 16 MB matches the server's own frame cap.
+const maxMessageSize = 16 << 20
+
+// Result holds the rows of one query.
+type Result struct {
+	Columns []string
+	Rows    [][]string
+	// Tag is the server's command completion tag, e.g. "SELECT 3".
+	Tag string
+}
+
+// Client routes queries over a primary and any number of replicas.
+type Client struct {
+	// MaxStaleness pins reads to the primary for this long after a
+	// write through this client.
+	MaxStaleness time.Duration
+
+	primary  *endpoint
+	replicas []*endpoint
+
+	mu        sync.Mutex
+	next      int // round-robin cursor over replicas
+	lastWrite time.Time
+}
+
+// NewClient returns a client writing to primary and reading from the
+// replicas. With no replicas, everything goes to the primary.
+func NewClient(primary string, replicas ...string) *Client {
+	c := &Client{MaxStaleness: DefaultMaxStaleness, primary: &endpoint{addr: primary}}
+	for _, r := range replicas {
+		c.replicas = append(c.replicas, &endpoint{addr: r})
+	}
+	return c
+}
+
+// Query runs one statement, routing it by kind: writes and recent-write
+// reads to the primary, other reads to a replica.
+func (c *Client) Query(sql string) (*Result, error) {
+	if !isReadOnly(sql) {
+		res, err := c.primary.query(sql)
+		if err == nil {
+			c.mu.Lock()
+			c.lastWrite = time.Now()
+			c.mu.Unlock()
+		}
+		return res, err
+	}
+	for _, ep := range c.readOrder() {
+		res, err := ep.query(sql)
+		if err == nil {
+			return res, nil
+		}
+		if _, ok := err.(*ServerError); ok {
+			// The statement itself failed; trying elsewhere won't help.
+			return nil, err
+		}
+	}
+	return c.primary.query(sql)
+}
+
+// Exec runs a statement for its side effects, returning the completion
+// tag.
+func (c *Client) Exec(sql string) (string, error) {
+	res, err := c.Query(sql)
+	if err != nil {
+		return "", err
+	}
+	return res.Tag, nil
+}
+
+// Close closes all endpoint connections.
+func (c *Client) Close() {
+	c.primary.close()
+	for _, ep := range c.replicas {
+		ep.close()
+	}
+}
+
+// readOrder returns the endpoints to try for a read: replicas starting at
+// the round-robin cursor, unless reads are pinned to the primary.
+func (c *Client) readOrder() []*endpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.replicas) == 0 {
+		return nil
+	}
+	if c.MaxStaleness > 0 && !c.lastWrite.IsZero() && time.Since(c.lastWrite) < c.MaxStaleness {
+		return nil
+	}
+	order := make([]*endpoint, 0, len(c.replicas))
+	for i := range c.replicas {
+		order = append(order, c.replicas[(c.next+i)%len(c.replicas)])
+	}
+	c.next = (c.next + 1) % len(c.replicas)
+	return order
+}
+
+// isReadOnly reports whether sql can be served by a replica.
+func isReadOnly(sql string) bool {
+	fields := strings.Fields(strings.ToUpper(sql))
+	if len(fields) == 0 {
+		return true
+	}
+	switch fields[0] {
+	case "SELECT", "SHOW", "EXPLAIN":
+		return true
+	}
+	return false
+}
+
+// ServerError is an error reported by the server rather than the
+// transport; the statement reached a server and was rejected.
+type ServerError struct {
+	Code    string
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return e.Message
+}
+
+// endpoint is one server connection, dialed lazily and redialed after
+// transport errors.
+type endpoint struct {
+	addr string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+func (ep *endpoint) close() {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if ep.conn != nil {
+		ep.conn.Close()
+		ep.conn = nil
+	}
+}
+
+// query runs one statement on this endpoint. Transport failures drop the
+// connection so the next call redials.
+func (ep *endpoint) query(sql string) (*Result, error) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if ep.conn == nil {
+		if err := ep.dial(); err != nil {
+			return nil, err
+		}
+	}
+	res, err := ep.run(sql)
+	if err != nil {
+		if _, ok := err.(*ServerError); !ok {
+			ep.conn.Close()
+			ep.conn = nil
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// dial connects and completes the startup handshake.
+func (ep *endpoint) dial() error {
+	conn, err := net.Dial("tcp", ep.addr)
+	if err != nil {
+		return err
+	}
+	ep.conn = conn
+	ep.r = bufio.NewReader(conn)
+	ep.w = bufio.NewWriter(conn)
+	var payload []byte
+	payload = binary.BigEndian.AppendUint32(payload, 196608) // protocol 3.0
+	payload = append(payload, "user\x00godb\x00\x00"...)
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(payload)+4))
+	ep.w.Write(hdr[:])
+	ep.w.Write(payload)
+	if err := ep.w.Flush(); err != nil {
+		conn.Close()
+		ep.conn = nil
+		return err
+	}
+	// Read until ReadyForQuery, expecting trust auth.
+	for {
+		msgType, body, err := ep.read()
+		if err != nil {
+			conn.Close()
+			ep.conn = nil
+			return err
+		}
+		switch msgType {
+		case 'R':
+			if len(body) < 4 || binary.BigEndian.Uint32(body) != 0 {
+				conn.Close()
+				ep.conn = nil
+				return fmt.Errorf("client: server requires authentication")
+			}
+		case 'E':
+			conn.Close()
+			ep.conn = nil
+			return parseServerError(body)
+		case 'Z':
+			return nil
+		}
+		// ParameterStatus and friends are informational.
+	}
+}
+
+// run sends one simple query and collects its results, consuming through
+// ReadyForQuery.
+func (ep *endpoint) run(sql string) (*Result, error) {
+	var payload []byte
+	payload = append(payload, sql...)
+	payload = append(payload, 0)
+	ep.w.WriteByte('Q')
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(payload)+4))
+	ep.w.Write(hdr[:])
+	ep.w.Write(payload)
+	if err := ep.w.Flush(); err != nil {
+		return nil, err
+	}
+	res := &Result{}
+	var srvErr error
+	for {
+		msgType, body, err := ep.read()
+		if err != nil {
+			return nil, err
+		}
+		switch msgType {
+		case 'T':
+			res.Columns = parseRowDescription(body)
+		case 'D':
+			row, err := parseDataRow(body)
+			if err != nil {
+				return nil, err
+			}
+			res.Rows = append(res.Rows, row)
+		case 'C':
+			res.Tag = cstring(body)
+		case 'E':
+			srvErr = parseServerError(body)
+		case 'Z':
+			if srvErr != nil {
+				return nil, srvErr
+			}
+			return res, nil
+		}
+	}
+}
+
+// read reads one framed server message.
+func (ep *endpoint) read() (byte, []byte, error) {
+	msgType, err := ep.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var length int32
+	if err := binary.Read(ep.r, binary.BigEndian, &length); err != nil {
+		return 0, nil, err
+	}
+	// The length includes itself and is not trusted: below 4 it would
+	// underflow the buffer size, and an absurd value is a corrupt frame.
+	if length < 4 || length > maxMessageSize {
+		return 0, nil, fmt.Errorf("client: invalid message length %d", length)
+	}
+	body := make([]byte, length-4)
+	if _, err := io.ReadFull(ep.r, body); err != nil {
+		return 0, nil, err
+	}
+	return msgType, body, nil
+}
+
+func parseRowDescription(body []byte) []string {
+	if len(body) < 2 {
+		return nil
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	body = body[2:]
+	cols := make([]string, 0, n)
+	for i := 0; i < n && len(body) > 0; i++ {
+		name := cstring(body)
+		cols = append(cols, name)
+		// Skip the name, its NUL, and the 18 bytes of field metadata.
+		skip := len(name) + 1 + 18
+		if skip > len(body) {
+			break
+		}
+		body = body[skip:]
+	}
+	return cols
+}
+
+func parseDataRow(body []byte) ([]string, error) {
+	if len(body) < 2 {
+		return nil, fmt.Errorf("client: short DataRow")
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	body = body[2:]
+	row := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if len(body) < 4 {
+			return nil, fmt.Errorf("client: short DataRow")
+		}
+		flen := int32(binary.BigEndian.Uint32(body))
+		body = body[4:]
+		if flen < 0 {
+			row = append(row, "")
+			continue
+		}
+		if int(flen) > len(body) {
+			return nil, fmt.Errorf("client: short DataRow")
+		}
+		row = append(row, string(body[:flen]))
+		body = body[flen:]
+	}
+	return row, nil
+}
+
+// parseServerError decodes an ErrorResponse's tagged fields.
+func parseServerError(body []byte) *ServerError {
+	e := &ServerError{}
+	for len(body) > 0 && body[0] != 0 {
+		tag := body[0]
+		val := cstring(body[1:])
+		switch tag {
+		case 'C':
+			e.Code = val
+		case 'M':
+			e.Message = val
+		}
+		body = body[1+len(val)+1:]
+	}
+	if e.Message == "" {
+		e.Message = "server error"
+	}
+	return e
+}
+
+// cstring returns the bytes of b up to the first NUL as a string.
+func cstring(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,50 @@
+// Command godbdump dumps a GoDB database to a portable text archive on
+// stdout, or restores one from stdin.
+//
+// Dump a database (the schema file lists its tables, LoadSchema syntax):
+//
+//	godbdump -dir db/ -schema db/catalog.txt > db.dump
+//
+// Restore into an empty directory:
+//
+//	godbdump -restore -dir newdb/ < db.dump
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "database root directory")
+	schema := flag.String("schema", "", "schema file naming the tables to dump")
+	restore := flag.Bool("restore", false, "restore a dump from stdin instead of dumping")
+	pages := flag.Int("pages", 1000, "buffer pool size in pages")
+	flag.Parse()
+
+	bp := godb.NewBufferPool(*pages)
+	cat := godb.NewCatalog(bp, *dir)
+	if *restore {
+		if err := cat.RestoreDump(os.Stdin); err != nil {
+			fatal("restore: %v", err)
+		}
+		return
+	}
+	if *schema == "" {
+		fatal("dump requires -schema")
+	}
+	if err := cat.LoadSchema(*schema); err != nil {
+		fatal("loading schema: %v", err)
+	}
+	if err := cat.Dump(os.Stdout); err != nil {
+		fatal("dump: %v", err)
+	}
+}
+
+func fatal(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "godbdump: "+format+"\n", args...)
+	os.Exit(1)
+}
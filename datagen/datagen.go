@@ -0,0 +1,130 @@
+// Package datagen produces deterministic synthetic tuples directly into
+// a DBFile: int columns drawn from uniform, zipfian, or sequential
+// distributions, string columns drawn from pools, and any column made
+// nullable at a fixed ratio. The same seed always produces the same
+// rows, so performance tests and examples can describe their data
+// instead of checking in CSV fixtures of various sizes.
+package datagen
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// ValueGen produces one column value per row from the generator's
+// deterministic random source.
+type ValueGen func(rng *rand.Rand) godb.DBValue
+
+// Uniform returns int values drawn uniformly from [min, max].
+func Uniform(min, max int64) ValueGen {
+	return func(rng *rand.Rand) godb.DBValue {
+		return godb.IntField{Value: min + rng.Int63n(max-min+1)}
+	}
+}
+
+// Zipf returns int values in [0, n) with a zipfian skew; s > 1 controls
+// the skew (larger is more skewed), as in rand.NewZipf.
+func Zipf(n uint64, s float64) ValueGen {
+	var z *rand.Zipf
+	return func(rng *rand.Rand) godb.DBValue {
+		if z == nil {
+			z = rand.NewZipf(rng, s, 1, n-1)
+		}
+		return godb.IntField{Value: int64(z.Uint64())}
+	}
+}
+
+// Sequence returns consecutive int values starting at start — the usual
+// choice for key columns.
+func Sequence(start int64) ValueGen {
+	next := start
+	return func(*rand.Rand) godb.DBValue {
+		v := next
+		next++
+		return godb.IntField{Value: v}
+	}
+}
+
+// StringPool returns string values drawn uniformly from pool.
+func StringPool(pool []string) ValueGen {
+	return func(rng *rand.Rand) godb.DBValue {
+		return godb.StringField{Value: pool[rng.Intn(len(pool))]}
+	}
+}
+
+// MakePool builds a pool of n distinct strings "<prefix>-0" through
+// "<prefix>-<n-1>" for StringPool.
+func MakePool(prefix string, n int) []string {
+	pool := make([]string, n)
+	for i := range pool {
+		pool[i] = fmt.Sprintf("%s-%d", prefix, i)
+	}
+	return pool
+}
+
+// Nullable wraps g so a ratio of its values (0 none, 1 all) come out as
+// nulls instead. Only column files store nulls; heap pages are
+// fixed-width and reject NullField when they are flushed.
+func Nullable(g ValueGen, ratio float64) ValueGen {
+	return func(rng *rand.Rand) godb.DBValue {
+		if rng.Float64() < ratio {
+			return godb.NullField{}
+		}
+		return g(rng)
+	}
+}
+
+// Generator produces rows of a fixed schema from one seeded source.
+type Generator struct {
+	desc godb.TupleDesc
+	rng  *rand.Rand
+	cols []ValueGen
+}
+
+// NewGenerator returns a generator for desc with one ValueGen per column,
+// seeded so the row sequence is reproducible.
+func NewGenerator(desc godb.TupleDesc, seed int64, cols ...ValueGen) (*Generator, error) {
+	if len(cols) != len(desc.Fields) {
+		return nil, fmt.Errorf("schema has %d columns but %d generators were supplied", len(desc.Fields), len(cols))
+	}
+	return &Generator{desc: desc, rng: rand.New(rand.NewSource(seed)), cols: cols}, nil
+}
+
+// Tuple produces the next row.
+func (g *Generator) Tuple() *godb.Tuple {
+	fields := make([]godb.DBValue, len(g.cols))
+	for i, gen := range g.cols {
+		fields[i] = gen(g.rng)
+	}
+	return &godb.Tuple{Desc: g.desc, Fields: fields}
+}
+
+// fillBatch is how many rows Fill inserts per transaction, keeping any
+// one transaction's dirty page set small.
+const fillBatch = 1024
+
+// Fill inserts nrows generated rows into f through bp, in batches of
+// fillBatch rows per transaction.
+func (g *Generator) Fill(f godb.DBFile, bp *godb.BufferPool, nrows int) error {
+	for done := 0; done < nrows; {
+		n := fillBatch
+		if nrows-done < n {
+			n = nrows - done
+		}
+		tid := godb.NewTID()
+		if err := bp.BeginTransaction(tid); err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if err := godb.InsertTuple(f, g.Tuple(), tid); err != nil {
+				bp.AbortTransaction(tid)
+				return err
+			}
+		}
+		bp.CommitTransaction(tid)
+		done += n
+	}
+	return nil
+}
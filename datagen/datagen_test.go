@@ -0,0 +1,109 @@
+package datagen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+func testDesc() godb.TupleDesc {
+	return godb.TupleDesc{Fields: []godb.FieldType{
+		{Fname: "id", Ftype: godb.IntType},
+		{Fname: "grp", Ftype: godb.IntType},
+		{Fname: "name", Ftype: godb.StringType},
+	}}
+}
+
+func newTestGen(t *testing.T, seed int64) *Generator {
+	t.Helper()
+	g, err := NewGenerator(testDesc(), seed,
+		Sequence(0),
+		Zipf(100, 1.5),
+		StringPool(MakePool("name", 10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestDeterministic(t *testing.T) {
+	a, b := newTestGen(t, 7), newTestGen(t, 7)
+	for i := 0; i < 100; i++ {
+		ta, tb := a.Tuple(), b.Tuple()
+		for j := range ta.Fields {
+			if ta.Fields[j] != tb.Fields[j] {
+				t.Fatalf("row %d field %d differs: %v vs %v", i, j, ta.Fields[j], tb.Fields[j])
+			}
+		}
+	}
+}
+
+func TestGeneratorArity(t *testing.T) {
+	if _, err := NewGenerator(testDesc(), 1, Sequence(0)); err == nil {
+		t.Fatal("expected error for mismatched generator count")
+	}
+}
+
+func TestUniformRange(t *testing.T) {
+	g, err := NewGenerator(godb.TupleDesc{Fields: []godb.FieldType{{Fname: "v", Ftype: godb.IntType}}},
+		3, Uniform(10, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		v := g.Tuple().Fields[0].(godb.IntField).Value
+		if v < 10 || v > 20 {
+			t.Fatalf("value %d outside [10, 20]", v)
+		}
+	}
+}
+
+func TestNullableRatio(t *testing.T) {
+	g, err := NewGenerator(godb.TupleDesc{Fields: []godb.FieldType{{Fname: "v", Ftype: godb.IntType}}},
+		5, Nullable(Uniform(0, 9), 0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nulls := 0
+	for i := 0; i < 1000; i++ {
+		if _, ok := g.Tuple().Fields[0].(godb.NullField); ok {
+			nulls++
+		}
+	}
+	if nulls < 400 || nulls > 600 {
+		t.Fatalf("got %d nulls out of 1000 at ratio 0.5", nulls)
+	}
+}
+
+func TestFill(t *testing.T) {
+	bp := godb.NewBufferPool(100)
+	desc := testDesc()
+	hf, err := godb.NewHeapFile(filepath.Join(t.TempDir(), "gen.dat"), &desc, bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2500 rows crosses several fillBatch transactions.
+	if err := newTestGen(t, 11).Fill(hf, bp, 2500); err != nil {
+		t.Fatal(err)
+	}
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		t.Fatal(err)
+	}
+	defer bp.CommitTransaction(tid)
+	iter, err := hf.Iterator(tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for tup, err := iter(); tup != nil; tup, err = iter() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if n != 2500 {
+		t.Fatalf("got %d rows, want 2500", n)
+	}
+}
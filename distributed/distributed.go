@@ -0,0 +1,592 @@
+// Package distributed runs queries over a table partitioned across several
+// GoDB instances. A Coordinator hash-partitions rows by a key column and
+// routes each insert to one shard; reads push work down to the shards — a
+// scan with an optional filter, or a partial aggregate (COUNT, SUM, MIN,
+// MAX) — and merge the shard results locally, so only matching rows or
+// one aggregate row per shard crosses the network. Each shard is an
+// ordinary catalog served by a Worker; shards and the coordinator speak
+// newline-delimited JSON over TCP, one connection per request, in the
+// same spirit as the replication stream.
+package distributed
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sync"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// Filter is a predicate pushed down to the shards: rows where the named
+// field compares true against the value. Op is one of =, !=, <, <=, >,
+// >=.
+type Filter struct {
+	Field string  `json:"field"`
+	Op    string  `json:"op"`
+	Int   *int64  `json:"int,omitempty"`
+	Str   *string `json:"str,omitempty"`
+}
+
+// FilterValue builds the filter's comparison value.
+func (f *Filter) value() (godb.DBValue, error) {
+	switch {
+	case f.Int != nil:
+		return godb.IntField{Value: *f.Int}, nil
+	case f.Str != nil:
+		return godb.StringField{Value: *f.Str}, nil
+	}
+	return nil, fmt.Errorf("distributed: filter on %q has no value", f.Field)
+}
+
+// NewIntFilter returns a filter comparing field against v.
+func NewIntFilter(field, op string, v int64) *Filter {
+	return &Filter{Field: field, Op: op, Int: &v}
+}
+
+// NewStringFilter returns a filter comparing field against v.
+func NewStringFilter(field, op string, v string) *Filter {
+	return &Filter{Field: field, Op: op, Str: &v}
+}
+
+// request is one pushed-down operation on the wire.
+type request struct {
+	Op     string      `json:"op"` // "insert", "scan", "agg", "schema"
+	Table  string      `json:"table"`
+	Tuple  []wireValue `json:"tuple,omitempty"`
+	Filter *Filter     `json:"filter,omitempty"`
+	// Agg is "count", "sum", "min", or "max"; Field is its input column.
+	Agg   string `json:"agg,omitempty"`
+	Field string `json:"field,omitempty"`
+}
+
+// response is one message back from a shard: a row, an aggregate result,
+// or the final done/error marker.
+type response struct {
+	Row   []wireValue `json:"row,omitempty"`
+	Value *wireValue  `json:"value,omitempty"`
+	Count int64       `json:"count,omitempty"`
+	Done  bool        `json:"done,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// wireValue encodes one DBValue; exactly one field is set.
+type wireValue struct {
+	Int *int64  `json:"i,omitempty"`
+	Str *string `json:"s,omitempty"`
+}
+
+func encodeValue(v godb.DBValue) wireValue {
+	switch x := v.(type) {
+	case godb.IntField:
+		n := x.Value
+		return wireValue{Int: &n}
+	case godb.StringField:
+		s := x.Value
+		return wireValue{Str: &s}
+	}
+	return wireValue{}
+}
+
+func (v wireValue) decode() (godb.DBValue, error) {
+	switch {
+	case v.Int != nil:
+		return godb.IntField{Value: *v.Int}, nil
+	case v.Str != nil:
+		return godb.StringField{Value: *v.Str}, nil
+	}
+	return nil, fmt.Errorf("distributed: value with no contents")
+}
+
+// Worker serves one shard's catalog to a coordinator.
+type Worker struct {
+	Catalog *godb.Catalog
+	BufPool *godb.BufferPool
+}
+
+// NewWorker returns a worker over c and bp.
+func NewWorker(c *godb.Catalog, bp *godb.BufferPool) *Worker {
+	return &Worker{Catalog: c, BufPool: bp}
+}
+
+// ListenAndServe accepts coordinator connections on addr until the
+// listener fails.
+func (wk *Worker) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return wk.Serve(ln)
+}
+
+// Serve accepts connections from ln, one request per connection.
+func (wk *Worker) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			wk.handleConn(conn)
+		}()
+	}
+}
+
+func (wk *Worker) handleConn(conn net.Conn) {
+	enc := json.NewEncoder(conn)
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		enc.Encode(response{Done: true, Error: err.Error()})
+		return
+	}
+	if err := wk.handle(&req, enc); err != nil {
+		enc.Encode(response{Done: true, Error: err.Error()})
+		return
+	}
+	enc.Encode(response{Done: true})
+}
+
+func (wk *Worker) handle(req *request, enc *json.Encoder) error {
+	f, err := wk.Catalog.GetTable(req.Table)
+	if err != nil {
+		return err
+	}
+	tid := godb.NewTID()
+	if err := wk.BufPool.BeginTransaction(tid); err != nil {
+		return err
+	}
+	err = func() error {
+		switch req.Op {
+		case "insert":
+			t, err := decodeRow(f.Descriptor(), req.Tuple)
+			if err != nil {
+				return err
+			}
+			return godb.InsertTuple(f, t, tid)
+		case "schema":
+			for _, ft := range f.Descriptor().Fields {
+				name, typ := ft.Fname, ft.Ftype.String()
+				if err := enc.Encode(response{Row: []wireValue{{Str: &name}, {Str: &typ}}}); err != nil {
+					return err
+				}
+			}
+			return nil
+		case "scan":
+			return wk.scan(f, req.Filter, tid, enc)
+		case "agg":
+			return wk.agg(f, req, tid, enc)
+		}
+		return fmt.Errorf("distributed: unknown request op %q", req.Op)
+	}()
+	if err != nil {
+		wk.BufPool.AbortTransaction(tid)
+		return err
+	}
+	wk.BufPool.CommitTransaction(tid)
+	return nil
+}
+
+// planFor builds the shard-local plan: the table scan with the pushed
+// filter applied, if any.
+func planFor(f godb.DBFile, filter *Filter) (godb.Operator, error) {
+	if filter == nil {
+		return f, nil
+	}
+	ft, err := fieldByName(f.Descriptor(), filter.Field)
+	if err != nil {
+		return nil, err
+	}
+	v, err := filter.value()
+	if err != nil {
+		return nil, err
+	}
+	op, err := boolOpFor(filter.Op)
+	if err != nil {
+		return nil, err
+	}
+	return godb.NewFilter(godb.NewConstExpr(v, ft.Ftype), op, godb.NewFieldExpr(ft), f)
+}
+
+func (wk *Worker) scan(f godb.DBFile, filter *Filter, tid godb.TransactionID, enc *json.Encoder) error {
+	plan, err := planFor(f, filter)
+	if err != nil {
+		return err
+	}
+	iter, err := plan.Iterator(tid)
+	if err != nil {
+		return err
+	}
+	for {
+		t, err := iter()
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			return nil
+		}
+		row := make([]wireValue, len(t.Fields))
+		for i, v := range t.Fields {
+			row[i] = encodeValue(v)
+		}
+		if err := enc.Encode(response{Row: row}); err != nil {
+			return err
+		}
+	}
+}
+
+// agg computes the shard's partial aggregate plus the number of rows it
+// covered, so the coordinator can tell an empty shard's MIN/MAX from a
+// real zero.
+func (wk *Worker) agg(f godb.DBFile, req *request, tid godb.TransactionID, enc *json.Encoder) error {
+	plan, err := planFor(f, req.Filter)
+	if err != nil {
+		return err
+	}
+	ft, err := fieldByName(f.Descriptor(), req.Field)
+	if err != nil {
+		return err
+	}
+	expr := godb.NewFieldExpr(ft)
+	var st godb.AggState
+	switch req.Agg {
+	case "count":
+		st = &godb.CountAggState{}
+	case "sum":
+		st = &godb.SumAggState{}
+	case "min":
+		st = &godb.MinAggState{}
+	case "max":
+		st = &godb.MaxAggState{}
+	default:
+		return fmt.Errorf("distributed: unsupported aggregate %q", req.Agg)
+	}
+	if err := st.Init(req.Agg, expr); err != nil {
+		return err
+	}
+	cnt := &godb.CountAggState{}
+	if err := cnt.Init("n", expr); err != nil {
+		return err
+	}
+	iter, err := godb.NewAggregator([]godb.AggState{st, cnt}, plan).Iterator(tid)
+	if err != nil {
+		return err
+	}
+	t, err := iter()
+	if err != nil {
+		return err
+	}
+	if t == nil || len(t.Fields) != 2 {
+		return fmt.Errorf("distributed: aggregate produced no row")
+	}
+	v := encodeValue(t.Fields[0])
+	n, ok := t.Fields[1].(godb.IntField)
+	if !ok {
+		return fmt.Errorf("distributed: aggregate count is not an int")
+	}
+	return enc.Encode(response{Value: &v, Count: n.Value})
+}
+
+// Coordinator partitions tables over a fixed list of shard workers.
+type Coordinator struct {
+	shards []string
+}
+
+// NewCoordinator returns a coordinator over the given worker addresses.
+// The shard list is the partitioning function: changing its length or
+// order re-homes rows, so it must match across restarts.
+func NewCoordinator(shardAddrs []string) *Coordinator {
+	return &Coordinator{shards: shardAddrs}
+}
+
+// NumShards returns the number of shards.
+func (c *Coordinator) NumShards() int {
+	return len(c.shards)
+}
+
+// shardFor hashes a key value to a shard index.
+func (c *Coordinator) shardFor(key godb.DBValue) int {
+	h := fnv.New64a()
+	switch v := key.(type) {
+	case godb.IntField:
+		fmt.Fprintf(h, "i:%d", v.Value)
+	case godb.StringField:
+		fmt.Fprintf(h, "s:%s", v.Value)
+	}
+	return int(h.Sum64() % uint64(len(c.shards)))
+}
+
+// Insert routes t to the shard owning its key and inserts it there.
+func (c *Coordinator) Insert(table, keyField string, t *godb.Tuple) error {
+	ft, err := fieldByName(&t.Desc, keyField)
+	if err != nil {
+		return err
+	}
+	var key godb.DBValue
+	for i, f := range t.Desc.Fields {
+		if f.Fname == ft.Fname {
+			key = t.Fields[i]
+			break
+		}
+	}
+	row := make([]wireValue, len(t.Fields))
+	for i, v := range t.Fields {
+		row[i] = encodeValue(v)
+	}
+	addr := c.shards[c.shardFor(key)]
+	_, err = ask(addr, &request{Op: "insert", Table: table, Tuple: row})
+	return err
+}
+
+// Schema fetches a table's descriptor from the first shard.
+func (c *Coordinator) Schema(table string) (*godb.TupleDesc, error) {
+	rows, err := ask(c.shards[0], &request{Op: "schema", Table: table})
+	if err != nil {
+		return nil, err
+	}
+	desc := &godb.TupleDesc{}
+	for _, r := range rows {
+		if len(r.Row) != 2 || r.Row[0].Str == nil || r.Row[1].Str == nil {
+			return nil, fmt.Errorf("distributed: bad schema row")
+		}
+		ft := godb.FieldType{Fname: *r.Row[0].Str, TableQualifier: table}
+		if *r.Row[1].Str == "int" {
+			ft.Ftype = godb.IntType
+		} else {
+			ft.Ftype = godb.StringType
+		}
+		desc.Fields = append(desc.Fields, ft)
+	}
+	return desc, nil
+}
+
+// Scan runs a filtered scan on every shard in parallel and returns the
+// merged rows (in no particular order).
+func (c *Coordinator) Scan(table string, filter *Filter) ([]*godb.Tuple, error) {
+	desc, err := c.Schema(table)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := c.askAll(&request{Op: "scan", Table: table, Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+	var tuples []*godb.Tuple
+	for _, shard := range responses {
+		for _, r := range shard {
+			t, err := decodeRow(desc, r.Row)
+			if err != nil {
+				return nil, err
+			}
+			tuples = append(tuples, t)
+		}
+	}
+	return tuples, nil
+}
+
+// Count returns the number of rows matching filter across all shards.
+func (c *Coordinator) Count(table, field string, filter *Filter) (int64, error) {
+	parts, err := c.partials(table, "count", field, filter)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, p := range parts {
+		total += p.Count
+	}
+	return total, nil
+}
+
+// Sum returns the sum of field over the rows matching filter.
+func (c *Coordinator) Sum(table, field string, filter *Filter) (int64, error) {
+	parts, err := c.partials(table, "sum", field, filter)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, p := range parts {
+		v, err := p.Value.decode()
+		if err != nil {
+			return 0, err
+		}
+		n, ok := v.(godb.IntField)
+		if !ok {
+			return 0, fmt.Errorf("distributed: SUM over a non-int column")
+		}
+		total += n.Value
+	}
+	return total, nil
+}
+
+// Min returns the minimum of field over the rows matching filter, or nil
+// if no rows match.
+func (c *Coordinator) Min(table, field string, filter *Filter) (godb.DBValue, error) {
+	return c.extreme(table, "min", field, filter)
+}
+
+// Max returns the maximum of field over the rows matching filter, or nil
+// if no rows match.
+func (c *Coordinator) Max(table, field string, filter *Filter) (godb.DBValue, error) {
+	return c.extreme(table, "max", field, filter)
+}
+
+func (c *Coordinator) extreme(table, agg, field string, filter *Filter) (godb.DBValue, error) {
+	parts, err := c.partials(table, agg, field, filter)
+	if err != nil {
+		return nil, err
+	}
+	var best godb.DBValue
+	for _, p := range parts {
+		if p.Count == 0 {
+			continue
+		}
+		v, err := p.Value.decode()
+		if err != nil {
+			return nil, err
+		}
+		if best == nil || better(v, best, agg == "min") {
+			best = v
+		}
+	}
+	return best, nil
+}
+
+// better reports whether a beats b for the given direction.
+func better(a, b godb.DBValue, min bool) bool {
+	switch x := a.(type) {
+	case godb.IntField:
+		y, ok := b.(godb.IntField)
+		if !ok {
+			return false
+		}
+		if min {
+			return x.Value < y.Value
+		}
+		return x.Value > y.Value
+	case godb.StringField:
+		y, ok := b.(godb.StringField)
+		if !ok {
+			return false
+		}
+		if min {
+			return x.Value < y.Value
+		}
+		return x.Value > y.Value
+	}
+	return false
+}
+
+// partials runs one aggregate request on every shard and collects the
+// per-shard results.
+func (c *Coordinator) partials(table, agg, field string, filter *Filter) ([]response, error) {
+	responses, err := c.askAll(&request{Op: "agg", Table: table, Filter: filter, Agg: agg, Field: field})
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]response, 0, len(responses))
+	for i, shard := range responses {
+		found := false
+		for _, r := range shard {
+			if r.Value != nil {
+				parts = append(parts, r)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("distributed: shard %d returned no aggregate", i)
+		}
+	}
+	return parts, nil
+}
+
+// askAll sends one request to every shard in parallel.
+func (c *Coordinator) askAll(req *request) ([][]response, error) {
+	responses := make([][]response, len(c.shards))
+	errs := make([]error, len(c.shards))
+	var wg sync.WaitGroup
+	for i, addr := range c.shards {
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			responses[i], errs[i] = ask(addr, req)
+		}(i, addr)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return responses, nil
+}
+
+// ask sends one request to one shard and reads its responses up to the
+// done marker.
+func ask(addr string, req *request) ([]response, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(conn)
+	var out []response
+	for {
+		var resp response
+		if err := dec.Decode(&resp); err != nil {
+			return nil, fmt.Errorf("distributed: shard %s: %v", addr, err)
+		}
+		if resp.Done {
+			if resp.Error != "" {
+				return nil, fmt.Errorf("distributed: shard %s: %s", addr, resp.Error)
+			}
+			return out, nil
+		}
+		out = append(out, resp)
+	}
+}
+
+func decodeRow(desc *godb.TupleDesc, vals []wireValue) (*godb.Tuple, error) {
+	if len(vals) != len(desc.Fields) {
+		return nil, fmt.Errorf("distributed: row has %d fields, schema has %d", len(vals), len(desc.Fields))
+	}
+	fields := make([]godb.DBValue, len(vals))
+	for i, v := range vals {
+		f, err := v.decode()
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = f
+	}
+	return &godb.Tuple{Desc: *desc, Fields: fields}, nil
+}
+
+func fieldByName(desc *godb.TupleDesc, name string) (godb.FieldType, error) {
+	for _, f := range desc.Fields {
+		if f.Fname == name {
+			return f, nil
+		}
+	}
+	return godb.FieldType{}, fmt.Errorf("distributed: no field %q in schema", name)
+}
+
+func boolOpFor(op string) (godb.BoolOp, error) {
+	switch op {
+	case "=":
+		return godb.OpEq, nil
+	case "!=":
+		return godb.OpNeq, nil
+	case "<":
+		return godb.OpLt, nil
+	case "<=":
+		return godb.OpLe, nil
+	case ">":
+		return godb.OpGt, nil
+	case ">=":
+		return godb.OpGe, nil
+	}
+	return 0, fmt.Errorf("distributed: unsupported filter op %q", op)
+}
@@ -0,0 +1,3 @@
+module github.com/josephinelee1234/GoDB
+
+go 1.21.6
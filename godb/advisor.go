@@ -0,0 +1,372 @@
+package godb
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Adaptive storage advisor. The workload recorder counts, per table, how
+// often it is scanned versus point-written and which columns queries
+// reference; AdviseLayout turns those counts into layout recommendations
+// — a scan-heavy table read through a narrow column subset belongs in a
+// ColumnFile, a write- or delete-heavy one in a HeapFile — and
+// ConvertTableLayout performs the move. Recording rides the same
+// constrained-wrapper hooks as the change stream and table stats, so it
+// sees programmatic DML as well as parsed queries.
+
+// TableWorkload is the recorded access pattern of one table.
+type TableWorkload struct {
+	// Scans counts full iterations started over the table.
+	Scans int64
+	// Inserts and Deletes count point writes.
+	Inserts int64
+	Deletes int64
+	// Queries counts parsed query plans referencing the table, and
+	// ColumnTouches how many of those plans referenced each column.
+	Queries       int64
+	ColumnTouches map[string]int64
+}
+
+// workloadRecorder accumulates per-table workloads; nil on a catalog
+// until EnableWorkloadRecorder.
+type workloadRecorder struct {
+	mu     sync.Mutex
+	tables map[string]*TableWorkload
+}
+
+func (r *workloadRecorder) table(name string) *TableWorkload {
+	w, ok := r.tables[name]
+	if !ok {
+		w = &TableWorkload{ColumnTouches: make(map[string]int64)}
+		r.tables[name] = w
+	}
+	return w
+}
+
+// EnableWorkloadRecorder starts recording table access patterns. Existing
+// tables are wrapped so their scans and writes are counted; enable after
+// the catalog's tables are registered.
+func (c *Catalog) EnableWorkloadRecorder() error {
+	if c.workload == nil {
+		c.workload = &workloadRecorder{tables: make(map[string]*TableWorkload)}
+	}
+	for name := range c.tables {
+		if _, err := c.constrained(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Workload returns a copy of the recorded workload for table, or nil if
+// nothing has been recorded.
+func (c *Catalog) Workload(table string) *TableWorkload {
+	if c.workload == nil {
+		return nil
+	}
+	c.workload.mu.Lock()
+	defer c.workload.mu.Unlock()
+	w, ok := c.workload.tables[table]
+	if !ok {
+		return nil
+	}
+	out := *w
+	out.ColumnTouches = make(map[string]int64, len(w.ColumnTouches))
+	for k, v := range w.ColumnTouches {
+		out.ColumnTouches[k] = v
+	}
+	return &out
+}
+
+// recordTableScan counts one full iteration over table.
+func (c *Catalog) recordTableScan(table string) {
+	if c.workload == nil {
+		return
+	}
+	c.workload.mu.Lock()
+	c.workload.table(table).Scans++
+	c.workload.mu.Unlock()
+}
+
+// recordWorkloadWrite counts one point write on table.
+func (c *Catalog) recordWorkloadWrite(table string, kind ChangeKind) {
+	if c.workload == nil {
+		return
+	}
+	c.workload.mu.Lock()
+	w := c.workload.table(table)
+	if kind == ChangeInsert {
+		w.Inserts++
+	} else {
+		w.Deletes++
+	}
+	c.workload.mu.Unlock()
+}
+
+// Iterator counts the scan before delegating to the wrapped file, and
+// applies the table's TTL scan filter if one is enabled.
+func (f *constrainedFile) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	if f.catalog != nil {
+		f.catalog.recordTableScan(f.table)
+	}
+	iter, err := f.DBFile.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	iter = f.applyVirtual(iter)
+	if f.catalog != nil {
+		iter = f.catalog.applyTTLFilter(f.table, iter)
+		iter = f.catalog.bufPool.auditIterator(tid, f.table, iter)
+	}
+	return iter, nil
+}
+
+// NoteQuery records which tables and columns a query plan references.
+// ParseQuery calls it automatically when the recorder is enabled; callers
+// building plans programmatically can call it themselves.
+func (c *Catalog) NoteQuery(plan Operator) {
+	if c.workload == nil {
+		return
+	}
+	tables := make(map[string]map[string]bool)
+	walkPlanFields(plan, func(ft FieldType) {
+		if ft.TableQualifier == "" {
+			return
+		}
+		cols, ok := tables[ft.TableQualifier]
+		if !ok {
+			cols = make(map[string]bool)
+			tables[ft.TableQualifier] = cols
+		}
+		cols[ft.Fname] = true
+	})
+	c.workload.mu.Lock()
+	defer c.workload.mu.Unlock()
+	for table, cols := range tables {
+		w := c.workload.table(table)
+		w.Queries++
+		for col := range cols {
+			w.ColumnTouches[col]++
+		}
+	}
+}
+
+// walkPlanFields visits every field reference in a plan's expressions.
+// Operators and expressions outside the known set contribute nothing.
+func walkPlanFields(op Operator, visit func(FieldType)) {
+	switch o := op.(type) {
+	case *Filter:
+		walkExprFields(o.left, visit)
+		walkExprFields(o.right, visit)
+		walkPlanFields(o.child, visit)
+	case *Project:
+		for _, e := range o.selectFields {
+			walkExprFields(e, visit)
+		}
+		walkPlanFields(o.child, visit)
+	case *EqualityJoin:
+		walkExprFields(o.leftField, visit)
+		walkExprFields(o.rightField, visit)
+		walkPlanFields(*o.left, visit)
+		walkPlanFields(*o.right, visit)
+	case *Aggregator:
+		for _, e := range o.groupByFields {
+			walkExprFields(e, visit)
+		}
+		for _, st := range o.newAggState {
+			walkExprFields(aggStateExpr(st), visit)
+		}
+		walkPlanFields(o.child, visit)
+	case *OrderBy:
+		for _, e := range o.orderBy {
+			walkExprFields(e, visit)
+		}
+		walkPlanFields(o.child, visit)
+	case *LimitOp:
+		walkPlanFields(o.child, visit)
+	}
+}
+
+// aggStateExpr returns the input expression of a built-in aggregate
+// state, or nil for unknown implementations.
+func aggStateExpr(st AggState) Expr {
+	switch a := st.(type) {
+	case *CountAggState:
+		return a.expr
+	case *SumAggState:
+		return a.expr
+	case *AvgAggState:
+		return a.expr
+	case *MinAggState:
+		return a.expr
+	case *MaxAggState:
+		return a.expr
+	}
+	return nil
+}
+
+func walkExprFields(e Expr, visit func(FieldType)) {
+	if e == nil {
+		return
+	}
+	switch x := e.(type) {
+	case *FieldExpr:
+		visit(x.selectField)
+	case *ArithExpr:
+		walkExprFields(x.left, visit)
+		walkExprFields(x.right, visit)
+	}
+}
+
+// LayoutAdvice is one table's layout recommendation.
+type LayoutAdvice struct {
+	Table       string
+	Current     string // storage engine name
+	Recommended string
+	Reason      string
+}
+
+// adviceMinOps is the activity floor below which no advice is given;
+// a handful of operations says nothing about the workload.
+const adviceMinOps = 10
+
+// AdviseLayout recommends a storage layout per recorded table. Only
+// tables whose recorded activity clears a small floor, and whose
+// recommendation differs from their current layout, are returned.
+func (c *Catalog) AdviseLayout() []LayoutAdvice {
+	if c.workload == nil {
+		return nil
+	}
+	c.workload.mu.Lock()
+	defer c.workload.mu.Unlock()
+	var out []LayoutAdvice
+	for name, w := range c.workload.tables {
+		f, err := c.GetTable(name)
+		if err != nil {
+			continue
+		}
+		current := engineName(f)
+		if current != "heap" && current != "column" {
+			continue
+		}
+		writes := w.Inserts + w.Deletes
+		if w.Scans+writes < adviceMinOps {
+			continue
+		}
+		numCols := len(f.Descriptor().Fields)
+		frac := 1.0
+		if w.Queries > 0 && numCols > 0 {
+			frac = float64(len(w.ColumnTouches)) / float64(numCols)
+		}
+		switch {
+		case current == "column" && w.Deletes > 0:
+			out = append(out, LayoutAdvice{name, current, "heap",
+				"column files are append-only but the workload deletes rows"})
+		case current == "column" && writes > 4*w.Scans:
+			out = append(out, LayoutAdvice{name, current, "heap",
+				fmt.Sprintf("%d point writes vs %d scans favor the row layout", writes, w.Scans)})
+		case current == "heap" && w.Deletes == 0 && w.Scans > 4*writes && frac <= 0.5:
+			out = append(out, LayoutAdvice{name, current, "column",
+				fmt.Sprintf("%d scans vs %d writes touching %d of %d columns favor the column layout", w.Scans, writes, len(w.ColumnTouches), numCols)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Table < out[j].Table })
+	return out
+}
+
+// engineName names the storage engine backing f, unwrapping the
+// constraint wrapper.
+func engineName(f DBFile) string {
+	if cf, ok := f.(*constrainedFile); ok {
+		f = cf.DBFile
+	}
+	switch f.(type) {
+	case *HeapFile:
+		return "heap"
+	case *ColumnFile:
+		return "column"
+	case *MemFile:
+		return "memory"
+	}
+	return "other"
+}
+
+// ConvertTableLayout rebuilds table on the named storage engine, copying
+// every row and swapping the new file into the catalog. Heap and column
+// files keep their data under different file names, so the copy never
+// clobbers the source. The table must be quiesced: no transactions may
+// touch it while the conversion runs, and a clean buffer pool (after
+// FlushAllPages) is assumed.
+func (c *Catalog) ConvertTableLayout(table, engine string) error {
+	f, err := c.GetTable(table)
+	if err != nil {
+		return err
+	}
+	if engineName(f) == engine {
+		return GoDBError{IllegalOperationError, fmt.Sprintf("table %s is already stored as %s", table, engine)}
+	}
+	ctor, err := storageEngine(engine)
+	if err != nil {
+		return err
+	}
+	old := f
+	wrapper, wrapped := f.(*constrainedFile)
+	if wrapped {
+		old = wrapper.DBFile
+	}
+	newFile, err := ctor(c.tableFilePath(table), old.Descriptor().copy(), c.bufPool)
+	if err != nil {
+		return err
+	}
+	tid := NewTID()
+	if err := c.bufPool.BeginTransaction(tid); err != nil {
+		return err
+	}
+	err = func() error {
+		iter, err := old.Iterator(tid)
+		if err != nil {
+			return err
+		}
+		for {
+			t, err := iter()
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return nil
+			}
+			if err := newFile.insertTuple(&Tuple{Desc: t.Desc, Fields: t.Fields}, tid); err != nil {
+				return err
+			}
+		}
+	}()
+	if err != nil {
+		c.bufPool.AbortTransaction(tid)
+		return err
+	}
+	c.bufPool.CommitTransaction(tid)
+	c.bufPool.FlushAllPages()
+	if wrapped {
+		wrapper.DBFile = newFile
+	} else {
+		c.tables[table] = newFile
+	}
+	removeTableFiles(old, c.tableFilePath(table))
+	return nil
+}
+
+// removeTableFiles deletes the on-disk files of a replaced table; a
+// failure here only leaks disk space, so errors are ignored.
+func removeTableFiles(f DBFile, path string) {
+	switch old := f.(type) {
+	case *HeapFile:
+		os.Remove(old.fileName)
+	case *ColumnFile:
+		for i := range old.desc.Fields {
+			os.Remove(old.columnPath(i))
+		}
+		os.Remove(old.tierPath())
+	}
+}
@@ -0,0 +1,338 @@
+package godb
+
+import "fmt"
+
+// AggState accumulates one aggregate (COUNT, SUM, ...) over a stream of
+// tuples. Copy returns a fresh state for a new group.
+type AggState interface {
+	Init(alias string, expr Expr) error
+	Copy() AggState
+	AddTuple(t *Tuple)
+	Finalize() DBValue
+	GetTupleDesc() *TupleDesc
+}
+
+// CountAggState counts input tuples.
+type CountAggState struct {
+	alias string
+	expr  Expr
+	count int64
+}
+
+func (a *CountAggState) Init(alias string, expr Expr) error {
+	a.alias, a.expr, a.count = alias, expr, 0
+	return nil
+}
+
+func (a *CountAggState) Copy() AggState {
+	return &CountAggState{alias: a.alias, expr: a.expr}
+}
+
+func (a *CountAggState) AddTuple(t *Tuple) {
+	a.count++
+}
+
+func (a *CountAggState) Finalize() DBValue {
+	return IntField{a.count}
+}
+
+func (a *CountAggState) GetTupleDesc() *TupleDesc {
+	return &TupleDesc{Fields: []FieldType{{Fname: a.alias, Ftype: IntType}}}
+}
+
+// SumAggState sums a numeric expression. Decimal inputs accumulate on the
+// unscaled integers at the input's declared scale, so the sum is exact.
+type SumAggState struct {
+	alias string
+	expr  Expr
+	sum   int64
+	dec   bool
+	scale int
+}
+
+func (a *SumAggState) Init(alias string, expr Expr) error {
+	a.alias, a.expr, a.sum = alias, expr, 0
+	ft := expr.GetExprType()
+	a.dec, a.scale = ft.Ftype == DecimalType, ft.Scale
+	return nil
+}
+
+func (a *SumAggState) Copy() AggState {
+	return &SumAggState{alias: a.alias, expr: a.expr, dec: a.dec, scale: a.scale}
+}
+
+func (a *SumAggState) AddTuple(t *Tuple) {
+	v, err := a.expr.EvalExpr(t)
+	if err != nil {
+		return
+	}
+	switch x := v.(type) {
+	case IntField:
+		a.sum += x.Value
+	case DecimalField:
+		if d, err := x.rescale(a.scale); err == nil {
+			a.sum += d.Unscaled
+		}
+	}
+}
+
+func (a *SumAggState) Finalize() DBValue {
+	if a.dec {
+		return DecimalField{Unscaled: a.sum, Scale: a.scale}
+	}
+	return IntField{a.sum}
+}
+
+func (a *SumAggState) GetTupleDesc() *TupleDesc {
+	if a.dec {
+		return &TupleDesc{Fields: []FieldType{{Fname: a.alias, Ftype: DecimalType, Scale: a.scale}}}
+	}
+	return &TupleDesc{Fields: []FieldType{{Fname: a.alias, Ftype: IntType}}}
+}
+
+// AvgAggState averages a numeric expression with truncating division: int
+// inputs divide as ints, decimal inputs sum exactly on the unscaled
+// integers and truncate the quotient at the input's scale.
+type AvgAggState struct {
+	alias string
+	expr  Expr
+	sum   int64
+	count int64
+	dec   bool
+	scale int
+}
+
+func (a *AvgAggState) Init(alias string, expr Expr) error {
+	a.alias, a.expr, a.sum, a.count = alias, expr, 0, 0
+	ft := expr.GetExprType()
+	a.dec, a.scale = ft.Ftype == DecimalType, ft.Scale
+	return nil
+}
+
+func (a *AvgAggState) Copy() AggState {
+	return &AvgAggState{alias: a.alias, expr: a.expr, dec: a.dec, scale: a.scale}
+}
+
+func (a *AvgAggState) AddTuple(t *Tuple) {
+	v, err := a.expr.EvalExpr(t)
+	if err != nil {
+		return
+	}
+	switch x := v.(type) {
+	case IntField:
+		a.sum += x.Value
+		a.count++
+	case DecimalField:
+		if d, err := x.rescale(a.scale); err == nil {
+			a.sum += d.Unscaled
+			a.count++
+		}
+	}
+}
+
+func (a *AvgAggState) Finalize() DBValue {
+	avg := int64(0)
+	if a.count > 0 {
+		avg = a.sum / a.count
+	}
+	if a.dec {
+		return DecimalField{Unscaled: avg, Scale: a.scale}
+	}
+	return IntField{avg}
+}
+
+func (a *AvgAggState) GetTupleDesc() *TupleDesc {
+	if a.dec {
+		return &TupleDesc{Fields: []FieldType{{Fname: a.alias, Ftype: DecimalType, Scale: a.scale}}}
+	}
+	return &TupleDesc{Fields: []FieldType{{Fname: a.alias, Ftype: IntType}}}
+}
+
+// MinAggState tracks the minimum of an expression.
+type MinAggState struct {
+	alias string
+	expr  Expr
+	min   DBValue
+}
+
+func (a *MinAggState) Init(alias string, expr Expr) error {
+	a.alias, a.expr, a.min = alias, expr, nil
+	return nil
+}
+
+func (a *MinAggState) Copy() AggState {
+	return &MinAggState{alias: a.alias, expr: a.expr}
+}
+
+func (a *MinAggState) AddTuple(t *Tuple) {
+	v, err := a.expr.EvalExpr(t)
+	if err != nil {
+		return
+	}
+	if a.min == nil {
+		a.min = v
+		return
+	}
+	if less, err := compareValues(v, a.min, OpLt); err == nil && less {
+		a.min = v
+	}
+}
+
+func (a *MinAggState) Finalize() DBValue {
+	if a.min == nil {
+		return IntField{0}
+	}
+	return a.min
+}
+
+func (a *MinAggState) GetTupleDesc() *TupleDesc {
+	return &TupleDesc{Fields: []FieldType{{Fname: a.alias, Ftype: a.expr.GetExprType().Ftype}}}
+}
+
+// MaxAggState tracks the maximum of an expression.
+type MaxAggState struct {
+	alias string
+	expr  Expr
+	max   DBValue
+}
+
+func (a *MaxAggState) Init(alias string, expr Expr) error {
+	a.alias, a.expr, a.max = alias, expr, nil
+	return nil
+}
+
+func (a *MaxAggState) Copy() AggState {
+	return &MaxAggState{alias: a.alias, expr: a.expr}
+}
+
+func (a *MaxAggState) AddTuple(t *Tuple) {
+	v, err := a.expr.EvalExpr(t)
+	if err != nil {
+		return
+	}
+	if a.max == nil {
+		a.max = v
+		return
+	}
+	if more, err := compareValues(v, a.max, OpGt); err == nil && more {
+		a.max = v
+	}
+}
+
+func (a *MaxAggState) Finalize() DBValue {
+	if a.max == nil {
+		return IntField{0}
+	}
+	return a.max
+}
+
+func (a *MaxAggState) GetTupleDesc() *TupleDesc {
+	return &TupleDesc{Fields: []FieldType{{Fname: a.alias, Ftype: a.expr.GetExprType().Ftype}}}
+}
+
+// Aggregator computes one or more aggregates over its child, optionally
+// grouped by a list of expressions. It is blocking: the child is fully
+// consumed before the first result is produced.
+type Aggregator struct {
+	groupByFields []Expr
+	newAggState   []AggState
+	child         Operator
+}
+
+// NewAggregator returns an ungrouped aggregator over child.
+func NewAggregator(aggStates []AggState, child Operator) *Aggregator {
+	return &Aggregator{newAggState: aggStates, child: child}
+}
+
+// NewGroupedAggregator returns an aggregator grouped by groupByFields.
+func NewGroupedAggregator(aggStates []AggState, groupByFields []Expr, child Operator) *Aggregator {
+	return &Aggregator{groupByFields: groupByFields, newAggState: aggStates, child: child}
+}
+
+func (a *Aggregator) Descriptor() *TupleDesc {
+	fields := make([]FieldType, 0, len(a.groupByFields)+len(a.newAggState))
+	for _, e := range a.groupByFields {
+		fields = append(fields, e.GetExprType())
+	}
+	for _, s := range a.newAggState {
+		fields = append(fields, s.GetTupleDesc().Fields...)
+	}
+	return &TupleDesc{Fields: fields}
+}
+
+func (a *Aggregator) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	childIter, err := a.child.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	desc := a.Descriptor()
+	loaded := false
+	var results []*Tuple
+	i := 0
+	return func() (*Tuple, error) {
+		if !loaded {
+			groups := make(map[string][]AggState)
+			groupKeys := make(map[string][]DBValue)
+			var order []string
+			for {
+				t, err := childIter()
+				if err != nil {
+					return nil, err
+				}
+				if t == nil {
+					break
+				}
+				if err := chargeMaterialized(tid, t); err != nil {
+					return nil, err
+				}
+				keyVals := make([]DBValue, len(a.groupByFields))
+				key := ""
+				for gi, e := range a.groupByFields {
+					v, err := e.EvalExpr(t)
+					if err != nil {
+						return nil, err
+					}
+					keyVals[gi] = v
+					key += fmt.Sprintf("%v|", v)
+				}
+				states, ok := groups[key]
+				if !ok {
+					states = make([]AggState, len(a.newAggState))
+					for si, s := range a.newAggState {
+						states[si] = s.Copy()
+					}
+					groups[key] = states
+					groupKeys[key] = keyVals
+					order = append(order, key)
+				}
+				for _, s := range states {
+					s.AddTuple(t)
+				}
+			}
+			if len(a.groupByFields) == 0 && len(order) == 0 {
+				// Ungrouped aggregate over empty input still yields one row.
+				states := make([]AggState, len(a.newAggState))
+				for si, s := range a.newAggState {
+					states[si] = s.Copy()
+				}
+				groups[""] = states
+				groupKeys[""] = nil
+				order = append(order, "")
+			}
+			for _, key := range order {
+				fields := append([]DBValue{}, groupKeys[key]...)
+				for _, s := range groups[key] {
+					fields = append(fields, s.Finalize())
+				}
+				results = append(results, &Tuple{Desc: *desc.copy(), Fields: fields})
+			}
+			loaded = true
+		}
+		if i >= len(results) {
+			return nil, nil
+		}
+		t := results[i]
+		i++
+		return t, nil
+	}, nil
+}
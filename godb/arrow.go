@@ -0,0 +1,205 @@
+package godb
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Arrow interop. GoDB carries no external dependencies, so these helpers
+// do not return an arrow-go arrow.Record directly; instead ArrowBatch
+// holds raw buffers laid out exactly per the Arrow columnar format —
+// validity as a 1-bit-per-row LSB-ordered bitmap, int columns as
+// little-endian 64-bit data, string columns as int32 offsets plus UTF-8
+// data — so callers using arrow-go can wrap them in arrays without
+// copying (array.NewData over the buffers), and DataFrame tooling or
+// Arrow Flight can consume results without a CSV round trip.
+
+// ArrowColumn is one column of an ArrowBatch in Arrow physical layout.
+type ArrowColumn struct {
+	// Validity has one bit per row, least-significant bit first; a zero
+	// bit marks a null. Nil means the column contains no nulls.
+	Validity []byte
+	// Data holds little-endian int64 values for int columns, or the
+	// concatenated UTF-8 bytes for string columns.
+	Data []byte
+	// Offsets is rows+1 little-endian int32 offsets into Data; string
+	// columns only.
+	Offsets []byte
+}
+
+// ArrowBatch is a record batch: a schema plus one Arrow-layout column per
+// field, all of length NumRows.
+type ArrowBatch struct {
+	Schema  *TupleDesc
+	NumRows int
+	Columns []ArrowColumn
+}
+
+// ToArrowBatch drains op into one record batch. Null values get a cleared
+// validity bit (and a zero value in the data buffer, as Arrow permits).
+func ToArrowBatch(op Operator, tid TransactionID) (*ArrowBatch, error) {
+	iter, err := op.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	desc := op.Descriptor()
+	b := &ArrowBatch{Schema: desc.copy(), Columns: make([]ArrowColumn, len(desc.Fields))}
+	// Offsets buffers start with the leading zero Arrow requires.
+	for i, ft := range desc.Fields {
+		if ft.Ftype == StringType {
+			b.Columns[i].Offsets = make([]byte, 4)
+		}
+	}
+	for {
+		t, err := iter()
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			break
+		}
+		row := b.NumRows
+		for i, v := range t.Fields {
+			col := &b.Columns[i]
+			null := isNull(v)
+			if null && col.Validity == nil {
+				// First null: materialize the bitmap with every earlier
+				// row marked valid.
+				col.Validity = make([]byte, (row+8)/8)
+				for r := 0; r < row; r++ {
+					col.Validity[r/8] |= 1 << (r % 8)
+				}
+			}
+			if col.Validity != nil {
+				for row/8 >= len(col.Validity) {
+					col.Validity = append(col.Validity, 0)
+				}
+				if !null {
+					col.Validity[row/8] |= 1 << (row % 8)
+				}
+			}
+			switch desc.Fields[i].Ftype {
+			case IntType:
+				var x int64
+				if iv, ok := v.(IntField); ok {
+					x = iv.Value
+				} else if !null {
+					return nil, GoDBError{TypeMismatchError, fmt.Sprintf("non-int value %T in int column %s", v, desc.Fields[i].Fname)}
+				}
+				col.Data = binary.LittleEndian.AppendUint64(col.Data, uint64(x))
+			case StringType:
+				if sv, ok := v.(StringField); ok {
+					col.Data = append(col.Data, sv.Value...)
+				} else if !null {
+					return nil, GoDBError{TypeMismatchError, fmt.Sprintf("non-string value %T in string column %s", v, desc.Fields[i].Fname)}
+				}
+				col.Offsets = binary.LittleEndian.AppendUint32(col.Offsets, uint32(len(col.Data)))
+			}
+		}
+		b.NumRows++
+	}
+	return b, nil
+}
+
+// RowGroupToArrow converts one row group of the column file to a record
+// batch, copying straight from the typed column vectors via the batch
+// readers rather than materializing tuples.
+func (f *ColumnFile) RowGroupToArrow(rowGroup int, tid TransactionID) (*ArrowBatch, error) {
+	b := &ArrowBatch{Schema: f.desc.copy(), Columns: make([]ArrowColumn, len(f.desc.Fields))}
+	for colNo, ft := range f.desc.Fields {
+		col := &b.Columns[colNo]
+		var valid []bool
+		switch ft.Ftype {
+		case IntType:
+			vals, vd, err := f.ReadIntColumnBatch(colNo, rowGroup, tid)
+			if err != nil {
+				return nil, err
+			}
+			valid = vd
+			for _, v := range vals {
+				col.Data = binary.LittleEndian.AppendUint64(col.Data, uint64(v))
+			}
+		case StringType:
+			vals, vd, err := f.ReadStringColumnBatch(colNo, rowGroup, tid)
+			if err != nil {
+				return nil, err
+			}
+			valid = vd
+			col.Offsets = make([]byte, 4)
+			for _, s := range vals {
+				col.Data = append(col.Data, s...)
+				col.Offsets = binary.LittleEndian.AppendUint32(col.Offsets, uint32(len(col.Data)))
+			}
+		}
+		b.NumRows = len(valid)
+		for r, ok := range valid {
+			if !ok && col.Validity == nil {
+				col.Validity = make([]byte, (len(valid)+7)/8)
+				for i := 0; i < r; i++ {
+					col.Validity[i/8] |= 1 << (i % 8)
+				}
+			}
+			if col.Validity != nil && ok {
+				col.Validity[r/8] |= 1 << (r % 8)
+			}
+		}
+	}
+	return b, nil
+}
+
+// valueAt decodes the value of column col at row, honoring the validity
+// bitmap.
+func (b *ArrowBatch) valueAt(col, row int) (DBValue, error) {
+	c := &b.Columns[col]
+	if c.Validity != nil && c.Validity[row/8]&(1<<(row%8)) == 0 {
+		return NullField{}, nil
+	}
+	switch b.Schema.Fields[col].Ftype {
+	case IntType:
+		if (row+1)*8 > len(c.Data) {
+			return nil, GoDBError{MalformedDataError, fmt.Sprintf("arrow batch int column %d too short for row %d", col, row)}
+		}
+		return IntField{int64(binary.LittleEndian.Uint64(c.Data[row*8:]))}, nil
+	case StringType:
+		if (row+2)*4 > len(c.Offsets) {
+			return nil, GoDBError{MalformedDataError, fmt.Sprintf("arrow batch string column %d too short for row %d", col, row)}
+		}
+		lo := binary.LittleEndian.Uint32(c.Offsets[row*4:])
+		hi := binary.LittleEndian.Uint32(c.Offsets[(row+1)*4:])
+		if hi < lo || int(hi) > len(c.Data) {
+			return nil, GoDBError{MalformedDataError, fmt.Sprintf("arrow batch string column %d has bad offsets at row %d", col, row)}
+		}
+		return StringField{string(c.Data[lo:hi])}, nil
+	}
+	return nil, GoDBError{TypeMismatchError, "unknown arrow column type"}
+}
+
+// LoadFromArrow inserts every row of the batch into f on behalf of tid.
+// The batch schema's field types must match f's descriptor column for
+// column (names are not checked, matching LoadFromCSV's positional
+// semantics).
+func LoadFromArrow(f DBFile, b *ArrowBatch, tid TransactionID) error {
+	desc := f.Descriptor()
+	if len(b.Schema.Fields) != len(desc.Fields) {
+		return GoDBError{TypeMismatchError, fmt.Sprintf("arrow batch has %d columns, table has %d", len(b.Schema.Fields), len(desc.Fields))}
+	}
+	for i := range desc.Fields {
+		if b.Schema.Fields[i].Ftype != desc.Fields[i].Ftype {
+			return GoDBError{TypeMismatchError, fmt.Sprintf("arrow batch column %d type does not match table", i)}
+		}
+	}
+	for row := 0; row < b.NumRows; row++ {
+		vals := make([]DBValue, len(desc.Fields))
+		for col := range desc.Fields {
+			v, err := b.valueAt(col, row)
+			if err != nil {
+				return err
+			}
+			vals[col] = v
+		}
+		if err := f.insertTuple(&Tuple{Desc: *desc.copy(), Fields: vals}, tid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
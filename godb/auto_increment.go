@@ -0,0 +1,112 @@
+package godb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// autoIncrement allocates values for one auto-increment column. The next
+// value is persisted to a sequence file under the catalog root on every
+// allocation, so restarts never reuse ids (gaps from aborted transactions
+// are accepted, as in most databases).
+type autoIncrement struct {
+	col     FieldType
+	seqPath string
+	mu      sync.Mutex
+	next    int64
+}
+
+// SetAutoIncrement marks col of table as auto-increment. The column must be
+// an IntType. Inserts that leave the column zero have it filled with the
+// next sequence value; the assigned value is visible in the inserted
+// tuple's field after insertTuple returns. The sequence starts above the
+// largest value currently in the table.
+func (c *Catalog) SetAutoIncrement(table string, col string) error {
+	cf, err := c.constrained(table)
+	if err != nil {
+		return err
+	}
+	resolved, err := resolveCols(cf.Descriptor(), []string{col})
+	if err != nil {
+		return err
+	}
+	if resolved[0].Ftype != IntType {
+		return GoDBError{IllegalOperationError, fmt.Sprintf("auto-increment column %s must be an int", col)}
+	}
+	ai := &autoIncrement{col: resolved[0], seqPath: filepath.Join(c.rootPath, table+"."+col+".seq")}
+	if err := ai.load(cf, c); err != nil {
+		return err
+	}
+	cf.autoInc = ai
+	return nil
+}
+
+// load initializes the sequence from its file, or from the table's current
+// maximum if no sequence file exists yet.
+func (ai *autoIncrement) load(f DBFile, c *Catalog) error {
+	data, err := os.ReadFile(ai.seqPath)
+	if err == nil {
+		v, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return GoDBError{MalformedDataError, fmt.Sprintf("bad sequence file %s: %v", ai.seqPath, err)}
+		}
+		ai.next = v
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return GoDBError{OsError, err.Error()}
+	}
+	tid := NewTID()
+	if err := c.bufPool.BeginTransaction(tid); err != nil {
+		return err
+	}
+	defer c.bufPool.CommitTransaction(tid)
+	iter, err := f.Iterator(tid)
+	if err != nil {
+		return err
+	}
+	var max int64
+	for {
+		t, err := iter()
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			break
+		}
+		idx, err := findFieldInTd(ai.col, &t.Desc)
+		if err != nil {
+			return err
+		}
+		if v, ok := t.Fields[idx].(IntField); ok && v.Value > max {
+			max = v.Value
+		}
+	}
+	ai.next = max + 1
+	return nil
+}
+
+// fill assigns the next sequence value to t's auto-increment column if it
+// is zero, persisting the advanced sequence before returning.
+func (ai *autoIncrement) fill(t *Tuple) error {
+	idx, err := findFieldInTd(ai.col, &t.Desc)
+	if err != nil {
+		return err
+	}
+	if v, ok := t.Fields[idx].(IntField); !ok || v.Value != 0 {
+		return nil
+	}
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	v := ai.next
+	ai.next++
+	if err := os.WriteFile(ai.seqPath, []byte(strconv.FormatInt(ai.next, 10)), 0644); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	t.Fields[idx] = IntField{v}
+	return nil
+}
@@ -0,0 +1,230 @@
+package godb
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+)
+
+// Per-page Bloom filters let equality scans skip heap pages that provably
+// do not contain the probed key, without reading them. Filters are enabled
+// per column via EnableBloomFilter, rebuilt for a page whenever it is
+// flushed, and persisted in a sidecar file next to the data.
+
+// bloomFilter is a fixed-size Bloom filter using double hashing.
+type bloomFilter struct {
+	Bits      []uint64 `json:"bits"`
+	NumHashes int      `json:"k"`
+}
+
+func newBloomFilter(numBits, numHashes int) *bloomFilter {
+	return &bloomFilter{Bits: make([]uint64, (numBits+63)/64), NumHashes: numHashes}
+}
+
+func (b *bloomFilter) hashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	v := h.Sum64()
+	return v, v>>33 | 1 // force odd so probes cycle the whole table
+}
+
+func (b *bloomFilter) add(key string) {
+	m := uint64(len(b.Bits) * 64)
+	h1, h2 := b.hashes(key)
+	for i := 0; i < b.NumHashes; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		b.Bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (b *bloomFilter) mayContain(key string) bool {
+	m := uint64(len(b.Bits) * 64)
+	h1, h2 := b.hashes(key)
+	for i := 0; i < b.NumHashes; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		if b.Bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// tableBloom is the set of per-page filters for one column of a heap file.
+type tableBloom struct {
+	col     FieldType
+	numBits int
+	perPage []*bloomFilter // indexed by page number
+}
+
+const (
+	defaultBloomBits   = 1024
+	defaultBloomHashes = 4
+)
+
+// EnableBloomFilter builds per-page Bloom filters on col, backfilling them
+// from the current pages and keeping them current as pages are flushed.
+func (f *HeapFile) EnableBloomFilter(col string) error {
+	resolved, err := resolveCols(f.desc, []string{col})
+	if err != nil {
+		return err
+	}
+	tb := &tableBloom{col: resolved[0], numBits: defaultBloomBits}
+	if err := tb.load(f.bloomPath(col)); err != nil {
+		return err
+	}
+	if tb.perPage == nil {
+		for pgNo := 0; pgNo < f.NumPages(); pgNo++ {
+			pg, err := f.readPage(pgNo)
+			if err != nil {
+				return err
+			}
+			tb.addPage(pgNo, pg.(*heapPage))
+		}
+		if err := tb.save(f.bloomPath(col)); err != nil {
+			return err
+		}
+	}
+	if f.blooms == nil {
+		f.blooms = make(map[string]*tableBloom)
+	}
+	f.blooms[col] = tb
+	return nil
+}
+
+func (f *HeapFile) bloomPath(col string) string {
+	return f.fileName + "." + col + ".bloom"
+}
+
+// bloomFor returns the filter set for col, or nil if none is enabled.
+func (f *HeapFile) bloomFor(col string) *tableBloom {
+	return f.blooms[col]
+}
+
+// addPage rebuilds the filter for page pgNo from its live tuples.
+func (tb *tableBloom) addPage(pgNo int, p *heapPage) {
+	bf := newBloomFilter(tb.numBits, defaultBloomHashes)
+	p.latch.RLock()
+	defer p.latch.RUnlock()
+	for _, t := range p.tuples {
+		if t == nil {
+			continue
+		}
+		if idx, err := findFieldInTd(tb.col, &t.Desc); err == nil {
+			bf.add(encodeKey(t.Fields[idx]))
+		}
+	}
+	for len(tb.perPage) <= pgNo {
+		tb.perPage = append(tb.perPage, nil)
+	}
+	tb.perPage[pgNo] = bf
+}
+
+// pageMayContain reports whether page pgNo could hold key. Pages without a
+// filter yet (or beyond the known range) must be read.
+func (tb *tableBloom) pageMayContain(pgNo int, key DBValue) bool {
+	if pgNo >= len(tb.perPage) || tb.perPage[pgNo] == nil {
+		return true
+	}
+	return tb.perPage[pgNo].mayContain(encodeKey(key))
+}
+
+func (tb *tableBloom) save(path string) error {
+	buf, err := json.Marshal(tb.perPage)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf, 0644); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
+
+func (tb *tableBloom) load(path string) error {
+	buf, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	if err := json.Unmarshal(buf, &tb.perPage); err != nil {
+		return GoDBError{MalformedDataError, fmt.Sprintf("bad bloom sidecar %s: %v", path, err)}
+	}
+	return nil
+}
+
+// refreshBloomFilters recomputes the filters covering page p after a flush.
+func (f *HeapFile) refreshBloomFilters(p *heapPage) {
+	for col, tb := range f.blooms {
+		tb.addPage(p.pageNo, p)
+		// Persistence is best effort; a stale filter only costs a page read.
+		_ = tb.save(f.bloomPath(col))
+	}
+}
+
+// BloomScan scans a heap file for rows whose column equals key, skipping
+// pages whose Bloom filter rules the key out. Matching is probabilistic in
+// the skipping direction only, so callers still apply an exact filter on
+// top.
+type BloomScan struct {
+	file *HeapFile
+	col  FieldType
+	key  DBValue
+}
+
+// NewBloomScan returns a page-skipping scan of f for col = key. It falls
+// back to scanning every page if no filter is enabled on col.
+func NewBloomScan(f *HeapFile, col string, key DBValue) (*BloomScan, error) {
+	resolved, err := resolveCols(f.desc, []string{col})
+	if err != nil {
+		return nil, err
+	}
+	return &BloomScan{file: f, col: resolved[0], key: key}, nil
+}
+
+func (s *BloomScan) Descriptor() *TupleDesc {
+	return s.file.Descriptor()
+}
+
+func (s *BloomScan) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	tb := s.file.bloomFor(s.col.Fname)
+	pgNo := 0
+	var pageIter func() (*Tuple, error)
+	var pageVersion int32
+	return func() (*Tuple, error) {
+		for {
+			if pageIter == nil {
+				for {
+					if pgNo >= s.file.NumPages() {
+						return nil, nil
+					}
+					if tb == nil || tb.pageMayContain(pgNo, s.key) {
+						break
+					}
+					pgNo++
+				}
+				pg, err := s.file.bufPool.GetPage(s.file, pgNo, tid, ReadPerm)
+				if err != nil {
+					return nil, err
+				}
+				hp := pg.(*heapPage)
+				pageIter = hp.tupleIter()
+				pageVersion = hp.version
+				pgNo++
+			}
+			t, err := pageIter()
+			if err != nil {
+				return nil, err
+			}
+			if t != nil {
+				if t, err = s.file.upgradeTuple(t, pageVersion); err != nil {
+					return nil, err
+				}
+				metrics.TuplesScanned("bloom_scan", 1)
+				return t, nil
+			}
+			pageIter = nil
+		}
+	}, nil
+}
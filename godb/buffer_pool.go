@@ -0,0 +1,659 @@
+package godb
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BufferPool caches up to numPages pages in memory and mediates all page
+// access by transactions. It implements strict two-phase locking at page
+// granularity with a NO STEAL / FORCE policy: dirty pages are never evicted
+// before commit, and are flushed at commit. The page cache is sharded by
+// page-key hash so concurrent scans miss on different shards without
+// serializing behind one mutex; lock and transaction state stay global
+// under mu, which deadlock detection needs anyway.
+type BufferPool struct {
+	mu       sync.Mutex
+	numPages int
+	shards   []*poolShard
+	// lockCond wakes lock waiters exactly when locks are released, instead
+	// of the sleep-poll loop this code used to run.
+	lockCond *sync.Cond
+	// capMu guards pageCount, the pool-wide cached page total; capacity is
+	// enforced globally so shard imbalance never rejects a fetch early.
+	capMu     sync.Mutex
+	pageCount int
+
+	// lockState maps page keys to the set of transactions holding a lock on
+	// the page; a write lock is held by exactly one transaction.
+	lockState map[any]*pageLock
+	// waitsFor records, for each waiting transaction, the transactions it is
+	// blocked behind; used for deadlock detection.
+	waitsFor map[TransactionID]map[TransactionID]bool
+	// queuedOn maps each queued transaction to the page key it is queued
+	// on, so error paths and lock release can withdraw the request.
+	queuedOn map[TransactionID]any
+	// pagesHeld maps each running transaction to the page keys it has locked.
+	pagesHeld map[TransactionID]map[any]bool
+	// fileLocks maps file lock keys to their multi-granularity lock state;
+	// filesHeld mirrors pagesHeld for file-level locks.
+	fileLocks map[any]*fileLock
+	filesHeld map[TransactionID]map[any]bool
+	// filePages groups each transaction's page locks by file lock key, and
+	// escalateAt is the per-file page lock count above which the lock is
+	// escalated to file granularity (0 disables escalation).
+	filePages  map[TransactionID]map[any]map[any]bool
+	escalateAt int
+	// running maps each live transaction to its start time; readOnly marks
+	// the subset started with BeginReadOnlyTransaction (nil until the
+	// first).
+	running  map[TransactionID]time.Time
+	readOnly map[TransactionID]bool
+	// victimPolicy picks the transaction aborted on deadlock; doomed maps
+	// transactions marked for abort (deadlock victims, admin kills) to the
+	// error their next page access returns.
+	victimPolicy DeadlockVictimPolicy
+	doomed       map[TransactionID]GoDBError
+	// closed blocks new transactions once Close has been called.
+	closed bool
+	// durability holds the DurabilityMode; read atomically because
+	// flushPage consults it both inside and outside bp.mu.
+	durability atomic.Int32
+	// commitHooks are invoked after every transaction outcome (true for
+	// commit, false for abort), outside the pool's lock.
+	commitHooks []func(tid TransactionID, committed bool)
+	// leaks holds the iterator leak audit state; nil until EnableLeakAudit.
+	leaks *leakAudit
+}
+
+type pageLock struct {
+	holders map[TransactionID]bool
+	writer  TransactionID
+	// queue orders blocked requesters first-come first-served: a new
+	// reader may not overtake a queued writer, so a stream of readers
+	// re-acquiring shared locks cannot starve a writer indefinitely.
+	queue []*lockWaiter
+}
+
+// lockWaiter is one queued lock request.
+type lockWaiter struct {
+	tid  TransactionID
+	perm RWPerm
+}
+
+// enqueue appends tid to the wait queue if not already present.
+func (pl *pageLock) enqueue(tid TransactionID, perm RWPerm) {
+	for _, w := range pl.queue {
+		if w.tid == tid {
+			return
+		}
+	}
+	pl.queue = append(pl.queue, &lockWaiter{tid: tid, perm: perm})
+}
+
+// dequeue removes tid from the wait queue.
+func (pl *pageLock) dequeue(tid TransactionID) {
+	for i, w := range pl.queue {
+		if w.tid == tid {
+			pl.queue = append(pl.queue[:i], pl.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// writerQueuedBefore returns the first queued writer ahead of tid's queue
+// position (or anywhere, if tid is not queued), nil if none.
+func (pl *pageLock) writerQueuedBefore(tid TransactionID) TransactionID {
+	for _, w := range pl.queue {
+		if w.tid == tid {
+			return nil
+		}
+		if w.perm == WritePerm {
+			return w.tid
+		}
+	}
+	return nil
+}
+
+// poolShard is one partition of the page cache.
+type poolShard struct {
+	mu    sync.Mutex
+	pages map[any]Page
+}
+
+// defaultPoolShards is the page cache shard count used by NewBufferPool.
+const defaultPoolShards = 8
+
+// NewBufferPool returns a buffer pool caching at most numPages pages.
+func NewBufferPool(numPages int) *BufferPool {
+	return NewShardedBufferPool(numPages, defaultPoolShards)
+}
+
+// NewShardedBufferPool returns a buffer pool with an explicit page cache
+// shard count; nShards = 1 reproduces the unsharded design for comparison.
+func NewShardedBufferPool(numPages, nShards int) *BufferPool {
+	if nShards < 1 {
+		nShards = 1
+	}
+	if nShards > numPages {
+		nShards = numPages
+	}
+	shards := make([]*poolShard, nShards)
+	for i := range shards {
+		shards[i] = &poolShard{pages: make(map[any]Page)}
+	}
+	bp := &BufferPool{
+		numPages:  numPages,
+		shards:    shards,
+		lockState: make(map[any]*pageLock),
+		waitsFor:  make(map[TransactionID]map[TransactionID]bool),
+		queuedOn:  make(map[TransactionID]any),
+		pagesHeld: make(map[TransactionID]map[any]bool),
+		fileLocks: make(map[any]*fileLock),
+		filesHeld: make(map[TransactionID]map[any]bool),
+		filePages: make(map[TransactionID]map[any]map[any]bool),
+		running:   make(map[TransactionID]time.Time),
+		doomed:    make(map[TransactionID]GoDBError),
+	}
+	bp.lockCond = sync.NewCond(&bp.mu)
+	return bp
+}
+
+// BeginTransaction registers tid as a running transaction.
+func (bp *BufferPool) BeginTransaction(tid TransactionID) error {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	if bp.closed {
+		return GoDBError{IllegalTransactionError, "buffer pool is closed"}
+	}
+	if _, ok := bp.running[tid]; ok {
+		return GoDBError{IllegalTransactionError, "transaction already running"}
+	}
+	bp.running[tid] = time.Now()
+	return nil
+}
+
+// RegisterCommitHook adds a function called after every transaction
+// outcome; committed is true for commits, false for aborts. Hooks run on
+// the committing goroutine, outside the pool's lock.
+func (bp *BufferPool) RegisterCommitHook(hook func(tid TransactionID, committed bool)) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	bp.commitHooks = append(bp.commitHooks, hook)
+}
+
+func (bp *BufferPool) runCommitHooks(tid TransactionID, committed bool) {
+	bp.mu.Lock()
+	hooks := bp.commitHooks
+	bp.mu.Unlock()
+	for _, h := range hooks {
+		h(tid, committed)
+	}
+}
+
+// CommitTransaction flushes all pages dirtied by tid and releases its locks.
+// Under DurabilitySyncOnCommit each file flushed to is fsynced once.
+func (bp *BufferPool) CommitTransaction(tid TransactionID) {
+	var syncFiles map[DBFile]bool
+	bp.mu.Lock()
+	// Read-only transactions dirty nothing, so their commit skips the
+	// flush scan and fsyncs outright.
+	if !bp.readOnly[tid] {
+		for key := range bp.pagesHeld[tid] {
+			if pg, ok := bp.cachedPage(key); ok && pg.isDirty() {
+				pg.getFile().flushPage(pg)
+				if bp.syncOnCommit() {
+					if syncFiles == nil {
+						syncFiles = make(map[DBFile]bool)
+					}
+					syncFiles[pg.getFile()] = true
+				}
+			}
+		}
+		for file := range syncFiles {
+			if err := file.Sync(); err != nil {
+				logger.Debugf("commit tid %d: sync failed: %v", *tid, err)
+			}
+		}
+	}
+	bp.releaseLocks(tid)
+	delete(bp.running, tid)
+	bp.mu.Unlock()
+	metrics.TransactionCommitted()
+	bp.runCommitHooks(tid, true)
+}
+
+// AbortTransaction discards all pages dirtied by tid and releases its locks.
+// Because of NO STEAL, dropping the cached copies is sufficient to undo the
+// transaction's writes.
+func (bp *BufferPool) AbortTransaction(tid TransactionID) {
+	bp.mu.Lock()
+	// Only pages this transaction dirtied are discarded; a dirty page merely
+	// locked by tid (possible under an escalated file lock) belongs to its
+	// dirtier.
+	for key := range bp.pagesHeld[tid] {
+		if pg, ok := bp.cachedPage(key); ok && pg.dirtier() == tid {
+			bp.removeCached(key)
+		}
+	}
+	bp.releaseLocks(tid)
+	delete(bp.running, tid)
+	bp.mu.Unlock()
+	metrics.TransactionAborted()
+	bp.runCommitHooks(tid, false)
+}
+
+// releaseLocks drops all locks held by tid, along with any still-queued
+// lock request. Caller holds bp.mu.
+func (bp *BufferPool) releaseLocks(tid TransactionID) {
+	bp.removeFromQueue(tid)
+	for key := range bp.pagesHeld[tid] {
+		if pl, ok := bp.lockState[key]; ok {
+			delete(pl.holders, tid)
+			if pl.writer == tid {
+				pl.writer = nil
+			}
+			if len(pl.holders) == 0 && len(pl.queue) == 0 {
+				delete(bp.lockState, key)
+			}
+		}
+	}
+	for key := range bp.filesHeld[tid] {
+		if fl, ok := bp.fileLocks[key]; ok {
+			delete(fl.holders, tid)
+			if len(fl.holders) == 0 {
+				delete(bp.fileLocks, key)
+			}
+		}
+	}
+	delete(bp.pagesHeld, tid)
+	delete(bp.filesHeld, tid)
+	delete(bp.filePages, tid)
+	delete(bp.waitsFor, tid)
+	delete(bp.doomed, tid)
+	delete(bp.readOnly, tid)
+	bp.lockCond.Broadcast()
+}
+
+// tryLock attempts to acquire a lock on key for tid with permission perm.
+// On conflict the request joins the page's FIFO wait queue and the
+// transactions blocking tid are returned; a blocked requester may also be
+// waiting behind an earlier queued writer, in which case that writer is
+// the blocker (keeping the waits-for graph chained correctly). Caller
+// holds bp.mu.
+func (bp *BufferPool) tryLock(key any, tid TransactionID, perm RWPerm) []TransactionID {
+	pl := bp.lockState[key]
+	if pl == nil {
+		pl = &pageLock{holders: make(map[TransactionID]bool)}
+		bp.lockState[key] = pl
+	}
+	// Re-acquisitions never queue: the lock is already held.
+	if pl.holders[tid] && (perm == ReadPerm || pl.writer == tid) {
+		return nil
+	}
+	var blockers []TransactionID
+	if perm == ReadPerm {
+		if pl.writer != nil && pl.writer != tid {
+			blockers = []TransactionID{pl.writer}
+		} else if w := pl.writerQueuedBefore(tid); w != nil {
+			blockers = []TransactionID{w}
+		}
+	} else {
+		for h := range pl.holders {
+			if h != tid {
+				blockers = append(blockers, h)
+			}
+		}
+		if len(blockers) == 0 && len(pl.queue) > 0 && pl.queue[0].tid != tid {
+			blockers = []TransactionID{pl.queue[0].tid}
+		}
+	}
+	if len(blockers) > 0 {
+		pl.enqueue(tid, perm)
+		bp.queuedOn[tid] = key
+		return blockers
+	}
+	pl.dequeue(tid)
+	delete(bp.queuedOn, tid)
+	if perm == WritePerm {
+		pl.writer = tid
+	}
+	pl.holders[tid] = true
+	if bp.pagesHeld[tid] == nil {
+		bp.pagesHeld[tid] = make(map[any]bool)
+	}
+	bp.pagesHeld[tid][key] = true
+	return nil
+}
+
+// removeFromQueue withdraws tid's queued lock request, if any. Caller
+// holds bp.mu.
+func (bp *BufferPool) removeFromQueue(tid TransactionID) {
+	key, ok := bp.queuedOn[tid]
+	if !ok {
+		return
+	}
+	delete(bp.queuedOn, tid)
+	if pl, ok := bp.lockState[key]; ok {
+		pl.dequeue(tid)
+		if len(pl.holders) == 0 && len(pl.queue) == 0 {
+			delete(bp.lockState, key)
+		}
+	}
+	bp.lockCond.Broadcast()
+}
+
+// GetPage returns page pageNo of file, reading it from disk if it is not
+// cached, after acquiring a lock with the requested permission on behalf of
+// tid. It blocks while the lock is held conflictingly by other transactions
+// and returns a DeadlockError if waiting would deadlock.
+func (bp *BufferPool) GetPage(file DBFile, pageNo int, tid TransactionID, perm RWPerm) (Page, error) {
+	if err := checkDeadline(tid); err != nil {
+		return nil, err
+	}
+	key := file.pageKey(pageNo)
+	// A page lock is announced at file granularity first: IS for reads, IX
+	// for writes, so file-level S/X locks conflict without enumerating pages.
+	intent := lockIS
+	if perm == WritePerm {
+		intent = lockIX
+	}
+	fk := fileLockKey{file: lockTarget(file)}
+	waitStart := time.Time{}
+	bp.mu.Lock()
+	if perm == WritePerm && bp.readOnly[tid] {
+		bp.mu.Unlock()
+		return nil, errReadOnlyWrite
+	}
+	for {
+		if derr, ok := bp.doomed[tid]; ok {
+			delete(bp.waitsFor, tid)
+			bp.removeFromQueue(tid)
+			bp.mu.Unlock()
+			return nil, derr
+		}
+		// A file-level lock covering perm (its own or from escalation)
+		// makes the page lock redundant. The page is still recorded in
+		// pagesHeld: commit and abort walk it to flush or discard the
+		// pages tid dirtied, and releaseLocks tolerates entries with no
+		// per-page lock state.
+		if bp.coveredByFileLock(fk, tid, perm) {
+			if bp.pagesHeld[tid] == nil {
+				bp.pagesHeld[tid] = make(map[any]bool)
+			}
+			bp.pagesHeld[tid][key] = true
+			bp.mu.Unlock()
+			return bp.fetchPage(file, pageNo, key)
+		}
+		blockers := bp.tryFileLock(fk, tid, intent)
+		if blockers == nil {
+			blockers = bp.tryLock(key, tid, perm)
+		}
+		if blockers == nil {
+			logger.Tracef("tid %d: acquired %v lock on %v", *tid, perm, key)
+			delete(bp.waitsFor, tid)
+			bp.trackPageLock(fk, key, tid)
+			bp.mu.Unlock()
+			return bp.fetchPage(file, pageNo, key)
+		}
+		metrics.LockWait()
+		if bp.waitsFor[tid] == nil {
+			bp.waitsFor[tid] = make(map[TransactionID]bool)
+		}
+		for _, b := range blockers {
+			bp.waitsFor[tid][b] = true
+		}
+		if cycle := bp.findDeadlockCycle(tid); cycle != nil {
+			victim := bp.chooseVictim(cycle)
+			if victim == tid {
+				delete(bp.waitsFor, tid)
+				bp.removeFromQueue(tid)
+				bp.mu.Unlock()
+				logger.Debugf("tid %d: deadlock detected waiting for %v; chosen as victim", *tid, key)
+				return nil, GoDBError{DeadlockError, "deadlock detected; aborting waiter"}
+			}
+			// Another cycle member was picked: doom it and keep waiting. It
+			// is blocked in this loop too, so the broadcast wakes it and it
+			// observes the doomed flag; its abort then breaks the cycle.
+			bp.doomed[victim] = GoDBError{DeadlockError, "chosen as deadlock victim; aborting"}
+			bp.lockCond.Broadcast()
+			logger.Debugf("tid %d: deadlock detected waiting for %v; dooming tid %d", *tid, key, *victim)
+		}
+		if waitStart.IsZero() {
+			waitStart = time.Now()
+		}
+		if err := bp.waitForLockRelease(tid, waitStart); err != nil {
+			delete(bp.waitsFor, tid)
+			bp.removeFromQueue(tid)
+			bp.mu.Unlock()
+			logger.Debugf("tid %d: gave up waiting for %v: %v", *tid, key, err)
+			return nil, err
+		}
+	}
+}
+
+// waitForLockRelease blocks tid until a lock is released (the condition
+// variable is broadcast by releaseLocks), honoring the transaction's
+// lock-wait timeout and statement deadline via a wake-up timer; without
+// either it waits indefinitely. A nil return means "retry"; spurious
+// wake-ups are harmless. Caller holds bp.mu, which Wait releases while
+// blocked.
+func (bp *BufferPool) waitForLockRelease(tid TransactionID, waitStart time.Time) error {
+	var deadline time.Time
+	if t := lockWaitTimeout(tid); t > 0 {
+		deadline = waitStart.Add(t)
+	}
+	if d, ok := statementDeadline(tid); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	if deadline.IsZero() {
+		bp.lockCond.Wait()
+		return nil
+	}
+	if remaining := time.Until(deadline); remaining > 0 {
+		timer := time.AfterFunc(remaining, bp.lockCond.Broadcast)
+		bp.lockCond.Wait()
+		timer.Stop()
+	}
+	if err := checkDeadline(tid); err != nil {
+		return err
+	}
+	if t := lockWaitTimeout(tid); t > 0 && time.Since(waitStart) >= t {
+		return GoDBError{TimeoutError, "lock wait timeout exceeded"}
+	}
+	return nil
+}
+
+// shardFor returns the cache shard responsible for key.
+func (bp *BufferPool) shardFor(key any) *poolShard {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%v", key)
+	return bp.shards[int(h.Sum32())%len(bp.shards)]
+}
+
+// cachedPage returns the cached page for key, if present.
+func (bp *BufferPool) cachedPage(key any) (Page, bool) {
+	s := bp.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pg, ok := s.pages[key]
+	return pg, ok
+}
+
+// removeCached drops key from the cache, if present.
+func (bp *BufferPool) removeCached(key any) {
+	s := bp.shardFor(key)
+	s.mu.Lock()
+	_, ok := s.pages[key]
+	delete(s.pages, key)
+	s.mu.Unlock()
+	if ok {
+		bp.capMu.Lock()
+		bp.pageCount--
+		bp.capMu.Unlock()
+	}
+}
+
+// fetchPage returns the cached page for key, reading it from disk on a
+// miss. The caller must already hold a lock covering the page; bp.mu is
+// not held here, so misses on different shards read from disk in parallel.
+func (bp *BufferPool) fetchPage(file DBFile, pageNo int, key any) (Page, error) {
+	s := bp.shardFor(key)
+	s.mu.Lock()
+	if pg, ok := s.pages[key]; ok {
+		s.mu.Unlock()
+		metrics.BufferPoolHit()
+		return pg, nil
+	}
+	s.mu.Unlock()
+	metrics.BufferPoolMiss()
+	// Reserve capacity before reading: the pool-wide limit is strict, with
+	// a global eviction pass once the target shard has no clean page.
+	bp.capMu.Lock()
+	for bp.pageCount >= bp.numPages {
+		if err := bp.evictAnyClean(s); err != nil {
+			bp.capMu.Unlock()
+			return nil, err
+		}
+	}
+	bp.pageCount++
+	bp.capMu.Unlock()
+	pg, err := file.readPage(pageNo)
+	if err != nil {
+		bp.capMu.Lock()
+		bp.pageCount--
+		bp.capMu.Unlock()
+		return nil, err
+	}
+	s.mu.Lock()
+	if existing, ok := s.pages[key]; ok {
+		// Another lock holder cached the page first; ours is identical.
+		s.mu.Unlock()
+		bp.capMu.Lock()
+		bp.pageCount--
+		bp.capMu.Unlock()
+		return existing, nil
+	}
+	s.pages[key] = pg
+	s.mu.Unlock()
+	return pg, nil
+}
+
+// evictAnyClean removes one clean page, looking in prefer's shard first and
+// falling back to a global pass. Dirty pages are never evicted (NO STEAL).
+// Caller holds bp.capMu and no shard locks.
+func (bp *BufferPool) evictAnyClean(prefer *poolShard) error {
+	shards := append([]*poolShard{prefer}, bp.shards...)
+	for i, s := range shards {
+		if i > 0 && s == prefer {
+			continue
+		}
+		s.mu.Lock()
+		for key, pg := range s.pages {
+			if !pg.isDirty() {
+				delete(s.pages, key)
+				s.mu.Unlock()
+				bp.pageCount--
+				metrics.BufferPoolEviction()
+				logger.Debugf("buffer pool: evicted page %v", key)
+				return nil
+			}
+		}
+		s.mu.Unlock()
+	}
+	return GoDBError{BufferPoolFullError, "all pages in buffer pool are dirty"}
+}
+
+// InvalidateFile discards every cached page belonging to file and marks
+// the transactions holding locks on it (page or file level) for abort, so
+// stale cached pages cannot resurrect data after the backing file changes
+// underneath the pool — a drop, truncate, compaction, or restore. A
+// constraint-checking wrapper is unwrapped to the backing file pages were
+// read through.
+func (bp *BufferPool) InvalidateFile(file DBFile) {
+	file = lockTarget(file)
+	bp.mu.Lock()
+	fk := fileLockKey{file: file}
+	if fl, ok := bp.fileLocks[fk]; ok {
+		for tid := range fl.holders {
+			bp.doomed[tid] = GoDBError{IllegalOperationError, "file was invalidated while the transaction held locks on it"}
+		}
+		bp.lockCond.Broadcast()
+	}
+	bp.mu.Unlock()
+	bp.capMu.Lock()
+	for _, s := range bp.shards {
+		s.mu.Lock()
+		for key, pg := range s.pages {
+			if pg.getFile() == file {
+				delete(s.pages, key)
+				bp.pageCount--
+			}
+		}
+		s.mu.Unlock()
+	}
+	bp.capMu.Unlock()
+}
+
+// dropAllPages discards every cached page without flushing. Callers must
+// ensure no transactions are running.
+func (bp *BufferPool) dropAllPages() {
+	bp.capMu.Lock()
+	defer bp.capMu.Unlock()
+	for _, s := range bp.shards {
+		s.mu.Lock()
+		s.pages = make(map[any]Page)
+		s.mu.Unlock()
+	}
+	bp.pageCount = 0
+}
+
+// FlushAllPages writes every cached page to disk, dirty or not. It is a
+// testing and shutdown aid and is not transactionally safe.
+func (bp *BufferPool) FlushAllPages() {
+	for _, s := range bp.shards {
+		s.mu.Lock()
+		for _, pg := range s.pages {
+			pg.getFile().flushPage(pg)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Close shuts the pool down for an embedding application: it refuses new
+// transactions, waits for running ones to commit or abort (up to timeout;
+// zero waits indefinitely), then flushes every cached page and empties the
+// cache. File handles are opened per operation, so none are held. If the
+// timeout expires with transactions still running, nothing is flushed and
+// a TimeoutError is returned; the stragglers can be killed with
+// KillTransaction and Close called again.
+func (bp *BufferPool) Close(timeout time.Duration) error {
+	bp.mu.Lock()
+	bp.closed = true
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for len(bp.running) > 0 {
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			n := len(bp.running)
+			bp.mu.Unlock()
+			return GoDBError{TimeoutError, fmt.Sprintf("%d transactions still running at close timeout", n)}
+		}
+		var timer *time.Timer
+		if !deadline.IsZero() {
+			timer = time.AfterFunc(time.Until(deadline), bp.lockCond.Broadcast)
+		}
+		bp.lockCond.Wait()
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	bp.mu.Unlock()
+	bp.FlushAllPages()
+	bp.dropAllPages()
+	return nil
+}
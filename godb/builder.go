@@ -0,0 +1,261 @@
+package godb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlanBuilder assembles an operator tree through a fluent chain:
+//
+//	plan, err := c.Scan("employees").
+//		Filter(Gt(F("age"), I(17))).
+//		Join(c.Scan("departments"), On("dept_id")).
+//		Project("name").
+//		OrderBy("name").
+//		Limit(10).
+//		Build()
+//
+// Each step validates its inputs against the child's descriptor — field
+// names must resolve, comparison and join-key types must match, projected
+// output names must be unique — so a bad plan fails at Build rather than
+// mid-iteration. The first error stops the chain; later steps are no-ops
+// and Build returns it.
+type PlanBuilder struct {
+	op  Operator
+	err error
+}
+
+// builderJoinBuffer is the join buffer size used for built plans; it
+// matches the parser's.
+const builderJoinBuffer = 10000
+
+// Scan starts a builder over the named table or view.
+func (c *Catalog) Scan(table string) *PlanBuilder {
+	op, err := c.tableOrView(table)
+	return &PlanBuilder{op: op, err: err}
+}
+
+// BuildPlan starts a builder over an existing operator, for plans whose
+// leaves are not catalog tables.
+func BuildPlan(op Operator) *PlanBuilder {
+	return &PlanBuilder{op: op}
+}
+
+// F references a field by name; "t.name" qualifies it with a table name.
+// The field's type is resolved when the expression is attached to a plan.
+func F(name string) *FieldExpr {
+	qualifier := ""
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		qualifier, name = name[:i], name[i+1:]
+	}
+	return &FieldExpr{selectField: FieldType{Fname: name, TableQualifier: qualifier}}
+}
+
+// I returns an int constant expression.
+func I(v int64) *ConstExpr {
+	return NewConstExpr(IntField{Value: v}, IntType)
+}
+
+// S returns a string constant expression.
+func S(v string) *ConstExpr {
+	return NewConstExpr(StringField{Value: v}, StringType)
+}
+
+// Pred is one comparison between two expressions, used by Filter.
+type Pred struct {
+	left  Expr
+	op    BoolOp
+	right Expr
+}
+
+// Gt builds the predicate left > right.
+func Gt(left, right Expr) Pred { return Pred{left, OpGt, right} }
+
+// Lt builds the predicate left < right.
+func Lt(left, right Expr) Pred { return Pred{left, OpLt, right} }
+
+// Ge builds the predicate left >= right.
+func Ge(left, right Expr) Pred { return Pred{left, OpGe, right} }
+
+// Le builds the predicate left <= right.
+func Le(left, right Expr) Pred { return Pred{left, OpLe, right} }
+
+// Eq builds the predicate left = right.
+func Eq(left, right Expr) Pred { return Pred{left, OpEq, right} }
+
+// Neq builds the predicate left != right.
+func Neq(left, right Expr) Pred { return Pred{left, OpNeq, right} }
+
+// JoinCond names the key columns of an equality join.
+type JoinCond struct {
+	left  string
+	right string
+}
+
+// On joins on a column with the same name on both sides.
+func On(field string) JoinCond {
+	return JoinCond{left: field, right: field}
+}
+
+// OnFields joins on differently named key columns.
+func OnFields(left, right string) JoinCond {
+	return JoinCond{left: left, right: right}
+}
+
+// fail records the builder's first error; later steps pass it through.
+func (b *PlanBuilder) fail(err error) *PlanBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// resolveExpr resolves every field reference in e against desc, filling in
+// each reference's declared type so GetExprType reports the table's type.
+// It returns the first unresolved or ambiguous reference's error.
+func resolveExpr(e Expr, desc *TupleDesc) error {
+	switch x := e.(type) {
+	case *FieldExpr:
+		idx, err := findFieldInTd(x.selectField, desc)
+		if err != nil {
+			return err
+		}
+		x.selectField = desc.Fields[idx]
+	case *ArithExpr:
+		if err := resolveExpr(x.left, desc); err != nil {
+			return err
+		}
+		return resolveExpr(x.right, desc)
+	}
+	return nil
+}
+
+// Filter keeps the tuples for which p holds.
+func (b *PlanBuilder) Filter(p Pred) *PlanBuilder {
+	if b.err != nil {
+		return b
+	}
+	desc := b.op.Descriptor()
+	if err := resolveExpr(p.left, desc); err != nil {
+		return b.fail(err)
+	}
+	if err := resolveExpr(p.right, desc); err != nil {
+		return b.fail(err)
+	}
+	lt, rt := p.left.GetExprType().Ftype, p.right.GetExprType().Ftype
+	if lt != rt {
+		return b.fail(GoDBError{TypeMismatchError, fmt.Sprintf("cannot compare %s to %s", lt, rt)})
+	}
+	f, err := NewFilter(p.right, p.op, p.left, b.op)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.op = f
+	return b
+}
+
+// Join equi-joins the plan with right on the condition's key columns.
+func (b *PlanBuilder) Join(right *PlanBuilder, cond JoinCond) *PlanBuilder {
+	if b.err != nil {
+		return b
+	}
+	if right.err != nil {
+		return b.fail(right.err)
+	}
+	leftKey := F(cond.left)
+	if err := resolveExpr(leftKey, b.op.Descriptor()); err != nil {
+		return b.fail(err)
+	}
+	rightKey := F(cond.right)
+	if err := resolveExpr(rightKey, right.op.Descriptor()); err != nil {
+		return b.fail(err)
+	}
+	lt, rt := leftKey.GetExprType().Ftype, rightKey.GetExprType().Ftype
+	if lt != rt {
+		return b.fail(GoDBError{TypeMismatchError,
+			fmt.Sprintf("join key types differ: %s is %s, %s is %s", cond.left, lt, cond.right, rt)})
+	}
+	j, err := NewJoin(b.op, leftKey, right.op, rightKey, builderJoinBuffer)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.op = j
+	return b
+}
+
+// Project keeps only the named columns, in the given order. Output names
+// are the bare column names and must be unique.
+func (b *PlanBuilder) Project(names ...string) *PlanBuilder {
+	if b.err != nil {
+		return b
+	}
+	desc := b.op.Descriptor()
+	exprs := make([]Expr, len(names))
+	outNames := make([]string, len(names))
+	seen := make(map[string]bool)
+	for i, name := range names {
+		fe := F(name)
+		if err := resolveExpr(fe, desc); err != nil {
+			return b.fail(err)
+		}
+		if out := fe.selectField.Fname; seen[out] {
+			return b.fail(GoDBError{DuplicateNameError, fmt.Sprintf("duplicate output name %s", out)})
+		} else {
+			seen[out] = true
+			outNames[i] = out
+		}
+		exprs[i] = fe
+	}
+	p, err := NewProjectOp(exprs, outNames, false, b.op)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.op = p
+	return b
+}
+
+// OrderBy sorts on the named columns, ascending by default; prefix a name
+// with '-' for descending ("-age").
+func (b *PlanBuilder) OrderBy(names ...string) *PlanBuilder {
+	if b.err != nil {
+		return b
+	}
+	desc := b.op.Descriptor()
+	exprs := make([]Expr, len(names))
+	ascending := make([]bool, len(names))
+	for i, name := range names {
+		ascending[i] = !strings.HasPrefix(name, "-")
+		fe := F(strings.TrimPrefix(name, "-"))
+		if err := resolveExpr(fe, desc); err != nil {
+			return b.fail(err)
+		}
+		exprs[i] = fe
+	}
+	o, err := NewOrderBy(exprs, b.op, ascending)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.op = o
+	return b
+}
+
+// Limit keeps at most n tuples.
+func (b *PlanBuilder) Limit(n int) *PlanBuilder {
+	if b.err != nil {
+		return b
+	}
+	if n < 0 {
+		return b.fail(GoDBError{IllegalOperationError, fmt.Sprintf("negative limit %d", n)})
+	}
+	b.op = NewLimitOp(I(int64(n)), b.op)
+	return b
+}
+
+// Build returns the assembled operator tree, or the first error recorded
+// by the chain.
+func (b *PlanBuilder) Build() (Operator, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.op, nil
+}
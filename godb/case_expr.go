@@ -0,0 +1,60 @@
+package godb
+
+import "fmt"
+
+// CaseWhen is one WHEN ... THEN ... arm of a searched CASE expression. The
+// predicate follows the repo's (left, op, right) form, with both sides
+// evaluated against the same input tuple.
+type CaseWhen struct {
+	Left  Expr
+	Op    BoolOp
+	Right Expr
+	Then  Expr
+}
+
+// CaseExpr is a searched CASE expression: the arms are tried in order and
+// the first whose predicate holds supplies the value; otherwise the ELSE
+// expression does. It is usable anywhere an Expr is, projections and
+// filters included.
+type CaseExpr struct {
+	whens    []CaseWhen
+	elseExpr Expr
+}
+
+// NewCaseExpr returns a CASE expression over the supplied arms. elseExpr is
+// required — without nullable values every input must produce something —
+// and every THEN must return the same type as the ELSE.
+func NewCaseExpr(whens []CaseWhen, elseExpr Expr) (*CaseExpr, error) {
+	if len(whens) == 0 {
+		return nil, GoDBError{IllegalOperationError, "case expression needs at least one WHEN arm"}
+	}
+	if elseExpr == nil {
+		return nil, GoDBError{IllegalOperationError, "case expression needs an ELSE arm"}
+	}
+	want := elseExpr.GetExprType().Ftype
+	for i, w := range whens {
+		if got := w.Then.GetExprType().Ftype; got != want {
+			return nil, GoDBError{TypeMismatchError, fmt.Sprintf("case arm %d returns a different type than ELSE", i)}
+		}
+	}
+	return &CaseExpr{whens: whens, elseExpr: elseExpr}, nil
+}
+
+func (c *CaseExpr) EvalExpr(t *Tuple) (DBValue, error) {
+	for _, w := range c.whens {
+		match, err := evalPred(t, w.Left, t, w.Right, w.Op)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			return w.Then.EvalExpr(t)
+		}
+	}
+	return c.elseExpr.EvalExpr(t)
+}
+
+func (c *CaseExpr) GetExprType() FieldType {
+	ft := c.elseExpr.GetExprType()
+	ft.Fname = "case"
+	return ft
+}
@@ -0,0 +1,61 @@
+package godb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CastExpr converts its child's value to another type at evaluation time,
+// the common case being CSV-derived string columns that are semantically
+// numeric. Int-to-string always succeeds; string-to-int trims surrounding
+// whitespace and fails with a TypeMismatchError when the text is not an
+// integer. Strings longer than StringLength are truncated like every other
+// string value in the system.
+type CastExpr struct {
+	child Expr
+	to    DBType
+}
+
+// NewCastExpr returns an expression casting child to type to.
+func NewCastExpr(child Expr, to DBType) *CastExpr {
+	return &CastExpr{child: child, to: to}
+}
+
+func (c *CastExpr) EvalExpr(t *Tuple) (DBValue, error) {
+	v, err := c.child.EvalExpr(t)
+	if err != nil {
+		return nil, err
+	}
+	switch c.to {
+	case IntType:
+		switch val := v.(type) {
+		case IntField:
+			return val, nil
+		case StringField:
+			n, err := strconv.ParseInt(strings.TrimSpace(val.Value), 10, 64)
+			if err != nil {
+				return nil, GoDBError{TypeMismatchError, fmt.Sprintf("cannot cast %q to int", val.Value)}
+			}
+			return IntField{n}, nil
+		}
+	case StringType:
+		switch val := v.(type) {
+		case StringField:
+			return val, nil
+		case IntField:
+			s := strconv.FormatInt(val.Value, 10)
+			if len(s) > StringLength {
+				s = s[:StringLength]
+			}
+			return StringField{s}, nil
+		}
+	}
+	return nil, GoDBError{TypeMismatchError, fmt.Sprintf("unsupported cast of %T", v)}
+}
+
+func (c *CastExpr) GetExprType() FieldType {
+	ft := c.child.GetExprType()
+	ft.Ftype = c.to
+	return ft
+}
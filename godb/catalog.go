@@ -0,0 +1,127 @@
+package godb
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Catalog tracks the tables of a database: each table has a name, a schema,
+// and a DBFile storing its tuples under the catalog's root directory.
+type Catalog struct {
+	bufPool  *BufferPool
+	rootPath string
+	tables   map[string]DBFile
+	// views maps view names to their stored SQL text.
+	views map[string]string
+	// cdc is the change stream for WatchTable subscribers; nil until the
+	// first subscription.
+	cdc *changeStream
+	// idxJournal buffers per-transaction secondary index maintenance for
+	// application at commit; nil until the first index is created.
+	idxJournal *indexJournal
+	// tableVersions counts modifications per table, for cache invalidation;
+	// versionMu guards it against concurrent writers.
+	versionMu     sync.Mutex
+	tableVersions map[string]int64
+	// prepared caches compiled statements by normalized query text.
+	preparedMu sync.Mutex
+	prepared   map[string]*PreparedQuery
+	// stats maintains per-table row counts and column bounds; nil until
+	// the first EnableTableStats.
+	stats *statsTracker
+	// workload records table access patterns for the storage advisor;
+	// nil until EnableWorkloadRecorder.
+	workload *workloadRecorder
+	// ttls holds per-table row expiry configuration; nil until the first
+	// SetTableTTL.
+	ttlMu sync.Mutex
+	ttls  map[string]*tableTTL
+	// cipherSecret, when set, encrypts newly created heap tables.
+	cipherSecret string
+}
+
+// NewCatalog returns an empty catalog whose table files live under rootPath.
+func NewCatalog(bufPool *BufferPool, rootPath string) *Catalog {
+	return &Catalog{bufPool: bufPool, rootPath: rootPath, tables: make(map[string]DBFile), tableVersions: make(map[string]int64)}
+}
+
+// tableFilePath returns the path of the heap file backing table name.
+func (c *Catalog) tableFilePath(name string) string {
+	return filepath.Join(c.rootPath, name+".dat")
+}
+
+// AddTable creates (or reopens) a heap file for a table with the supplied
+// schema and registers it under name.
+func (c *Catalog) AddTable(name string, desc TupleDesc) (DBFile, error) {
+	return c.AddTableWithEngine(name, desc, "heap")
+}
+
+// GetTable returns the DBFile registered under name.
+func (c *Catalog) GetTable(name string) (DBFile, error) {
+	f, ok := c.tables[name]
+	if !ok {
+		return nil, GoDBError{NoSuchTableError, fmt.Sprintf("no table %s", name)}
+	}
+	return f, nil
+}
+
+// TableNames returns the names of all registered tables.
+func (c *Catalog) TableNames() []string {
+	names := make([]string, 0, len(c.tables))
+	for n := range c.tables {
+		names = append(names, n)
+	}
+	return names
+}
+
+// LoadSchema populates the catalog from a schema file with one line per
+// table of the form:
+//
+//	tablename (field type, field type, ...)
+//
+// where type is "int" or "string".
+func (c *Catalog) LoadSchema(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		open := strings.IndexByte(line, '(')
+		close := strings.LastIndexByte(line, ')')
+		if open < 0 || close < open {
+			return GoDBError{ParseError, fmt.Sprintf("malformed catalog line %q", line)}
+		}
+		name := strings.TrimSpace(line[:open])
+		var fields []FieldType
+		for _, col := range strings.Split(line[open+1:close], ",") {
+			parts := strings.Fields(strings.TrimSpace(col))
+			if len(parts) != 2 {
+				return GoDBError{ParseError, fmt.Sprintf("malformed column %q in table %s", col, name)}
+			}
+			var ftype DBType
+			switch parts[1] {
+			case "int":
+				ftype = IntType
+			case "string":
+				ftype = StringType
+			default:
+				return GoDBError{ParseError, fmt.Sprintf("unknown type %q in table %s", parts[1], name)}
+			}
+			fields = append(fields, FieldType{Fname: parts[0], TableQualifier: name, Ftype: ftype})
+		}
+		if _, err := c.AddTable(name, TupleDesc{Fields: fields}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
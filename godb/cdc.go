@@ -0,0 +1,157 @@
+package godb
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Change data capture. WatchTable registers a channel that receives one
+// ChangeEvent per tuple inserted into or deleted from a table, delivered
+// when (and only when) the writing transaction commits; changes made by
+// aborted transactions are never emitted. Events are buffered per
+// transaction on the insert and delete paths and published from the
+// committing goroutine in execution order, so a slow subscriber applies
+// backpressure to commits rather than dropping events.
+
+// ChangeKind classifies a ChangeEvent.
+type ChangeKind int
+
+const (
+	ChangeInsert ChangeKind = iota
+	ChangeDelete
+	ChangeUpdate
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeInsert:
+		return "insert"
+	case ChangeDelete:
+		return "delete"
+	case ChangeUpdate:
+		return "update"
+	}
+	return "unknown"
+}
+
+// ChangeEvent describes one committed change to a watched table. For
+// updates, Tuple is the new image and Old the replaced one; for inserts
+// and deletes Old is nil.
+type ChangeEvent struct {
+	Table string
+	Kind  ChangeKind
+	Tuple *Tuple
+	Old   *Tuple
+}
+
+// changeStream tracks subscribers and per-transaction pending events.
+type changeStream struct {
+	mu       sync.Mutex
+	watchers map[string][]chan<- ChangeEvent
+	pending  map[TransactionID][]ChangeEvent
+}
+
+// WatchTable subscribes ch to committed changes on table. Delivery blocks
+// the committing transaction, so subscribers should keep the channel
+// drained (or buffered). The same channel may watch several tables.
+func (c *Catalog) WatchTable(table string, ch chan<- ChangeEvent) error {
+	if _, err := c.GetTable(table); err != nil {
+		return err
+	}
+	// Changes are recorded on the catalog's constrained wrapper; make sure
+	// the table has one even if it declares no constraints.
+	if _, err := c.constrained(table); err != nil {
+		return err
+	}
+	cs := c.changes()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.watchers[table] = append(cs.watchers[table], ch)
+	return nil
+}
+
+// UnwatchTable removes a subscription added with WatchTable.
+func (c *Catalog) UnwatchTable(table string, ch chan<- ChangeEvent) error {
+	cs := c.changes()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	subs := cs.watchers[table]
+	for i, sub := range subs {
+		if sub == ch {
+			cs.watchers[table] = append(subs[:i], subs[i+1:]...)
+			return nil
+		}
+	}
+	return GoDBError{NoSuchTableError, fmt.Sprintf("channel is not watching table %s", table)}
+}
+
+// changes lazily creates the catalog's change stream and hooks it into
+// transaction completion.
+func (c *Catalog) changes() *changeStream {
+	if c.cdc == nil {
+		c.cdc = &changeStream{
+			watchers: make(map[string][]chan<- ChangeEvent),
+			pending:  make(map[TransactionID][]ChangeEvent),
+		}
+		c.bufPool.RegisterCommitHook(c.cdc.transactionDone)
+	}
+	return c.cdc
+}
+
+// recordChange buffers a change made by tid for publication at commit.
+// Called from the constrained wrapper's insert and delete paths.
+func (c *Catalog) recordChange(tid TransactionID, table string, kind ChangeKind, t *Tuple) {
+	if c.cdc == nil {
+		return
+	}
+	cs := c.cdc
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if len(cs.watchers[table]) == 0 {
+		return
+	}
+	cs.pending[tid] = append(cs.pending[tid], ChangeEvent{Table: table, Kind: kind, Tuple: t})
+}
+
+// transactionDone publishes (on commit) or discards (on abort) the events
+// buffered for tid. A delete immediately followed by an insert on the
+// same table — the shape the update path produces — is coalesced into a
+// single ChangeUpdate event.
+func (cs *changeStream) transactionDone(tid TransactionID, committed bool) {
+	cs.mu.Lock()
+	events := cs.pending[tid]
+	delete(cs.pending, tid)
+	if !committed || len(events) == 0 {
+		cs.mu.Unlock()
+		return
+	}
+	events = coalesceUpdates(events)
+	// Snapshot the subscriber lists so delivery happens outside the lock.
+	subs := make(map[string][]chan<- ChangeEvent, len(cs.watchers))
+	for table, chans := range cs.watchers {
+		subs[table] = append([]chan<- ChangeEvent(nil), chans...)
+	}
+	cs.mu.Unlock()
+	for _, ev := range events {
+		for _, ch := range subs[ev.Table] {
+			ch <- ev
+		}
+	}
+}
+
+// coalesceUpdates merges each delete/insert pair on the same table into
+// one update event carrying both images.
+func coalesceUpdates(events []ChangeEvent) []ChangeEvent {
+	out := make([]ChangeEvent, 0, len(events))
+	for i := 0; i < len(events); i++ {
+		ev := events[i]
+		if ev.Kind == ChangeDelete && i+1 < len(events) &&
+			events[i+1].Kind == ChangeInsert && events[i+1].Table == ev.Table {
+			out = append(out, ChangeEvent{Table: ev.Table, Kind: ChangeUpdate, Tuple: events[i+1].Tuple, Old: ev.Tuple})
+			i++
+			continue
+		}
+		out = append(out, ev)
+	}
+	return out
+}
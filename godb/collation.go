@@ -0,0 +1,100 @@
+package godb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Collator defines the string comparison semantics used throughout the
+// engine: predicate evaluation, ORDER BY, equality-join bucketing, and
+// index keys all consult the active collator, so "Josie" and "josie"
+// compare the same way everywhere.
+type Collator interface {
+	// Compare orders a against b, returning -1, 0, or +1.
+	Compare(a, b string) int
+	// Key returns a sort key for s: byte-wise comparison of keys must agree
+	// with Compare, and strings comparing equal must produce equal keys.
+	// Keys are used for hash-join buckets and persisted index entries.
+	Key(s string) string
+}
+
+// binaryCollator compares raw bytes; it is the default and matches the
+// engine's historical behavior.
+type binaryCollator struct{}
+
+func (binaryCollator) Compare(a, b string) int { return strings.Compare(a, b) }
+func (binaryCollator) Key(s string) string     { return s }
+
+// nocaseCollator compares strings case-insensitively by simple Unicode
+// lowercasing, so "Josie" and "josie" are equal.
+type nocaseCollator struct{}
+
+func (nocaseCollator) Compare(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+func (nocaseCollator) Key(s string) string { return strings.ToLower(s) }
+
+// The module deliberately has no external dependencies, so no locale-aware
+// collator is bundled; an embedding application can register one (for
+// example backed by golang.org/x/text/collate, whose sort keys satisfy the
+// Key contract) and select it with SetStringCollation.
+var (
+	collMu    sync.RWMutex
+	collators = map[string]Collator{
+		"binary": binaryCollator{},
+		"nocase": nocaseCollator{},
+	}
+	activeCollator  Collator = binaryCollator{}
+	activeCollation          = "binary"
+)
+
+// RegisterCollation makes a collator selectable under name; built-in names
+// cannot be replaced.
+func RegisterCollation(name string, c Collator) error {
+	collMu.Lock()
+	defer collMu.Unlock()
+	if _, ok := collators[name]; ok {
+		return GoDBError{DuplicateNameError, fmt.Sprintf("collation %s already registered", name)}
+	}
+	collators[name] = c
+	return nil
+}
+
+// SetStringCollation selects the active collation by name ("binary" and
+// "nocase" are built in). Indexes persist collation keys, so indexes on
+// string columns built under a different collation must be dropped and
+// recreated after switching.
+func SetStringCollation(name string) error {
+	collMu.Lock()
+	defer collMu.Unlock()
+	c, ok := collators[name]
+	if !ok {
+		return GoDBError{IllegalOperationError, fmt.Sprintf("no collation named %s", name)}
+	}
+	activeCollator, activeCollation = c, name
+	return nil
+}
+
+// StringCollation returns the active collation's name.
+func StringCollation() string {
+	collMu.RLock()
+	defer collMu.RUnlock()
+	return activeCollation
+}
+
+// collate returns the active collator.
+func collate() Collator {
+	collMu.RLock()
+	defer collMu.RUnlock()
+	return activeCollator
+}
+
+// joinKey normalizes v for use as a hash bucket key, so strings that
+// compare equal under the active collation land in the same bucket.
+func joinKey(v DBValue) DBValue {
+	if s, ok := v.(StringField); ok {
+		return StringField{Value: collate().Key(s.Value)}
+	}
+	return v
+}
@@ -0,0 +1,115 @@
+package godb
+
+import "fmt"
+
+// Batch column readers for the vectorized execution path. Rather than
+// zipping column values back into tuples one row at a time, these hand
+// back one row group's worth of a single column as a typed slice copied
+// straight out of the column pages, along with a per-row validity slice
+// (false marks a null). Aggregates and filters that only touch one column
+// can run over the vector without any joinTuples reconstruction.
+
+// ReadIntColumnBatch returns the values of int column colNo for the rows
+// of row group rowGroup. The returned slices are copies; the caller may
+// keep or mutate them after the pages leave the buffer pool. A short (or
+// empty) final group returns a short slice rather than an error.
+func (f *ColumnFile) ReadIntColumnBatch(colNo, rowGroup int, tid TransactionID) ([]int64, []bool, error) {
+	if err := f.checkBatchCol(colNo, IntType); err != nil {
+		return nil, nil, err
+	}
+	key := vectorKey{base: f.baseName, colNo: colNo, rowGroup: rowGroup}
+	if f.vcache != nil {
+		if vals, valid, ok := f.vcache.getInts(key); ok {
+			return vals, valid, nil
+		}
+	}
+	var vals []int64
+	valid, err := f.readBatch(colNo, rowGroup, tid, func(cp *columnPage, lo, hi int) {
+		vals = append(vals, cp.ints[lo:hi]...)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if f.vcache != nil {
+		f.vcache.putInts(key, vals, valid)
+	}
+	return vals, valid, nil
+}
+
+// ReadStringColumnBatch is ReadIntColumnBatch for string columns.
+func (f *ColumnFile) ReadStringColumnBatch(colNo, rowGroup int, tid TransactionID) ([]string, []bool, error) {
+	if err := f.checkBatchCol(colNo, StringType); err != nil {
+		return nil, nil, err
+	}
+	key := vectorKey{base: f.baseName, colNo: colNo, rowGroup: rowGroup}
+	if f.vcache != nil {
+		if vals, valid, ok := f.vcache.getStrings(key); ok {
+			return vals, valid, nil
+		}
+	}
+	var vals []string
+	valid, err := f.readBatch(colNo, rowGroup, tid, func(cp *columnPage, lo, hi int) {
+		vals = append(vals, cp.strs[lo:hi]...)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if f.vcache != nil {
+		f.vcache.putStrings(key, vals, valid)
+	}
+	return vals, valid, nil
+}
+
+func (f *ColumnFile) checkBatchCol(colNo int, want DBType) error {
+	if colNo < 0 || colNo >= len(f.desc.Fields) {
+		return GoDBError{FieldNotFoundError, fmt.Sprintf("column index %d out of range for %s", colNo, f.baseName)}
+	}
+	if f.desc.Fields[colNo].Ftype != want {
+		return GoDBError{TypeMismatchError, fmt.Sprintf("column %d of %s is not of the requested type", colNo, f.baseName)}
+	}
+	return nil
+}
+
+// readBatch walks the column pages covering row group rowGroup of column
+// colNo, calling copyVals for each page's slot range and collecting the
+// corresponding validity bits. The row group's rows can span several pages
+// of the column, since columns pack different numbers of values per page.
+func (f *ColumnFile) readBatch(colNo, rowGroup int, tid TransactionID, copyVals func(cp *columnPage, lo, hi int)) ([]bool, error) {
+	total, err := f.numRows(tid)
+	if err != nil {
+		return nil, err
+	}
+	start := rowGroup * f.rowGroupSize()
+	if rowGroup < 0 || start > total {
+		return nil, GoDBError{IllegalOperationError, fmt.Sprintf("row group %d out of range for %s", rowGroup, f.baseName)}
+	}
+	end := start + f.rowGroupSize()
+	if end > total {
+		end = total
+	}
+	valid := make([]bool, 0, end-start)
+	for row := start; row < end; {
+		colPageNo, slot := f.colLocation(colNo, row)
+		pg, err := f.bufPool.GetPage(f, f.pageNoFor(colNo, colPageNo), tid, ReadPerm)
+		if err != nil {
+			return nil, err
+		}
+		cp := pg.(*columnPage)
+		cp.latch.RLock()
+		hi := slot + (end - row)
+		if n := cp.numValues(); hi > n {
+			hi = n
+		}
+		if hi <= slot {
+			cp.latch.RUnlock()
+			return nil, GoDBError{MalformedDataError, fmt.Sprintf("column %d of %s ends before row %d", colNo, f.baseName, row)}
+		}
+		copyVals(cp, slot, hi)
+		for i := slot; i < hi; i++ {
+			valid = append(valid, cp.valid[i/8]&(1<<(i%8)) != 0)
+		}
+		cp.latch.RUnlock()
+		row += hi - slot
+	}
+	return valid, nil
+}
@@ -0,0 +1,213 @@
+package godb
+
+import (
+	"container/list"
+	"sync"
+)
+
+// ColumnCache pins decoded column vectors of hot row groups in memory, so
+// repeated analytical scans of the same columns skip the page read and
+// decode entirely. Its budget is separate from the BufferPool's: the pool
+// holds page images for the transactional path, while this cache holds
+// the typed slices the batch readers produce. Entries are admitted only
+// on their second access — a one-off full scan should not wash the cache
+// out — and evicted least recently used when over budget. Inserts
+// invalidate the affected row group, so readers never see a stale tail.
+// One cache may be shared by any number of column files.
+type ColumnCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	curBytes int64
+	entries  map[vectorKey]*list.Element
+	lru      *list.List // of *vectorEntry, front = most recent
+	// seen marks groups read once, the admission ticket for caching.
+	seen         map[vectorKey]struct{}
+	hits, misses int64
+	// pending records the row groups each in-flight transaction has
+	// written. A writer's own batch reads can cache its uncommitted
+	// values, so an abort invalidates these groups a second time.
+	pending map[TransactionID][]pendingWrite
+}
+
+// pendingWrite is one row group written by an in-flight transaction.
+type pendingWrite struct {
+	base     string
+	numCols  int
+	rowGroup int
+}
+
+// vectorKey identifies one column of one row group of one column file.
+type vectorKey struct {
+	base     string
+	colNo    int
+	rowGroup int
+}
+
+// vectorEntry is one cached vector; ints or strs is set per column type.
+type vectorEntry struct {
+	key   vectorKey
+	ints  []int64
+	strs  []string
+	valid []bool
+	bytes int64
+}
+
+// NewColumnCache returns a cache holding at most maxBytes of decoded
+// vectors.
+func NewColumnCache(maxBytes int64) *ColumnCache {
+	return &ColumnCache{
+		maxBytes: maxBytes,
+		entries:  make(map[vectorKey]*list.Element),
+		lru:      list.New(),
+		seen:     make(map[vectorKey]struct{}),
+		pending:  make(map[TransactionID][]pendingWrite),
+	}
+}
+
+// SetVectorCache installs c as the file's vector cache; the batch readers
+// consult it from then on, and transaction outcomes on the file's buffer
+// pool drive invalidation.
+func (f *ColumnFile) SetVectorCache(c *ColumnCache) {
+	f.vcache = c
+	if c != nil {
+		f.bufPool.RegisterCommitHook(c.transactionDone)
+	}
+}
+
+// Stats returns the cache's lifetime hit and miss counts and its current
+// size in bytes.
+func (c *ColumnCache) Stats() (hits, misses, bytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.curBytes
+}
+
+// getInts returns a copy of the cached int vector for key, if present.
+func (c *ColumnCache) getInts(key vectorKey) ([]int64, []bool, bool) {
+	e := c.get(key)
+	if e == nil || e.ints == nil {
+		return nil, nil, false
+	}
+	return append([]int64(nil), e.ints...), append([]bool(nil), e.valid...), true
+}
+
+// getStrings returns a copy of the cached string vector for key, if
+// present.
+func (c *ColumnCache) getStrings(key vectorKey) ([]string, []bool, bool) {
+	e := c.get(key)
+	if e == nil || e.strs == nil {
+		return nil, nil, false
+	}
+	return append([]string(nil), e.strs...), append([]bool(nil), e.valid...), true
+}
+
+func (c *ColumnCache) get(key vectorKey) *vectorEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil
+	}
+	c.hits++
+	c.lru.MoveToFront(el)
+	return el.Value.(*vectorEntry)
+}
+
+// putInts offers an int vector for caching; it is stored (copied) only if
+// the group has been read before.
+func (c *ColumnCache) putInts(key vectorKey, vals []int64, valid []bool) {
+	c.put(&vectorEntry{
+		key:   key,
+		ints:  append([]int64(nil), vals...),
+		valid: append([]bool(nil), valid...),
+		bytes: int64(len(vals))*8 + int64(len(valid)),
+	})
+}
+
+// putStrings offers a string vector for caching.
+func (c *ColumnCache) putStrings(key vectorKey, vals []string, valid []bool) {
+	bytes := int64(len(valid))
+	for _, s := range vals {
+		bytes += int64(len(s)) + 16
+	}
+	c.put(&vectorEntry{
+		key:   key,
+		strs:  append([]string(nil), vals...),
+		valid: append([]bool(nil), valid...),
+		bytes: bytes,
+	})
+}
+
+func (c *ColumnCache) put(e *vectorEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[e.key]; ok {
+		return
+	}
+	if _, ok := c.seen[e.key]; !ok {
+		// First sighting: remember it, cache it next time.
+		if len(c.seen) > 1<<16 {
+			c.seen = make(map[vectorKey]struct{})
+		}
+		c.seen[e.key] = struct{}{}
+		return
+	}
+	if e.bytes > c.maxBytes {
+		return
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	c.curBytes += e.bytes
+	for c.curBytes > c.maxBytes {
+		back := c.lru.Back()
+		if back == nil {
+			break
+		}
+		c.remove(back.Value.(*vectorEntry).key)
+	}
+}
+
+// noteWrite invalidates one row group's vectors and remembers the write
+// so an abort can invalidate again.
+func (c *ColumnCache) noteWrite(tid TransactionID, base string, numCols, rowGroup int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidateLocked(base, numCols, rowGroup)
+	c.pending[tid] = append(c.pending[tid], pendingWrite{base, numCols, rowGroup})
+}
+
+// transactionDone is the commit hook: an aborted writer's groups are
+// invalidated once more, since its own reads may have re-cached dirty
+// values after the write.
+func (c *ColumnCache) transactionDone(tid TransactionID, committed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writes, ok := c.pending[tid]
+	if !ok {
+		return
+	}
+	delete(c.pending, tid)
+	if committed {
+		return
+	}
+	for _, w := range writes {
+		c.invalidateLocked(w.base, w.numCols, w.rowGroup)
+	}
+}
+
+// invalidateLocked drops every column's vector for one row group of one
+// file; the caller holds the lock.
+func (c *ColumnCache) invalidateLocked(base string, numCols, rowGroup int) {
+	for colNo := 0; colNo < numCols; colNo++ {
+		c.remove(vectorKey{base: base, colNo: colNo, rowGroup: rowGroup})
+	}
+}
+
+// remove drops one entry; the caller holds the lock.
+func (c *ColumnCache) remove(key vectorKey) {
+	if el, ok := c.entries[key]; ok {
+		c.curBytes -= el.Value.(*vectorEntry).bytes
+		c.lru.Remove(el)
+		delete(c.entries, key)
+	}
+}
@@ -0,0 +1,481 @@
+package godb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ColumnFile stores the tuples of one table column-by-column: each column
+// lives in its own file of pages (<base>.<field>.col), and row i occupies
+// the same page ordinal and slot in every column file. It implements DBFile
+// so the operators can scan it like a heap file, and additionally supports
+// scanning a subset of columns.
+type ColumnFile struct {
+	baseName string
+	desc     *TupleDesc
+	bufPool  *BufferPool
+	// tier is nil until AttachObjectStore enables tiered storage.
+	tier *columnTier
+	// vcache is nil until SetVectorCache enables vector caching.
+	vcache *ColumnCache
+}
+
+// columnFilePageKey identifies one page of one column of a column file in
+// the buffer pool.
+type columnFilePageKey struct {
+	baseName string
+	colNo    int
+	pageNo   int
+}
+
+// columnPage is one page of one column: a 4-byte used-slot count, a
+// validity bitmap (one bit per slot; a zero bit marks a null), and
+// fixed-width serialized values. In memory the values live in a
+// type-specialized vector — one []int64 or []string rather than a boxed
+// DBValue per slot — so scans walk a contiguous slice and serialization
+// copies straight out of it.
+type columnPage struct {
+	file   *ColumnFile
+	colNo  int
+	pageNo int
+	ints   []int64
+	strs   []string
+	valid  []byte
+	dirty  dirtyState
+	// latch protects the typed vectors during append and serialization;
+	// like the heap page latch, it is physical protection against
+	// concurrent operators of one transaction, not a transaction lock.
+	latch sync.RWMutex
+}
+
+// numValues returns the number of values appended to the page.
+func (p *columnPage) numValues() int {
+	if p.file.desc.Fields[p.colNo].Ftype == StringType {
+		return len(p.strs)
+	}
+	return len(p.ints)
+}
+
+// valueAt returns the value in slot i, or NullField if its validity bit is
+// clear.
+func (p *columnPage) valueAt(i int) DBValue {
+	p.latch.RLock()
+	defer p.latch.RUnlock()
+	if i/8 < len(p.valid) && p.valid[i/8]&(1<<(i%8)) == 0 {
+		return NullField{}
+	}
+	switch f := p.file.desc.Fields[p.colNo]; f.Ftype {
+	case StringType:
+		return StringField{p.strs[i]}
+	case DecimalType:
+		return DecimalField{Unscaled: p.ints[i], Scale: f.Scale}
+	}
+	return IntField{p.ints[i]}
+}
+
+// appendValue appends v to the page's typed vector and records its
+// validity bit; the caller has already checked capacity.
+func (p *columnPage) appendValue(v DBValue) error {
+	p.latch.Lock()
+	defer p.latch.Unlock()
+	idx := p.numValues()
+	ftype := p.file.desc.Fields[p.colNo].Ftype
+	setValid := true
+	switch x := v.(type) {
+	case IntField:
+		if ftype != IntType {
+			return GoDBError{TypeMismatchError, "int value in non-int column"}
+		}
+		p.ints = append(p.ints, x.Value)
+	case DecimalField:
+		if ftype != DecimalType {
+			return GoDBError{TypeMismatchError, "decimal value in non-decimal column"}
+		}
+		d, err := x.rescale(p.file.desc.Fields[p.colNo].Scale)
+		if err != nil {
+			return err
+		}
+		p.ints = append(p.ints, d.Unscaled)
+	case StringField:
+		if ftype != StringType {
+			return GoDBError{TypeMismatchError, "string value in non-string column"}
+		}
+		p.strs = append(p.strs, x.Value)
+	case NullField:
+		setValid = false
+		if ftype == StringType {
+			p.strs = append(p.strs, "")
+		} else {
+			p.ints = append(p.ints, 0)
+		}
+	default:
+		return GoDBError{TypeMismatchError, fmt.Sprintf("unsupported column value %T", v)}
+	}
+	for idx/8 >= len(p.valid) {
+		p.valid = append(p.valid, 0)
+	}
+	if setValid {
+		p.valid[idx/8] |= 1 << (idx % 8)
+	}
+	return nil
+}
+
+// NewColumnFile opens (creating if necessary) a column file rooted at
+// baseName, one backing file per column.
+func NewColumnFile(baseName string, desc *TupleDesc, bp *BufferPool) (*ColumnFile, error) {
+	cf := &ColumnFile{baseName: baseName, desc: desc, bufPool: bp}
+	for i := range desc.Fields {
+		f, err := os.OpenFile(cf.columnPath(i), os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, GoDBError{OsError, err.Error()}
+		}
+		f.Close()
+	}
+	return cf, nil
+}
+
+func (f *ColumnFile) columnPath(colNo int) string {
+	return fmt.Sprintf("%s.%s.col", f.baseName, f.desc.Fields[colNo].Fname)
+}
+
+func (f *ColumnFile) Descriptor() *TupleDesc {
+	return f.desc
+}
+
+// slotsPerPage returns the number of values of column colNo that fit on one
+// page; each slot costs its value bytes plus one validity bit.
+func (f *ColumnFile) slotsPerPage(colNo int) int {
+	sz := 8
+	if f.desc.Fields[colNo].Ftype == StringType {
+		sz = StringLength
+	}
+	avail := PageSize - 4
+	n := avail / sz
+	for n > 0 && (n+7)/8+n*sz > avail {
+		n--
+	}
+	return n
+}
+
+// NumPages returns the number of pages in the first column's file; every
+// column has the same number of rows but possibly different page counts, so
+// this is only meaningful for single-column bookkeeping.
+func (f *ColumnFile) NumPages() int {
+	return f.numColPages(0)
+}
+
+func (f *ColumnFile) numColPages(colNo int) int {
+	st, err := os.Stat(f.columnPath(colNo))
+	if err != nil {
+		return f.coldPages(colNo)
+	}
+	return f.coldPages(colNo) + int(st.Size())/PageSize
+}
+
+// numRows returns the total number of rows in the file, computed from the
+// first column.
+func (f *ColumnFile) numRows(tid TransactionID) (int, error) {
+	n := f.numColPages(0)
+	if n == 0 {
+		return 0, nil
+	}
+	pg, err := f.bufPool.GetPage(f, f.pageNoFor(0, n-1), tid, ReadPerm)
+	if err != nil {
+		return 0, err
+	}
+	cp := pg.(*columnPage)
+	return (n-1)*f.slotsPerPage(0) + cp.numValues(), nil
+}
+
+// pageNoFor packs a (column, page) pair into the single pageNo accepted by
+// readPage: pageNo = colPageNo*numColumns + colNo.
+func (f *ColumnFile) pageNoFor(colNo, colPageNo int) int {
+	return colPageNo*len(f.desc.Fields) + colNo
+}
+
+func (f *ColumnFile) pageKey(pgNo int) any {
+	nc := len(f.desc.Fields)
+	return columnFilePageKey{baseName: f.baseName, colNo: pgNo % nc, pageNo: pgNo / nc}
+}
+
+// readPage reads the packed (column, page) pair encoded in pageNo. As in
+// HeapFile.readPage, out-of-range page numbers and short reads get typed
+// errors instead of pages built from partial data.
+func (f *ColumnFile) readPage(pageNo int) (Page, error) {
+	nc := len(f.desc.Fields)
+	if pageNo < 0 {
+		return nil, GoDBError{IllegalOperationError, fmt.Sprintf("page %d out of range for %s", pageNo, f.baseName)}
+	}
+	colNo, colPageNo := pageNo%nc, pageNo/nc
+	if colPageNo >= f.numColPages(colNo) {
+		return nil, GoDBError{IllegalOperationError, fmt.Sprintf("page %d out of range for column %d of %s (%d pages)", colPageNo, colNo, f.baseName, f.numColPages(colNo))}
+	}
+	var buf []byte
+	if cold := f.coldPages(colNo); colPageNo < cold {
+		b, err := f.tierRead(colNo, colPageNo)
+		if err != nil {
+			return nil, err
+		}
+		buf = b
+	} else {
+		file, err := os.Open(f.columnPath(colNo))
+		if err != nil {
+			return nil, GoDBError{OsError, err.Error()}
+		}
+		defer file.Close()
+		buf = make([]byte, PageSize)
+		if _, err := io.ReadFull(io.NewSectionReader(file, int64(colPageNo-cold)*int64(PageSize), int64(PageSize)), buf); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, GoDBError{MalformedDataError, fmt.Sprintf("short read of page %d of column %d of %s: file ends mid-page", colPageNo, colNo, f.baseName)}
+			}
+			return nil, GoDBError{OsError, err.Error()}
+		}
+	}
+	used := int(binary.LittleEndian.Uint32(buf[0:4]))
+	spp := f.slotsPerPage(colNo)
+	if used < 0 || used > spp {
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("page %d of column %d of %s claims %d used slots (max %d)", colPageNo, colNo, f.baseName, used, spp)}
+	}
+	cp := &columnPage{file: f, colNo: colNo, pageNo: colPageNo}
+	bmBytes := (spp + 7) / 8
+	cp.valid = append([]byte(nil), buf[4:4+bmBytes]...)
+	data := buf[4+bmBytes:]
+	switch f.desc.Fields[colNo].Ftype {
+	case IntType, DecimalType:
+		cp.ints = make([]int64, used)
+		for i := range cp.ints {
+			cp.ints[i] = int64(binary.LittleEndian.Uint64(data[i*8:]))
+		}
+	case StringType:
+		cp.strs = make([]string, used)
+		for i := range cp.strs {
+			cp.strs[i] = strings.TrimRight(string(data[i*StringLength:(i+1)*StringLength]), "\x00")
+		}
+	}
+	return cp, nil
+}
+
+func (p *columnPage) isDirty() bool {
+	return p.dirty.isDirty()
+}
+
+func (p *columnPage) setDirty(tid TransactionID, dirty bool) {
+	p.dirty.set(tid, dirty)
+}
+
+func (p *columnPage) dirtier() TransactionID {
+	return p.dirty.dirtier()
+}
+
+func (p *columnPage) getFile() DBFile {
+	return p.file
+}
+
+func (p *columnPage) toBuffer() (*bytes.Buffer, error) {
+	p.latch.RLock()
+	defer p.latch.RUnlock()
+	b := new(bytes.Buffer)
+	if err := binary.Write(b, binary.LittleEndian, int32(p.numValues())); err != nil {
+		return nil, err
+	}
+	spp := p.file.slotsPerPage(p.colNo)
+	bm := make([]byte, (spp+7)/8)
+	copy(bm, p.valid)
+	b.Write(bm)
+	if p.file.desc.Fields[p.colNo].Ftype != StringType {
+		for _, v := range p.ints {
+			if err := binary.Write(b, binary.LittleEndian, v); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for _, s := range p.strs {
+			if len(s) > StringLength {
+				s = s[:StringLength]
+			}
+			sb := make([]byte, StringLength)
+			copy(sb, s)
+			b.Write(sb)
+		}
+	}
+	b.Write(make([]byte, PageSize-b.Len()))
+	return b, nil
+}
+
+func (f *ColumnFile) flushPage(page Page) error {
+	cp := page.(*columnPage)
+	cold := f.coldPages(cp.colNo)
+	if cp.pageNo < cold {
+		return GoDBError{IllegalOperationError, fmt.Sprintf("page %d of column %d of %s is offloaded to the object store and immutable", cp.pageNo, cp.colNo, f.baseName)}
+	}
+	b, err := cp.toBuffer()
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.columnPath(cp.colNo), os.O_RDWR, 0644)
+	if err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	defer file.Close()
+	if err := writePageAt(file, f.columnPath(cp.colNo), int64(cp.pageNo-cold)*int64(PageSize), b.Bytes()); err != nil {
+		return err
+	}
+	if f.bufPool.syncEveryFlush() {
+		if err := file.Sync(); err != nil {
+			return GoDBError{OsError, err.Error()}
+		}
+	}
+	cp.setDirty(nil, false)
+	metrics.PageFlushed()
+	logger.Debugf("column %s: flushed page %d of column %d", f.baseName, cp.pageNo, cp.colNo)
+	return nil
+}
+
+// insertTuple appends t's fields to the tail page of each column file. Rows
+// are append-only; deleteTuple is not supported. The insert happens in two
+// phases: first a tail page with a free slot is secured (locked, extending
+// the file if full) for every column, and only then are the values
+// appended — so a lock conflict or I/O failure partway through cannot
+// leave a torn row with some columns one value longer than the others.
+func (f *ColumnFile) insertTuple(t *Tuple, tid TransactionID) error {
+	// Validate every field up front: appendValue rejecting a value mid-row
+	// would break the all-or-nothing guarantee below. Decimals must also
+	// prove they fit the column's scale, since rescaling fails on values it
+	// cannot represent exactly.
+	for colNo := range f.desc.Fields {
+		switch x := t.Fields[colNo].(type) {
+		case IntField, StringField, NullField:
+		case DecimalField:
+			if _, err := x.rescale(f.desc.Fields[colNo].Scale); err != nil {
+				return err
+			}
+		default:
+			return GoDBError{TypeMismatchError, fmt.Sprintf("unsupported column value %T", t.Fields[colNo])}
+		}
+	}
+	pages := make([]*columnPage, len(f.desc.Fields))
+	for colNo := range f.desc.Fields {
+		cp, err := f.tailPageForInsert(colNo, tid)
+		if err != nil {
+			return err
+		}
+		pages[colNo] = cp
+	}
+	row := pages[0].pageNo*f.rowGroupSize() + pages[0].numValues()
+	for colNo, cp := range pages {
+		if err := cp.appendValue(t.Fields[colNo]); err != nil {
+			return err
+		}
+		cp.setDirty(tid, true)
+	}
+	t.Rid = f.ridForRow(row)
+	if f.vcache != nil {
+		f.vcache.noteWrite(tid, f.baseName, len(f.desc.Fields), row/f.rowGroupSize())
+	}
+	return nil
+}
+
+// tailPageForInsert returns column colNo's tail page, locked for writing
+// on behalf of tid and guaranteed to have a free slot; a full (or absent)
+// tail extends the column file on disk first. A page extended here but
+// never appended to — because securing a later column failed — is benign:
+// the next insert finds and fills it, and scans never reach it because row
+// counts derive from column 0.
+func (f *ColumnFile) tailPageForInsert(colNo int, tid TransactionID) (*columnPage, error) {
+	n := f.numColPages(colNo)
+	if n > 0 {
+		pg, err := f.bufPool.GetPage(f, f.pageNoFor(colNo, n-1), tid, WritePerm)
+		if err != nil {
+			return nil, err
+		}
+		cp := pg.(*columnPage)
+		if cp.numValues() < f.slotsPerPage(colNo) {
+			return cp, nil
+		}
+	}
+	np := &columnPage{file: f, colNo: colNo, pageNo: n}
+	if err := f.flushPage(np); err != nil {
+		return nil, err
+	}
+	pg, err := f.bufPool.GetPage(f, f.pageNoFor(colNo, n), tid, WritePerm)
+	if err != nil {
+		return nil, err
+	}
+	return pg.(*columnPage), nil
+}
+
+// deleteTuple is not supported for column files; the store is append-only.
+func (f *ColumnFile) deleteTuple(t *Tuple, tid TransactionID) error {
+	return GoDBError{IllegalOperationError, "column files are append-only"}
+}
+
+// Iterator scans every row, fetching one page per column at a time and
+// zipping the column values back into tuples.
+func (f *ColumnFile) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	return f.iteratorForColumns(tid, nil)
+}
+
+// IteratorColNames scans only the named columns, in the order given. Names
+// are resolved through the descriptor — a "table.column" form matches on
+// the table qualifier too — so callers are insulated from column index
+// changes; unknown or ambiguous names produce a descriptive error instead
+// of silently scanning the wrong column.
+func (f *ColumnFile) IteratorColNames(names []string, tid TransactionID) (func() (*Tuple, error), error) {
+	cols := make([]int, len(names))
+	for i, name := range names {
+		field := FieldType{Fname: name}
+		if dot := strings.Index(name, "."); dot >= 0 {
+			field = FieldType{TableQualifier: name[:dot], Fname: name[dot+1:]}
+		}
+		idx, err := findFieldInTd(field, f.desc)
+		if err != nil {
+			return nil, GoDBError{FieldNotFoundError, fmt.Sprintf("column %q: %v", name, err)}
+		}
+		cols[i] = idx
+	}
+	return f.iteratorForColumns(tid, cols)
+}
+
+// iteratorForColumns scans only the columns at indexes cols (all columns if
+// cols is nil), producing tuples with the corresponding sub-descriptor.
+func (f *ColumnFile) iteratorForColumns(tid TransactionID, cols []int) (func() (*Tuple, error), error) {
+	if cols == nil {
+		cols = make([]int, len(f.desc.Fields))
+		for i := range cols {
+			cols[i] = i
+		}
+	}
+	fields := make([]FieldType, len(cols))
+	for i, c := range cols {
+		fields[i] = f.desc.Fields[c]
+	}
+	desc := &TupleDesc{Fields: fields}
+	row := 0
+	return func() (*Tuple, error) {
+		vals := make([]DBValue, len(cols))
+		for i, colNo := range cols {
+			colPageNo, slot := f.colLocation(colNo, row)
+			if colPageNo >= f.numColPages(colNo) {
+				return nil, nil
+			}
+			pg, err := f.bufPool.GetPage(f, f.pageNoFor(colNo, colPageNo), tid, ReadPerm)
+			if err != nil {
+				return nil, err
+			}
+			cp := pg.(*columnPage)
+			if slot >= cp.numValues() {
+				return nil, nil
+			}
+			vals[i] = cp.valueAt(slot)
+		}
+		t := &Tuple{Desc: *desc.copy(), Fields: vals, Rid: f.ridForRow(row)}
+		row++
+		metrics.TuplesScanned("column_scan", 1)
+		return t, nil
+	}, nil
+}
@@ -0,0 +1,384 @@
+package godb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CompositeIndex indexes tuples on multiple columns with lexicographic
+// ordering, so a query filtering on a prefix of the indexed columns (e.g.
+// (name) or (name, age) of an index on (name, age, city)) can use a single
+// range scan. Entries are kept sorted on an order-preserving byte encoding
+// of the key and persisted as a sidecar file; like the hash index,
+// maintenance is buffered per transaction and applied at commit.
+type CompositeIndex struct {
+	table string
+	cols  []FieldType
+	path  string
+	base  *HeapFile
+
+	mu      sync.Mutex
+	entries []compositeEntry // sorted by Key
+}
+
+type compositeEntry struct {
+	Key string   `json:"k"` // order-preserving encoding of the column values
+	Rid RecordID `json:"r"`
+}
+
+// CreateCompositeIndex builds an ordered index on cols of table,
+// backfilling from current contents. Inserts and deletes through the
+// catalog keep it up to date.
+func (c *Catalog) CreateCompositeIndex(table string, cols []string) (*CompositeIndex, error) {
+	cf, err := c.constrained(table)
+	if err != nil {
+		return nil, err
+	}
+	base, err := c.heapFile(table)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := resolveCols(cf.Descriptor(), cols)
+	if err != nil {
+		return nil, err
+	}
+	idx := &CompositeIndex{
+		table: table,
+		cols:  resolved,
+		path:  c.tableFilePath(table) + "." + strings.Join(cols, "_") + ".cidx",
+		base:  base,
+	}
+	if err := idx.load(); err != nil {
+		return nil, err
+	}
+	if idx.entries == nil {
+		if err := idx.rebuild(c.bufPool, cf); err != nil {
+			return nil, err
+		}
+	}
+	c.indexTxn()
+	cf.indexes = append(cf.indexes, idx)
+	return idx, nil
+}
+
+// orderedEncode appends an order-preserving encoding of v: ints as
+// big-endian with the sign bit flipped, strings as their collation key,
+// NUL-terminated. Byte-wise comparison of concatenated encodings then
+// matches column-wise value comparison under the active collation; an
+// index built under one collation must be rebuilt after switching to
+// another.
+func orderedEncode(b []byte, v DBValue) []byte {
+	switch x := v.(type) {
+	case IntField:
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(x.Value)^(1<<63))
+		return append(b, buf[:]...)
+	case DecimalField:
+		// Values in one column share a scale, so the unscaled integer
+		// orders the same way the decimal values do.
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(x.Unscaled)^(1<<63))
+		return append(b, buf[:]...)
+	case StringField:
+		b = append(b, collate().Key(x.Value)...)
+		return append(b, 0)
+	}
+	return b
+}
+
+// key encodes the indexed columns of t.
+func (idx *CompositeIndex) key(t *Tuple) (string, error) {
+	var b []byte
+	for _, c := range idx.cols {
+		i, err := findFieldInTd(c, &t.Desc)
+		if err != nil {
+			return "", err
+		}
+		b = orderedEncode(b, t.Fields[i])
+	}
+	return string(b), nil
+}
+
+// prefixKey encodes a prefix of the indexed columns.
+func (idx *CompositeIndex) prefixKey(vals []DBValue) (string, error) {
+	if len(vals) > len(idx.cols) {
+		return "", GoDBError{IllegalOperationError, "prefix longer than indexed columns"}
+	}
+	var b []byte
+	for _, v := range vals {
+		b = orderedEncode(b, v)
+	}
+	return string(b), nil
+}
+
+func (idx *CompositeIndex) insert(t *Tuple) error {
+	key, err := idx.key(t)
+	if err != nil {
+		return err
+	}
+	rid, ok := t.Rid.(RecordID)
+	if !ok {
+		return GoDBError{IllegalOperationError, "indexed insert produced no record id"}
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	i := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].Key >= key })
+	idx.entries = append(idx.entries, compositeEntry{})
+	copy(idx.entries[i+1:], idx.entries[i:])
+	idx.entries[i] = compositeEntry{Key: key, Rid: rid}
+	return idx.save()
+}
+
+func (idx *CompositeIndex) remove(t *Tuple) error {
+	key, err := idx.key(t)
+	if err != nil {
+		return err
+	}
+	rid, _ := t.Rid.(RecordID)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	i := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].Key >= key })
+	for ; i < len(idx.entries) && idx.entries[i].Key == key; i++ {
+		if idx.entries[i].Rid == rid {
+			idx.entries = append(idx.entries[:i], idx.entries[i+1:]...)
+			break
+		}
+	}
+	return idx.save()
+}
+
+// LookupPrefix returns the record ids of rows whose leading indexed columns
+// equal vals, in index order.
+func (idx *CompositeIndex) LookupPrefix(vals []DBValue) ([]RecordID, error) {
+	prefix, err := idx.prefixKey(vals)
+	if err != nil {
+		return nil, err
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	i := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].Key >= prefix })
+	var rids []RecordID
+	for ; i < len(idx.entries) && strings.HasPrefix(idx.entries[i].Key, prefix); i++ {
+		rids = append(rids, idx.entries[i].Rid)
+	}
+	return rids, nil
+}
+
+func (idx *CompositeIndex) rebuild(bp *BufferPool, src Operator) error {
+	tid := NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		return err
+	}
+	defer bp.CommitTransaction(tid)
+	// Scan through the catalog wrapper rather than the base file, so a
+	// virtual computed column's values are present to key on.
+	iter, err := src.Iterator(tid)
+	if err != nil {
+		return err
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.entries = []compositeEntry{}
+	for {
+		t, err := iter()
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			break
+		}
+		key, err := idx.key(t)
+		if err != nil {
+			return err
+		}
+		idx.entries = append(idx.entries, compositeEntry{Key: key, Rid: t.Rid.(RecordID)})
+	}
+	sort.Slice(idx.entries, func(i, j int) bool { return idx.entries[i].Key < idx.entries[j].Key })
+	return idx.save()
+}
+
+// save persists the entries; caller holds idx.mu.
+func (idx *CompositeIndex) save() error {
+	buf, err := json.Marshal(idx.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(idx.path, buf, 0644); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
+
+func (idx *CompositeIndex) load() error {
+	buf, err := os.ReadFile(idx.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if err := json.Unmarshal(buf, &idx.entries); err != nil {
+		return GoDBError{MalformedDataError, fmt.Sprintf("bad index file %s: %v", idx.path, err)}
+	}
+	return nil
+}
+
+// decodeKey undoes orderedEncode, recovering the indexed column values.
+func (idx *CompositeIndex) decodeKey(key string) ([]DBValue, error) {
+	b := []byte(key)
+	vals := make([]DBValue, 0, len(idx.cols))
+	for _, c := range idx.cols {
+		switch c.Ftype {
+		case IntType:
+			if len(b) < 8 {
+				return nil, GoDBError{MalformedDataError, "truncated index key"}
+			}
+			v := binary.BigEndian.Uint64(b[:8]) ^ (1 << 63)
+			vals = append(vals, IntField{int64(v)})
+			b = b[8:]
+		case StringType:
+			i := 0
+			for i < len(b) && b[i] != 0 {
+				i++
+			}
+			if i >= len(b) {
+				return nil, GoDBError{MalformedDataError, "truncated index key"}
+			}
+			vals = append(vals, StringField{string(b[:i])})
+			b = b[i+1:]
+		}
+	}
+	return vals, nil
+}
+
+// IndexOnlyScan answers a query from the index entries alone, producing
+// tuples of exactly the indexed columns without touching the base file. The
+// planner selects it when an index covers every projected column. Note that
+// entries for deleted rows whose delete bypassed the catalog handle would
+// not be visible here; the catalog's paths keep the index consistent. Since
+// maintenance applies at commit, the scan reflects committed state only —
+// including for the scanning transaction's own uncommitted writes.
+type IndexOnlyScan struct {
+	index  *CompositeIndex
+	prefix []DBValue // optional leading-column equality constraint
+}
+
+// NewIndexOnlyScan returns an index-only scan of idx. A non-nil prefix
+// restricts the scan to entries whose leading columns equal it.
+func NewIndexOnlyScan(idx *CompositeIndex, prefix []DBValue) *IndexOnlyScan {
+	return &IndexOnlyScan{index: idx, prefix: prefix}
+}
+
+func (s *IndexOnlyScan) Descriptor() *TupleDesc {
+	fields := make([]FieldType, len(s.index.cols))
+	copy(fields, s.index.cols)
+	return &TupleDesc{Fields: fields}
+}
+
+func (s *IndexOnlyScan) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	prefix, err := s.index.prefixKey(s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	s.index.mu.Lock()
+	start := sort.Search(len(s.index.entries), func(i int) bool { return s.index.entries[i].Key >= prefix })
+	entries := make([]compositeEntry, 0)
+	for i := start; i < len(s.index.entries) && strings.HasPrefix(s.index.entries[i].Key, prefix); i++ {
+		entries = append(entries, s.index.entries[i])
+	}
+	s.index.mu.Unlock()
+	desc := s.Descriptor()
+	i := 0
+	return func() (*Tuple, error) {
+		if i >= len(entries) {
+			return nil, nil
+		}
+		e := entries[i]
+		i++
+		vals, err := s.index.decodeKey(e.Key)
+		if err != nil {
+			return nil, err
+		}
+		metrics.TuplesScanned("index_only_scan", 1)
+		return &Tuple{Desc: *desc.copy(), Fields: vals, Rid: e.Rid}, nil
+	}, nil
+}
+
+// compositeIndexCovering returns an index on file containing every column
+// in fields, or nil.
+func compositeIndexCovering(file DBFile, fields []string) *CompositeIndex {
+	cf, ok := file.(*constrainedFile)
+	if !ok {
+		return nil
+	}
+	for _, ti := range cf.indexes {
+		ci, ok := ti.(*CompositeIndex)
+		if !ok {
+			continue
+		}
+		covered := true
+		for _, f := range fields {
+			found := false
+			for _, c := range ci.cols {
+				if c.Fname == f {
+					found = true
+					break
+				}
+			}
+			if !found {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return ci
+		}
+	}
+	return nil
+}
+
+// CompositeIndexScan produces the base rows matching a prefix of the
+// indexed columns, in index order.
+type CompositeIndexScan struct {
+	index  *CompositeIndex
+	prefix []DBValue
+}
+
+// NewCompositeIndexScan returns a prefix-match scan over idx's base table.
+func NewCompositeIndexScan(idx *CompositeIndex, prefix []DBValue) *CompositeIndexScan {
+	return &CompositeIndexScan{index: idx, prefix: prefix}
+}
+
+func (s *CompositeIndexScan) Descriptor() *TupleDesc {
+	return s.index.base.Descriptor()
+}
+
+func (s *CompositeIndexScan) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	rids, err := s.index.LookupPrefix(s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	i := 0
+	return func() (*Tuple, error) {
+		for i < len(rids) {
+			rid := rids[i]
+			i++
+			t, err := s.index.base.getTupleByRid(rid, tid)
+			if err != nil {
+				return nil, err
+			}
+			if t != nil {
+				metrics.TuplesScanned("composite_index_scan", 1)
+				return t, nil
+			}
+		}
+		return nil, nil
+	}, nil
+}
@@ -0,0 +1,202 @@
+package godb
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Transparent page compression. A heap file with compression enabled
+// compresses each page image in flushPage and writes it — prefixed with a
+// small header naming the codec and compressed length — into the page's
+// fixed slot, so page offsets and every other code path (snapshots, undo
+// images, crash recovery) are unchanged; pages that do not shrink are
+// stored raw. readPage recognizes the header and decompresses. A sidecar
+// page directory (<file>.pages) records the codec and compressed size of
+// every page for inspection and space accounting. The stdlib "flate"
+// codec is built in; snappy or zstd implementations can be plugged in via
+// RegisterPageCodec without adding dependencies to the core.
+
+// PageCodec compresses and decompresses page images.
+type PageCodec interface {
+	// Name identifies the codec in page headers; it must be stable.
+	Name() string
+	Compress(raw []byte) ([]byte, error)
+	// Decompress inflates comp back to a page image of size want.
+	Decompress(comp []byte, want int) ([]byte, error)
+}
+
+var (
+	codecMu sync.RWMutex
+	codecs  = make(map[string]PageCodec)
+)
+
+// RegisterPageCodec makes codec available to EnableCompression and to
+// readPage for files written with it.
+func RegisterPageCodec(codec PageCodec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecs[strings.ToLower(codec.Name())] = codec
+}
+
+func pageCodec(name string) (PageCodec, error) {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	c, ok := codecs[strings.ToLower(name)]
+	if !ok {
+		return nil, GoDBError{NoSuchTableError, fmt.Sprintf("no page codec named %s", name)}
+	}
+	return c, nil
+}
+
+// flateCodec is the built-in stdlib DEFLATE codec.
+type flateCodec struct{}
+
+func (flateCodec) Name() string { return "flate" }
+
+func (flateCodec) Compress(raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (flateCodec) Decompress(comp []byte, want int) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(comp))
+	defer r.Close()
+	raw := make([]byte, 0, want)
+	buf := bytes.NewBuffer(raw)
+	if _, err := io.Copy(buf, r); err != nil {
+		return nil, err
+	}
+	if buf.Len() != want {
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("decompressed page is %d bytes, want %d", buf.Len(), want)}
+	}
+	return buf.Bytes(), nil
+}
+
+func init() {
+	RegisterPageCodec(flateCodec{})
+}
+
+// compressedPageMagic marks a page slot holding a compressed image. It is
+// not a plausible slot count, so raw pages can never be mistaken for
+// compressed ones.
+const compressedPageMagic = 0xC0DB90DB
+
+// PageDirEntry is one page's record in the sidecar directory.
+type PageDirEntry struct {
+	Codec   string `json:"codec"`
+	CompLen int    `json:"compLen"`
+}
+
+// EnableCompression makes flushPage compress pages of this file with the
+// named codec. Already-written pages stay readable in whatever form they
+// are on disk; they are rewritten compressed the next time they are
+// flushed.
+func (f *HeapFile) EnableCompression(codecName string) error {
+	codec, err := pageCodec(codecName)
+	if err != nil {
+		return err
+	}
+	f.codec = codec
+	return nil
+}
+
+// encodePageImage returns the bytes to store in the page's slot: a
+// compressed image with header if the codec shrinks it, the raw image
+// otherwise. The returned entry describes what was stored.
+func encodePageImage(codec PageCodec, raw []byte) ([]byte, PageDirEntry, error) {
+	if codec == nil {
+		return raw, PageDirEntry{Codec: "", CompLen: len(raw)}, nil
+	}
+	comp, err := codec.Compress(raw)
+	if err != nil {
+		return nil, PageDirEntry{}, err
+	}
+	name := codec.Name()
+	header := 4 + 1 + len(name) + 4
+	if header+len(comp) >= len(raw) {
+		return raw, PageDirEntry{Codec: "", CompLen: len(raw)}, nil
+	}
+	out := make([]byte, header+len(comp))
+	binary.LittleEndian.PutUint32(out[0:4], compressedPageMagic)
+	out[4] = byte(len(name))
+	copy(out[5:], name)
+	binary.LittleEndian.PutUint32(out[5+len(name):header], uint32(len(comp)))
+	copy(out[header:], comp)
+	return out, PageDirEntry{Codec: name, CompLen: len(out)}, nil
+}
+
+// decodePageImage returns the raw page image stored in slot, inflating it
+// if the slot carries a compression header.
+func decodePageImage(slot []byte) ([]byte, error) {
+	if len(slot) < 9 || binary.LittleEndian.Uint32(slot[0:4]) != compressedPageMagic {
+		return slot, nil
+	}
+	nameLen := int(slot[4])
+	header := 4 + 1 + nameLen + 4
+	if header > len(slot) {
+		return nil, GoDBError{MalformedDataError, "truncated compressed page header"}
+	}
+	name := string(slot[5 : 5+nameLen])
+	compLen := int(binary.LittleEndian.Uint32(slot[5+nameLen : header]))
+	if header+compLen > len(slot) {
+		return nil, GoDBError{MalformedDataError, "compressed page length exceeds slot"}
+	}
+	codec, err := pageCodec(name)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Decompress(slot[header:header+compLen], PageSize)
+}
+
+// pageDirPath is the sidecar directory file for a heap file.
+func pageDirPath(fileName string) string {
+	return fileName + ".pages"
+}
+
+// savePageDirEntry updates page pgNo's entry in the sidecar directory.
+// The directory is advisory — readPage trusts only the in-slot header —
+// so failures here are not fatal to the flush.
+func savePageDirEntry(fileName string, pgNo int, entry PageDirEntry) {
+	dir := make(map[int]PageDirEntry)
+	if buf, err := os.ReadFile(pageDirPath(fileName)); err == nil {
+		json.Unmarshal(buf, &dir)
+	}
+	dir[pgNo] = entry
+	if buf, err := json.Marshal(dir); err == nil {
+		os.WriteFile(pageDirPath(fileName), buf, 0644)
+	}
+}
+
+// PageDirectory returns the sidecar compressed-page directory of the
+// file, keyed by page number.
+func (f *HeapFile) PageDirectory() (map[int]PageDirEntry, error) {
+	dir := make(map[int]PageDirEntry)
+	buf, err := os.ReadFile(pageDirPath(f.fileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return dir, nil
+		}
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	if err := json.Unmarshal(buf, &dir); err != nil {
+		return nil, GoDBError{MalformedDataError, err.Error()}
+	}
+	return dir, nil
+}
@@ -0,0 +1,149 @@
+package godb
+
+import "fmt"
+
+// computedColumn is one generated column of a table: an expression over the
+// row's other fields. A stored column is declared in the table's schema and
+// materialized at insert time; a virtual column exists only in the catalog
+// wrapper's descriptor and is evaluated as tuples stream out of a scan.
+type computedColumn struct {
+	field  FieldType
+	expr   Expr
+	stored bool
+}
+
+// AddComputedColumn declares name as a computed column of table, derived by
+// evaluating expr over each row's other fields.
+//
+// When stored is true the column must already exist in the table's schema:
+// its value is computed and written on every insert, overwriting whatever
+// the caller supplied, so it occupies space and reads back like any other
+// column. When stored is false the column is virtual: it is appended to the
+// table's descriptor and computed at scan time, costing nothing on disk.
+// Either kind can be indexed with CreateHashIndex or CreateCompositeIndex,
+// which for a virtual column yields an expression index — lookups on the
+// computed value without storing it in the row.
+func (c *Catalog) AddComputedColumn(table, name string, expr Expr, stored bool) error {
+	cf, err := c.constrained(table)
+	if err != nil {
+		return err
+	}
+	base := cf.DBFile.Descriptor()
+	if err := resolveExpr(expr, base); err != nil {
+		return err
+	}
+	for _, cc := range cf.computed {
+		if cc.field.Fname == name {
+			return GoDBError{DuplicateNameError, fmt.Sprintf("table %s already has a computed column %s", table, name)}
+		}
+	}
+	if stored {
+		idx, err := findFieldInTd(FieldType{Fname: name}, base)
+		if err != nil {
+			return err
+		}
+		ft := base.Fields[idx]
+		if et := expr.GetExprType(); et.Ftype != ft.Ftype {
+			return GoDBError{TypeMismatchError,
+				fmt.Sprintf("computed column %s is %s but its expression produces %s", name, ft.Ftype, et.Ftype)}
+		}
+		cf.computed = append(cf.computed, &computedColumn{field: ft, expr: expr, stored: true})
+		return nil
+	}
+	if _, err := findFieldInTd(FieldType{Fname: name}, base); err == nil {
+		return GoDBError{DuplicateNameError, fmt.Sprintf("table %s already has a column %s", table, name)}
+	}
+	ft := expr.GetExprType()
+	ft.Fname = name
+	ft.TableQualifier = table
+	cf.computed = append(cf.computed, &computedColumn{field: ft, expr: expr})
+	return nil
+}
+
+// virtualCols returns the table's virtual computed columns.
+func (f *constrainedFile) virtualCols() []*computedColumn {
+	var virt []*computedColumn
+	for _, cc := range f.computed {
+		if !cc.stored {
+			virt = append(virt, cc)
+		}
+	}
+	return virt
+}
+
+// Descriptor appends the virtual computed columns to the base schema, so
+// queries can reference them like stored columns.
+func (f *constrainedFile) Descriptor() *TupleDesc {
+	base := f.DBFile.Descriptor()
+	virt := f.virtualCols()
+	if len(virt) == 0 {
+		return base
+	}
+	out := base.copy()
+	for _, cc := range virt {
+		out.Fields = append(out.Fields, cc.field)
+	}
+	return out
+}
+
+// fillStored materializes the stored computed columns into t, overwriting
+// whatever values the caller supplied for them.
+func (f *constrainedFile) fillStored(t *Tuple) error {
+	for _, cc := range f.computed {
+		if !cc.stored {
+			continue
+		}
+		idx, err := findFieldInTd(cc.field, &t.Desc)
+		if err != nil {
+			return err
+		}
+		v, err := cc.expr.EvalExpr(t)
+		if err != nil {
+			return err
+		}
+		t.Fields[idx] = v
+	}
+	return nil
+}
+
+// withVirtual returns t extended with the table's virtual computed values,
+// or t itself when there is nothing to add. Columns t already carries (a
+// tuple that came through an augmented scan) are not appended twice, and t
+// itself is never mutated — it may be a stored tuple handed out by a page.
+func (f *constrainedFile) withVirtual(t *Tuple) (*Tuple, error) {
+	virt := f.virtualCols()
+	if len(virt) == 0 || t == nil {
+		return t, nil
+	}
+	descFields := make([]FieldType, len(t.Desc.Fields), len(t.Desc.Fields)+len(virt))
+	copy(descFields, t.Desc.Fields)
+	fields := make([]DBValue, len(t.Fields), len(t.Fields)+len(virt))
+	copy(fields, t.Fields)
+	for _, cc := range virt {
+		if _, err := findFieldInTd(cc.field, &t.Desc); err == nil {
+			continue
+		}
+		v, err := cc.expr.EvalExpr(t)
+		if err != nil {
+			return nil, err
+		}
+		descFields = append(descFields, cc.field)
+		fields = append(fields, v)
+	}
+	return &Tuple{Desc: TupleDesc{Fields: descFields}, Fields: fields, Rid: t.Rid}, nil
+}
+
+// applyVirtual wraps iter so every scanned tuple carries the virtual
+// computed columns.
+func (f *constrainedFile) applyVirtual(iter func() (*Tuple, error)) func() (*Tuple, error) {
+	if len(f.virtualCols()) == 0 {
+		return iter
+	}
+	return func() (*Tuple, error) {
+		t, err := iter()
+		if err != nil || t == nil {
+			return nil, err
+		}
+		return f.withVirtual(t)
+	}
+}
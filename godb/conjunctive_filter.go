@@ -0,0 +1,133 @@
+package godb
+
+import "sort"
+
+// ConjunctiveFilter evaluates a conjunction of predicates over each child
+// tuple, short-circuiting on the first failure. Unlike a stack of Filter
+// operators it controls evaluation order: conjuncts are ranked by
+// (1 - selectivity) / cost — the classic ordering that maximizes rows
+// rejected per unit of work — so a cheap, selective int comparison runs
+// before an expensive string or function predicate. Selectivities start
+// from priors by operator kind and are refined from observed pass rates
+// as the scan runs, re-sorting every reorderInterval tuples.
+type ConjunctiveFilter struct {
+	conjuncts []*conjunct
+	child     Operator
+}
+
+// Conjunct is one predicate of a conjunction, in the repo's usual
+// (left, op, right) form with both sides evaluated on the same tuple.
+type Conjunct struct {
+	Left  Expr
+	Op    BoolOp
+	Right Expr
+}
+
+// conjunct carries a predicate plus its running statistics.
+type conjunct struct {
+	Conjunct
+	cost      int
+	evaluated int64
+	passed    int64
+}
+
+const reorderInterval = 1000
+
+// NewConjunctiveFilter returns a filter requiring every predicate in
+// conjuncts to hold.
+func NewConjunctiveFilter(child Operator, conjuncts []Conjunct) (*ConjunctiveFilter, error) {
+	if len(conjuncts) == 0 {
+		return nil, GoDBError{IllegalOperationError, "conjunctive filter needs at least one predicate"}
+	}
+	cs := make([]*conjunct, len(conjuncts))
+	for i, c := range conjuncts {
+		cs[i] = &conjunct{Conjunct: c, cost: exprCost(c.Left) + exprCost(c.Right)}
+	}
+	return &ConjunctiveFilter{conjuncts: cs, child: child}, nil
+}
+
+// exprCost is a coarse evaluation-cost estimate: constants are free, int
+// fields cheaper than strings, and anything computed (functions,
+// arithmetic, CASE) costs more again.
+func exprCost(e Expr) int {
+	switch e.(type) {
+	case *ConstExpr:
+		return 0
+	case *FieldExpr:
+		if e.GetExprType().Ftype == StringType {
+			return 2
+		}
+		return 1
+	default:
+		return 4
+	}
+}
+
+// selectivity estimates the fraction of tuples the conjunct passes: the
+// observed rate once enough tuples have been seen, an operator-kind prior
+// before that.
+func (c *conjunct) selectivity() float64 {
+	if c.evaluated >= 100 {
+		return float64(c.passed) / float64(c.evaluated)
+	}
+	switch c.Op {
+	case OpEq, OpNotDistinct:
+		return 0.1
+	case OpNeq:
+		return 0.9
+	default:
+		return 0.3
+	}
+}
+
+// reorder sorts conjuncts by descending rejected-rows-per-cost.
+func (f *ConjunctiveFilter) reorder() {
+	sort.SliceStable(f.conjuncts, func(i, j int) bool {
+		ci, cj := f.conjuncts[i], f.conjuncts[j]
+		// cost has a floor of 1 so free predicates still rank finitely.
+		ri := (1 - ci.selectivity()) / float64(max(ci.cost, 1))
+		rj := (1 - cj.selectivity()) / float64(max(cj.cost, 1))
+		return ri > rj
+	})
+}
+
+func (f *ConjunctiveFilter) Descriptor() *TupleDesc {
+	return f.child.Descriptor()
+}
+
+func (f *ConjunctiveFilter) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	childIter, err := f.child.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	f.reorder()
+	seen := int64(0)
+	return func() (*Tuple, error) {
+		for {
+			t, err := childIter()
+			if err != nil || t == nil {
+				return nil, err
+			}
+			seen++
+			if seen%reorderInterval == 0 {
+				f.reorder()
+			}
+			match := true
+			for _, c := range f.conjuncts {
+				ok, err := evalPred(t, c.Left, t, c.Right, c.Op)
+				if err != nil {
+					return nil, err
+				}
+				c.evaluated++
+				if !ok {
+					match = false
+					break
+				}
+				c.passed++
+			}
+			if match {
+				return t, nil
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,200 @@
+package godb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConstraintViolationError is returned when an insert or update would break
+// a declared constraint. Callers can type-assert on it to distinguish
+// constraint failures from storage errors.
+type ConstraintViolationError struct {
+	Table      string
+	Constraint string
+	Msg        string
+}
+
+func (e ConstraintViolationError) Error() string {
+	return fmt.Sprintf("constraint %s on table %s violated: %s", e.Constraint, e.Table, e.Msg)
+}
+
+// UniqueConstraint declares that the combination of Cols must be unique
+// within a table. Primary marks the table's primary key, which is unique
+// and at most one per table.
+type UniqueConstraint struct {
+	Table   string
+	Cols    []FieldType
+	Primary bool
+}
+
+// name returns the constraint's display name for error messages.
+func (u *UniqueConstraint) name() string {
+	names := make([]string, len(u.Cols))
+	for i, c := range u.Cols {
+		names[i] = c.Fname
+	}
+	kind := "unique"
+	if u.Primary {
+		kind = "pkey"
+	}
+	return fmt.Sprintf("%s_%s_%s", u.Table, strings.Join(names, "_"), kind)
+}
+
+// constrainedFile wraps a DBFile and enforces unique and foreign key
+// constraints on the insert and delete paths. Reads pass straight through.
+type constrainedFile struct {
+	DBFile
+	table       string
+	catalog     *Catalog
+	constraints []*UniqueConstraint
+	foreignKeys []*ForeignKeyConstraint
+	autoInc     *autoIncrement
+	indexes     []tableIndex
+	computed    []*computedColumn
+}
+
+// tableIndex is any secondary index maintained by the catalog's insert and
+// delete paths for a table. Maintenance is buffered per transaction and
+// applied at commit by the index journal.
+type tableIndex interface {
+	insert(t *Tuple) error
+	remove(t *Tuple) error
+}
+
+// insertTuple rejects t if any constraint already holds a tuple with the
+// same key values, then delegates to the underlying file. Without an index
+// the check is a full scan under the inserting transaction's locks.
+func (f *constrainedFile) insertTuple(t *Tuple, tid TransactionID) error {
+	if f.autoInc != nil {
+		if err := f.autoInc.fill(t); err != nil {
+			return err
+		}
+	}
+	if err := f.fillStored(t); err != nil {
+		return err
+	}
+	for _, uc := range f.constraints {
+		keyVals, err := constraintKey(t, uc)
+		if err != nil {
+			return err
+		}
+		iter, err := f.DBFile.Iterator(tid)
+		if err != nil {
+			return err
+		}
+		for {
+			existing, err := iter()
+			if err != nil {
+				return err
+			}
+			if existing == nil {
+				break
+			}
+			existingKey, err := constraintKey(existing, uc)
+			if err != nil {
+				return err
+			}
+			if keysEqual(keyVals, existingKey) {
+				return ConstraintViolationError{
+					Table:      f.table,
+					Constraint: uc.name(),
+					Msg:        fmt.Sprintf("duplicate key %v", keyVals),
+				}
+			}
+		}
+	}
+	if err := f.checkForeignKeys(t, tid); err != nil {
+		return err
+	}
+	if err := f.DBFile.insertTuple(t, tid); err != nil {
+		return err
+	}
+	if err := f.queueIndexOps(t, tid, false); err != nil {
+		return err
+	}
+	if f.catalog != nil {
+		f.catalog.recordChange(tid, f.table, ChangeInsert, t)
+		f.catalog.recordStatsChange(tid, f.table, ChangeInsert, t)
+		f.catalog.recordWorkloadWrite(f.table, ChangeInsert)
+		f.catalog.bumpTableVersion(f.table)
+	}
+	return nil
+}
+
+// deleteTuple applies the delete actions of any foreign keys referencing
+// this table (rejecting or cascading), then delegates.
+func (f *constrainedFile) deleteTuple(t *Tuple, tid TransactionID) error {
+	if err := f.enforceIncomingForeignKeys(t, tid); err != nil {
+		return err
+	}
+	if err := f.DBFile.deleteTuple(t, tid); err != nil {
+		return err
+	}
+	if err := f.queueIndexOps(t, tid, true); err != nil {
+		return err
+	}
+	if f.catalog != nil {
+		f.catalog.recordChange(tid, f.table, ChangeDelete, t)
+		f.catalog.recordStatsChange(tid, f.table, ChangeDelete, t)
+		f.catalog.recordWorkloadWrite(f.table, ChangeDelete)
+		f.catalog.bumpTableVersion(f.table)
+	}
+	return nil
+}
+
+// constraintKey extracts the constraint's column values from t.
+func constraintKey(t *Tuple, uc *UniqueConstraint) ([]DBValue, error) {
+	vals := make([]DBValue, len(uc.Cols))
+	for i, c := range uc.Cols {
+		idx, err := findFieldInTd(c, &t.Desc)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = t.Fields[idx]
+	}
+	return vals, nil
+}
+
+func keysEqual(a, b []DBValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// AddUniqueConstraint declares that cols must be unique in table. If
+// primary is true the constraint is the table's primary key; a table may
+// have at most one. Enforcement happens on every insert through the
+// catalog's handle for the table.
+func (c *Catalog) AddUniqueConstraint(table string, cols []string, primary bool) error {
+	cf, err := c.constrained(table)
+	if err != nil {
+		return err
+	}
+	resolved, err := resolveCols(cf.Descriptor(), cols)
+	if err != nil {
+		return err
+	}
+	if primary {
+		for _, uc := range cf.constraints {
+			if uc.Primary {
+				return GoDBError{DuplicateNameError, fmt.Sprintf("table %s already has a primary key", table)}
+			}
+		}
+	}
+	cf.constraints = append(cf.constraints, &UniqueConstraint{Table: table, Cols: resolved, Primary: primary})
+	return nil
+}
+
+// Constraints returns the unique constraints declared on table, if any.
+func (c *Catalog) Constraints(table string) []*UniqueConstraint {
+	if cf, ok := c.tables[table].(*constrainedFile); ok {
+		return cf.constraints
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package godb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PostgreSQL COPY formats, for bulk data exchange with Postgres tooling
+// (psql \copy, pg_dump COPY sections) and for the wire server's COPY
+// command. The text format is tab-separated with backslash escapes and
+// \N for NULL, terminated by a "\." line; the binary format is the
+// documented PGCOPY framing with int8 and text field encodings. NULLs
+// read into GoDB's null-free pages as zero values, matching the CSV
+// loader's convention.
+
+// copyBinarySignature opens every binary COPY stream.
+var copyBinarySignature = []byte("PGCOPY\n\xff\r\n\x00")
+
+// CopyTo writes f's rows to w in COPY text format, including the
+// terminating "\." line.
+func CopyTo(w io.Writer, f DBFile, tid TransactionID) error {
+	bw := bufio.NewWriter(w)
+	iter, err := f.Iterator(tid)
+	if err != nil {
+		return err
+	}
+	for {
+		t, err := iter()
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			break
+		}
+		for i, v := range t.Fields {
+			if i > 0 {
+				bw.WriteByte('\t')
+			}
+			switch x := v.(type) {
+			case IntField:
+				bw.WriteString(strconv.FormatInt(x.Value, 10))
+			case StringField:
+				bw.WriteString(escapeCopyText(x.Value))
+			case NullField:
+				bw.WriteString(`\N`)
+			default:
+				return GoDBError{TypeMismatchError, fmt.Sprintf("cannot COPY value %T", v)}
+			}
+		}
+		bw.WriteByte('\n')
+	}
+	bw.WriteString("\\.\n")
+	if err := bw.Flush(); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
+
+// CopyFrom inserts rows read from r in COPY text format into f,
+// returning the number of rows inserted. Input may end at EOF or at a
+// "\." terminator line.
+func CopyFrom(f DBFile, r io.Reader, tid TransactionID) (int, error) {
+	desc := f.Descriptor()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	count := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == `\.` {
+			break
+		}
+		cols := strings.Split(line, "\t")
+		if len(cols) != len(desc.Fields) {
+			return count, GoDBError{MalformedDataError, fmt.Sprintf("COPY row %d has %d fields, expected %d", count+1, len(cols), len(desc.Fields))}
+		}
+		vals := make([]DBValue, len(cols))
+		for i, col := range cols {
+			v, err := copyTextValue(col, desc.Fields[i].Ftype)
+			if err != nil {
+				return count, err
+			}
+			vals[i] = v
+		}
+		if err := f.insertTuple(&Tuple{Desc: *desc.copy(), Fields: vals}, tid); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, GoDBError{OsError, err.Error()}
+	}
+	return count, nil
+}
+
+// CopyToBinary writes f's rows to w in COPY binary format.
+func CopyToBinary(w io.Writer, f DBFile, tid TransactionID) error {
+	bw := bufio.NewWriter(w)
+	bw.Write(copyBinarySignature)
+	var hdr [8]byte // flags and extension length, both zero
+	bw.Write(hdr[:])
+	iter, err := f.Iterator(tid)
+	if err != nil {
+		return err
+	}
+	for {
+		t, err := iter()
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			break
+		}
+		binary.Write(bw, binary.BigEndian, int16(len(t.Fields)))
+		for _, v := range t.Fields {
+			switch x := v.(type) {
+			case IntField:
+				binary.Write(bw, binary.BigEndian, int32(8))
+				binary.Write(bw, binary.BigEndian, x.Value)
+			case StringField:
+				binary.Write(bw, binary.BigEndian, int32(len(x.Value)))
+				bw.WriteString(x.Value)
+			case NullField:
+				binary.Write(bw, binary.BigEndian, int32(-1))
+			default:
+				return GoDBError{TypeMismatchError, fmt.Sprintf("cannot COPY value %T", v)}
+			}
+		}
+	}
+	binary.Write(bw, binary.BigEndian, int16(-1))
+	if err := bw.Flush(); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
+
+// CopyFromBinary inserts rows read from r in COPY binary format into f,
+// returning the number of rows inserted.
+func CopyFromBinary(f DBFile, r io.Reader, tid TransactionID) (int, error) {
+	desc := f.Descriptor()
+	br := bufio.NewReader(r)
+	sig := make([]byte, len(copyBinarySignature))
+	if _, err := io.ReadFull(br, sig); err != nil || !bytes.Equal(sig, copyBinarySignature) {
+		return 0, GoDBError{MalformedDataError, "input does not start with the COPY binary signature"}
+	}
+	var flags, extLen int32
+	if err := binary.Read(br, binary.BigEndian, &flags); err != nil {
+		return 0, GoDBError{MalformedDataError, "truncated COPY binary header"}
+	}
+	if err := binary.Read(br, binary.BigEndian, &extLen); err != nil {
+		return 0, GoDBError{MalformedDataError, "truncated COPY binary header"}
+	}
+	if extLen > 0 {
+		if _, err := io.CopyN(io.Discard, br, int64(extLen)); err != nil {
+			return 0, GoDBError{MalformedDataError, "truncated COPY binary header extension"}
+		}
+	}
+	count := 0
+	for {
+		var nfields int16
+		if err := binary.Read(br, binary.BigEndian, &nfields); err != nil {
+			return count, GoDBError{MalformedDataError, "COPY binary stream ends without trailer"}
+		}
+		if nfields == -1 {
+			return count, nil
+		}
+		if int(nfields) != len(desc.Fields) {
+			return count, GoDBError{MalformedDataError, fmt.Sprintf("COPY row %d has %d fields, expected %d", count+1, nfields, len(desc.Fields))}
+		}
+		vals := make([]DBValue, nfields)
+		for i := range vals {
+			var flen int32
+			if err := binary.Read(br, binary.BigEndian, &flen); err != nil {
+				return count, GoDBError{MalformedDataError, "truncated COPY binary row"}
+			}
+			if flen == -1 {
+				if desc.Fields[i].Ftype == IntType {
+					vals[i] = IntField{0}
+				} else {
+					vals[i] = StringField{""}
+				}
+				continue
+			}
+			data := make([]byte, flen)
+			if _, err := io.ReadFull(br, data); err != nil {
+				return count, GoDBError{MalformedDataError, "truncated COPY binary row"}
+			}
+			switch desc.Fields[i].Ftype {
+			case IntType:
+				switch flen {
+				case 8:
+					vals[i] = IntField{int64(binary.BigEndian.Uint64(data))}
+				case 4:
+					vals[i] = IntField{int64(int32(binary.BigEndian.Uint32(data)))}
+				case 2:
+					vals[i] = IntField{int64(int16(binary.BigEndian.Uint16(data)))}
+				default:
+					return count, GoDBError{MalformedDataError, fmt.Sprintf("COPY binary int field of %d bytes", flen)}
+				}
+			case StringType:
+				s := string(data)
+				if len(s) > StringLength {
+					s = s[:StringLength]
+				}
+				vals[i] = StringField{s}
+			}
+		}
+		if err := f.insertTuple(&Tuple{Desc: *desc.copy(), Fields: vals}, tid); err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// escapeCopyText applies the COPY text escapes.
+func escapeCopyText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\v':
+			b.WriteString(`\v`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// copyTextValue converts one COPY text field, applying unescapes and the
+// \N null marker.
+func copyTextValue(col string, ftype DBType) (DBValue, error) {
+	if col == `\N` {
+		if ftype == IntType {
+			return IntField{0}, nil
+		}
+		return StringField{""}, nil
+	}
+	s, err := unescapeCopyText(col)
+	if err != nil {
+		return nil, err
+	}
+	if ftype == IntType {
+		v, perr := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if perr != nil {
+			return nil, GoDBError{MalformedDataError, fmt.Sprintf("bad COPY int %q: %v", col, perr)}
+		}
+		return IntField{v}, nil
+	}
+	if len(s) > StringLength {
+		s = s[:StringLength]
+	}
+	return StringField{s}, nil
+}
+
+// unescapeCopyText reverses escapeCopyText.
+func unescapeCopyText(s string) (string, error) {
+	if !strings.ContainsRune(s, '\\') {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", GoDBError{MalformedDataError, "COPY field ends mid-escape"}
+		}
+		switch s[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		default:
+			return "", GoDBError{MalformedDataError, fmt.Sprintf("unknown COPY escape \\%c", s[i])}
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,181 @@
+package godb
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Parallel CSV ingestion. Quoted fields may contain newlines, so the
+// input cannot be split into byte ranges without parsing it; instead one
+// reader goroutine runs the CSV parser and deals batches of parsed
+// records to N workers, which convert them to tuples and insert, one
+// committed transaction per batch. Heap inserts from different workers
+// land on different pages (the allocation latch hands each extension a
+// distinct page), so the workers contend only briefly.
+
+// csvBatchSize is how many records a worker converts and inserts per
+// transaction; larger batches amortize transaction overhead, smaller
+// ones shrink lock footprints.
+const csvBatchSize = 256
+
+// csvRecordBatch is one unit of work for a parser worker.
+type csvRecordBatch struct {
+	records [][]string
+	lines   []int
+}
+
+// LoadFromCSVParallel bulk loads the file from r according to d using
+// workers concurrent inserters (1 behaves like LoadFromCSVDialect with
+// batched transactions). Rows rejected under d.SkipMalformed are
+// reported in no particular order; without it the first bad row stops
+// the load, though rows already inserted by other workers stay.
+func (f *HeapFile) LoadFromCSVParallel(r io.Reader, d CSVDialect, workers int) ([]CSVReject, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	r, err := DecompressReader(r)
+	if err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(r)
+	cr.Comma = ','
+	if d.Comma != 0 {
+		cr.Comma = d.Comma
+	}
+	cr.Comment = d.Comment
+	cr.LazyQuotes = d.LazyQuotes
+	cr.FieldsPerRecord = -1
+
+	var (
+		mu       sync.Mutex
+		rejects  []CSVReject
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+	reject := func(line int, record []string, err error) {
+		if !d.SkipMalformed {
+			fail(err)
+			return
+		}
+		mu.Lock()
+		rejects = append(rejects, CSVReject{Line: line, Record: record, Err: err})
+		mu.Unlock()
+	}
+
+	batches := make(chan csvRecordBatch, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				if failed() {
+					continue // drain so the reader never blocks
+				}
+				f.insertCSVBatch(batch, d, reject, fail)
+			}
+		}()
+	}
+
+	pending := csvRecordBatch{}
+	flush := func() {
+		if len(pending.records) > 0 {
+			batches <- pending
+			pending = csvRecordBatch{}
+		}
+	}
+	first := true
+	for !failed() {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if pe, ok := err.(*csv.ParseError); ok {
+				reject(pe.Line, nil, GoDBError{MalformedDataError, err.Error()})
+				continue
+			}
+			fail(GoDBError{OsError, err.Error()})
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if first && d.HasHeader {
+			first = false
+			continue
+		}
+		first = false
+		pending.records = append(pending.records, record)
+		pending.lines = append(pending.lines, line)
+		if len(pending.records) >= csvBatchSize {
+			flush()
+		}
+	}
+	flush()
+	close(batches)
+	wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	return rejects, firstErr
+}
+
+// insertCSVBatch converts and inserts one batch of records under a single
+// transaction. A batch whose transaction loses a deadlock is aborted —
+// which rolls back its partial inserts — and retried from scratch; other
+// insert failures abort the load.
+func (f *HeapFile) insertCSVBatch(batch csvRecordBatch, d CSVDialect, reject func(int, []string, error), fail func(error)) {
+	// Conversion failures are per-row and deterministic; report them once,
+	// outside the retry loop.
+	var values [][]DBValue
+	for i, record := range batch.records {
+		line := batch.lines[i]
+		if d.SkipLastField && len(record) > 0 {
+			record = record[:len(record)-1]
+		}
+		if len(record) != len(f.desc.Fields) {
+			reject(line, record, GoDBError{MalformedDataError, fmt.Sprintf("line %d has %d fields, expected %d", line, len(record), len(f.desc.Fields))})
+			continue
+		}
+		vals, err := f.convertCSVRecord(record, d, line)
+		if err != nil {
+			reject(line, record, err)
+			continue
+		}
+		values = append(values, vals)
+	}
+	if len(values) == 0 {
+		return
+	}
+retry:
+	for attempt := 0; ; attempt++ {
+		tid := NewTID()
+		if err := f.bufPool.BeginTransaction(tid); err != nil {
+			fail(err)
+			return
+		}
+		for _, vals := range values {
+			if err := f.insertTuple(&Tuple{Desc: *f.desc.copy(), Fields: vals}, tid); err != nil {
+				f.bufPool.AbortTransaction(tid)
+				if ge, ok := err.(GoDBError); ok && ge.code == DeadlockError && attempt < 10 {
+					continue retry
+				}
+				fail(err)
+				return
+			}
+		}
+		f.bufPool.CommitTransaction(tid)
+		return
+	}
+}
@@ -0,0 +1,118 @@
+package godb
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Server-side cursors. A Cursor owns an operator's iterator and hands out
+// results in caller-sized batches, so the position lives in the cursor
+// rather than in a closure a wire-protocol handler would have to keep on
+// its stack. CursorRegistry maps client-visible cursor ids to open
+// cursors, giving a future server fetch-in-batches semantics: open a
+// query, return the id, serve FETCH requests against it, close it.
+
+// Cursor is an open iteration over one operator's results.
+type Cursor struct {
+	op      Operator
+	tid     TransactionID
+	iter    func() (*Tuple, error)
+	done    bool
+	fetched int64
+}
+
+// NewCursor opens a cursor over op's results under tid. The iterator is
+// opened immediately, so operator errors surface here rather than on the
+// first fetch.
+func NewCursor(op Operator, tid TransactionID) (*Cursor, error) {
+	iter, err := op.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	return &Cursor{op: op, tid: tid, iter: iter}, nil
+}
+
+// Descriptor returns the schema of the cursor's tuples.
+func (c *Cursor) Descriptor() *TupleDesc {
+	return c.op.Descriptor()
+}
+
+// FetchN returns up to n tuples, advancing the cursor. A short (possibly
+// empty) batch means the results are exhausted.
+func (c *Cursor) FetchN(n int) ([]*Tuple, error) {
+	if c.done || n <= 0 {
+		return nil, nil
+	}
+	batch := make([]*Tuple, 0, n)
+	for len(batch) < n {
+		t, err := c.iter()
+		if err != nil {
+			return batch, err
+		}
+		if t == nil {
+			c.done = true
+			break
+		}
+		batch = append(batch, t)
+	}
+	c.fetched += int64(len(batch))
+	return batch, nil
+}
+
+// Done reports whether the cursor has produced its last tuple.
+func (c *Cursor) Done() bool {
+	return c.done
+}
+
+// Fetched returns the number of tuples handed out so far.
+func (c *Cursor) Fetched() int64 {
+	return c.fetched
+}
+
+// CursorRegistry tracks open cursors by id on behalf of a server.
+type CursorRegistry struct {
+	mu      sync.Mutex
+	nextID  int64
+	cursors map[int64]*Cursor
+}
+
+// NewCursorRegistry returns an empty registry.
+func NewCursorRegistry() *CursorRegistry {
+	return &CursorRegistry{cursors: make(map[int64]*Cursor)}
+}
+
+// Open opens a cursor over op under tid and returns its id.
+func (r *CursorRegistry) Open(op Operator, tid TransactionID) (int64, error) {
+	cur, err := NewCursor(op, tid)
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	r.cursors[r.nextID] = cur
+	return r.nextID, nil
+}
+
+// Fetch returns up to n tuples from cursor id. Exhausted cursors are
+// closed automatically.
+func (r *CursorRegistry) Fetch(id int64, n int) ([]*Tuple, error) {
+	r.mu.Lock()
+	cur, ok := r.cursors[id]
+	r.mu.Unlock()
+	if !ok {
+		return nil, GoDBError{NoSuchTableError, fmt.Sprintf("no open cursor %d", id)}
+	}
+	batch, err := cur.FetchN(n)
+	if err == nil && cur.Done() {
+		r.Close(id)
+	}
+	return batch, err
+}
+
+// Close forgets cursor id; closing an unknown id is a no-op.
+func (r *CursorRegistry) Close(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cursors, id)
+}
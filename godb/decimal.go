@@ -0,0 +1,197 @@
+package godb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DecimalField is an exact fixed-point numeric value: Unscaled holds the
+// value multiplied by 10^Scale, so 123.45 at scale 2 is stored as 12345.
+// Arithmetic and comparison work on the scaled integers and are exact,
+// which is the point — monetary columns like salary or bonus cannot
+// tolerate float rounding. A column's scale lives in its FieldType and
+// every value stored in it carries that scale.
+type DecimalField struct {
+	Unscaled int64
+	Scale    int
+}
+
+func (DecimalField) isDBValue() {}
+
+// maxDecimalScale bounds the scale so 10^Scale fits in an int64.
+const maxDecimalScale = 18
+
+// decimalPow10 returns 10^n for n in [0, maxDecimalScale].
+func decimalPow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
+
+// String renders the value with Scale fractional digits, e.g. "123.45".
+func (d DecimalField) String() string {
+	if d.Scale == 0 {
+		return fmt.Sprintf("%d", d.Unscaled)
+	}
+	u := d.Unscaled
+	sign := ""
+	if u < 0 {
+		sign, u = "-", -u
+	}
+	p := decimalPow10(d.Scale)
+	return fmt.Sprintf("%s%d.%0*d", sign, u/p, d.Scale, u%p)
+}
+
+// ParseDecimal parses a literal like "123.45" or "-7" into a field with
+// the given scale, rejecting malformed text, scales outside
+// [0, maxDecimalScale], and literals with more fractional digits than
+// scale — exactness means never rounding silently.
+func ParseDecimal(s string, scale int) (DecimalField, error) {
+	if scale < 0 || scale > maxDecimalScale {
+		return DecimalField{}, GoDBError{TypeMismatchError, fmt.Sprintf("decimal scale %d out of range", scale)}
+	}
+	text := strings.TrimSpace(s)
+	neg := false
+	switch {
+	case strings.HasPrefix(text, "-"):
+		neg, text = true, text[1:]
+	case strings.HasPrefix(text, "+"):
+		text = text[1:]
+	}
+	whole, frac := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		whole, frac = text[:i], text[i+1:]
+	}
+	if whole == "" && frac == "" {
+		return DecimalField{}, GoDBError{MalformedDataError, fmt.Sprintf("malformed decimal %q", s)}
+	}
+	if len(frac) > scale {
+		return DecimalField{}, GoDBError{MalformedDataError, fmt.Sprintf("decimal %q has more than %d fractional digits", s, scale)}
+	}
+	u := int64(0)
+	for _, digits := range []string{whole, frac} {
+		for _, c := range digits {
+			if c < '0' || c > '9' {
+				return DecimalField{}, GoDBError{MalformedDataError, fmt.Sprintf("malformed decimal %q", s)}
+			}
+			u = u*10 + int64(c-'0')
+		}
+	}
+	u *= decimalPow10(scale - len(frac))
+	if neg {
+		u = -u
+	}
+	return DecimalField{Unscaled: u, Scale: scale}, nil
+}
+
+// rescale returns d expressed at the target scale. Scaling up is always
+// exact; scaling down fails unless the dropped digits are zero.
+func (d DecimalField) rescale(scale int) (DecimalField, error) {
+	switch {
+	case scale == d.Scale:
+		return d, nil
+	case scale > d.Scale:
+		return DecimalField{Unscaled: d.Unscaled * decimalPow10(scale-d.Scale), Scale: scale}, nil
+	default:
+		p := decimalPow10(d.Scale - scale)
+		if d.Unscaled%p != 0 {
+			return DecimalField{}, GoDBError{TypeMismatchError, fmt.Sprintf("cannot represent %s at scale %d exactly", d, scale)}
+		}
+		return DecimalField{Unscaled: d.Unscaled / p, Scale: scale}, nil
+	}
+}
+
+// alignDecimals expresses a and b at their larger scale.
+func alignDecimals(a, b DecimalField) (x, y int64, scale int) {
+	scale = a.Scale
+	if b.Scale > scale {
+		scale = b.Scale
+	}
+	return a.Unscaled * decimalPow10(scale-a.Scale), b.Unscaled * decimalPow10(scale-b.Scale), scale
+}
+
+// compareDecimals orders a against b, returning -1, 0, or +1.
+func compareDecimals(a, b DecimalField) int {
+	x, y, _ := alignDecimals(a, b)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// toDecimal widens v to a decimal; ints become scale-0 decimals so mixed
+// int/decimal arithmetic stays exact.
+func toDecimal(v DBValue) (DecimalField, bool) {
+	switch x := v.(type) {
+	case DecimalField:
+		return x, true
+	case IntField:
+		return DecimalField{Unscaled: x.Value, Scale: 0}, true
+	}
+	return DecimalField{}, false
+}
+
+// evalDecimalArith applies op to two numeric values, at least one of them
+// a decimal. Addition and subtraction align to the larger scale;
+// multiplication adds the scales, trimming trailing zero digits (or
+// failing) when the sum would exceed maxDecimalScale; division and
+// modulus keep the larger operand scale, with division truncating toward
+// zero at that scale (like int division).
+func evalDecimalArith(lv, rv DBValue, op ArithOp) (DBValue, error) {
+	l, ok := toDecimal(lv)
+	if !ok {
+		return nil, GoDBError{TypeMismatchError, "arithmetic on non-numeric operand"}
+	}
+	r, ok := toDecimal(rv)
+	if !ok {
+		return nil, GoDBError{TypeMismatchError, "arithmetic on non-numeric operand"}
+	}
+	switch op {
+	case OpAdd:
+		x, y, s := alignDecimals(l, r)
+		return DecimalField{Unscaled: x + y, Scale: s}, nil
+	case OpSub:
+		x, y, s := alignDecimals(l, r)
+		return DecimalField{Unscaled: x - y, Scale: s}, nil
+	case OpMul:
+		// The combined scale can exceed maxDecimalScale, past which
+		// 10^Scale no longer fits an int64 and rendering or rescaling the
+		// result would silently overflow. Drop trailing zero digits from
+		// the operands (before multiplying, so the trimmed digits cannot
+		// overflow the product) until the combined scale fits; if that
+		// would lose digits, reject the product — exactness means never
+		// rounding silently.
+		for l.Scale+r.Scale > maxDecimalScale {
+			switch {
+			case l.Scale > 0 && l.Unscaled%10 == 0:
+				l.Unscaled /= 10
+				l.Scale--
+			case r.Scale > 0 && r.Unscaled%10 == 0:
+				r.Unscaled /= 10
+				r.Scale--
+			default:
+				return nil, GoDBError{TypeMismatchError,
+					fmt.Sprintf("product scale %d exceeds the maximum decimal scale %d", l.Scale+r.Scale, maxDecimalScale)}
+			}
+		}
+		return DecimalField{Unscaled: l.Unscaled * r.Unscaled, Scale: l.Scale + r.Scale}, nil
+	case OpDiv:
+		x, y, s := alignDecimals(l, r)
+		if y == 0 {
+			return nil, GoDBError{IllegalOperationError, "division by zero"}
+		}
+		return DecimalField{Unscaled: x * decimalPow10(s) / y, Scale: s}, nil
+	case OpMod:
+		x, y, s := alignDecimals(l, r)
+		if y == 0 {
+			return nil, GoDBError{IllegalOperationError, "division by zero"}
+		}
+		return DecimalField{Unscaled: x % y, Scale: s}, nil
+	}
+	return nil, GoDBError{IllegalOperationError, fmt.Sprintf("unknown arithmetic op %d", op)}
+}
@@ -0,0 +1,85 @@
+package godb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Transparent input decompression for the bulk loaders. DecompressReader
+// sniffs the leading magic bytes of a stream and, when they name a
+// registered format, interposes the matching decompressor; unrecognized
+// input passes through untouched, so callers can hand any reader to the
+// CSV loaders without knowing whether the dataset shipped compressed.
+// The stdlib gzip format is built in; a zstd decompressor can be plugged
+// in via RegisterStreamDecompressor without adding dependencies to the
+// core.
+
+// StreamDecompressor turns a compressed stream into a plain one.
+type StreamDecompressor interface {
+	// Name identifies the format (for error messages and lookups).
+	Name() string
+	// Magic returns the leading bytes identifying the format.
+	Magic() []byte
+	// Open wraps r, which begins with Magic, in a decompressing reader.
+	Open(r io.Reader) (io.Reader, error)
+}
+
+var (
+	decompMu sync.RWMutex
+	decomps  []StreamDecompressor
+)
+
+// RegisterStreamDecompressor makes d available to DecompressReader;
+// re-registering a name replaces the previous decompressor.
+func RegisterStreamDecompressor(d StreamDecompressor) {
+	decompMu.Lock()
+	defer decompMu.Unlock()
+	for i, existing := range decomps {
+		if strings.EqualFold(existing.Name(), d.Name()) {
+			decomps[i] = d
+			return
+		}
+	}
+	decomps = append(decomps, d)
+}
+
+// DecompressReader returns a reader yielding the decompressed content of
+// r if its leading bytes match a registered format, and r's own content
+// otherwise. Streams shorter than any magic sequence pass through.
+func DecompressReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	decompMu.RLock()
+	defer decompMu.RUnlock()
+	for _, d := range decomps {
+		magic := d.Magic()
+		head, err := br.Peek(len(magic))
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, GoDBError{OsError, err.Error()}
+		}
+		if bytes.Equal(head, magic) {
+			out, err := d.Open(br)
+			if err != nil {
+				return nil, GoDBError{MalformedDataError, err.Error()}
+			}
+			return out, nil
+		}
+	}
+	return br, nil
+}
+
+// gzipDecompressor is the built-in stdlib gzip format.
+type gzipDecompressor struct{}
+
+func (gzipDecompressor) Name() string  { return "gzip" }
+func (gzipDecompressor) Magic() []byte { return []byte{0x1f, 0x8b} }
+func (gzipDecompressor) Open(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+func init() {
+	RegisterStreamDecompressor(gzipDecompressor{})
+}
@@ -0,0 +1,122 @@
+package godb
+
+// DeleteOp deletes every tuple of its child from deleteFile and produces a
+// single tuple containing the number of rows deleted. Like InsertOp,
+// nothing is removed until the first call to the iterator; all deletions
+// then happen under the caller's transaction.
+type DeleteOp struct {
+	deleteFile  DBFile
+	child       Operator
+	collectRids bool
+	returning   bool
+	rids        []RecordID
+}
+
+// NewDeleteOp returns a delete operator removing child's tuples from
+// deleteFile. The child is typically a filtered scan of the same file.
+func NewDeleteOp(deleteFile DBFile, child Operator) *DeleteOp {
+	return &DeleteOp{deleteFile: deleteFile, child: child}
+}
+
+// CollectRids makes the operator record the record id of every deleted
+// tuple for retrieval via AffectedRids after the iterator completes.
+func (d *DeleteOp) CollectRids() *DeleteOp {
+	d.collectRids = true
+	return d
+}
+
+// AffectedRids returns the record ids collected during the last execution;
+// it is only populated when CollectRids was called first.
+func (d *DeleteOp) AffectedRids() []RecordID {
+	return d.rids
+}
+
+// Returning switches the operator into RETURNING mode: instead of a count
+// it emits each deleted tuple, record id included, so applications can see
+// exactly which rows were removed without a preceding scan.
+func (d *DeleteOp) Returning() *DeleteOp {
+	d.returning = true
+	return d
+}
+
+func (d *DeleteOp) Descriptor() *TupleDesc {
+	if d.returning {
+		return d.deleteFile.Descriptor()
+	}
+	return &TupleDesc{Fields: []FieldType{{Fname: "count", Ftype: IntType}}}
+}
+
+func (d *DeleteOp) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	childIter, err := d.child.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	if d.returning {
+		// Collect up front — as below, so the scan does not observe its own
+		// deletions — then delete and emit one tuple per call.
+		var tuples []*Tuple
+		collected := false
+		count := int64(0)
+		return func() (*Tuple, error) {
+			if !collected {
+				for {
+					t, err := childIter()
+					if err != nil {
+						return nil, PartialError{Applied: 0, Err: err}
+					}
+					if t == nil {
+						break
+					}
+					tuples = append(tuples, t)
+				}
+				collected = true
+			}
+			if len(tuples) == 0 {
+				return nil, nil
+			}
+			t := tuples[0]
+			tuples = tuples[1:]
+			if err := d.deleteFile.deleteTuple(t, tid); err != nil {
+				return nil, PartialError{Applied: count, Err: err}
+			}
+			count++
+			return t, nil
+		}, nil
+	}
+	done := false
+	return func() (*Tuple, error) {
+		if done {
+			return nil, nil
+		}
+		if d.collectRids {
+			d.rids = nil
+		}
+		// Collect first, then delete, so the scan does not observe its own
+		// deletions.
+		var tuples []*Tuple
+		for {
+			t, err := childIter()
+			if err != nil {
+				return nil, PartialError{Applied: 0, Err: err}
+			}
+			if t == nil {
+				break
+			}
+			tuples = append(tuples, t)
+		}
+		count := int64(0)
+		for _, t := range tuples {
+			if err := d.deleteFile.deleteTuple(t, tid); err != nil {
+				return nil, PartialError{Applied: count, Err: err}
+			}
+			if d.collectRids {
+				if rid, ok := t.Rid.(RecordID); ok {
+					d.rids = append(d.rids, rid)
+				}
+			}
+			count++
+		}
+		done = true
+		return &Tuple{Desc: *d.Descriptor(), Fields: []DBValue{IntField{count}}}, nil
+	}, nil
+}
@@ -0,0 +1,46 @@
+package godb
+
+import "sync"
+
+// dirtyState is a page's dirty flag together with the transaction that
+// dirtied it, guarded by its own mutex: setDirty runs under the dirtier's
+// page lock, but isDirty is read by eviction, commit, and the audits from
+// other goroutines, so unguarded bools were a data race. Attributing the
+// dirtier lets rollback discard exactly the pages the aborting
+// transaction modified instead of every dirty page it happens to hold a
+// lock on.
+type dirtyState struct {
+	mu    sync.Mutex
+	dirty bool
+	tid   TransactionID
+}
+
+// isDirty reports whether the page is dirty.
+func (d *dirtyState) isDirty() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dirty
+}
+
+// set marks the page dirty on behalf of tid, or clean (the tid is then
+// cleared, whatever was passed).
+func (d *dirtyState) set(tid TransactionID, dirty bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dirty = dirty
+	if dirty {
+		d.tid = tid
+	} else {
+		d.tid = nil
+	}
+}
+
+// dirtier returns the transaction that dirtied the page, nil if clean.
+func (d *dirtyState) dirtier() TransactionID {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.dirty {
+		return nil
+	}
+	return d.tid
+}
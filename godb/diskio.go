@@ -0,0 +1,47 @@
+package godb
+
+import "os"
+
+// WriteFault intercepts page writes for fault-injection testing. It
+// receives the target path, offset, and the bytes about to be written, and
+// returns how many of those bytes should actually reach the file plus an
+// error to surface to the writer. Returning (len(data), nil) lets the write
+// proceed untouched; returning fewer bytes simulates a torn write and a
+// non-nil error simulates a crashed or failed write.
+type WriteFault func(path string, off int64, data []byte) (int, error)
+
+// writeFault is consulted on every page write when set. Test-only; nil in
+// production.
+var writeFault WriteFault
+
+// SetWriteFault installs (or, with nil, removes) the write fault hook.
+func SetWriteFault(f WriteFault) {
+	writeFault = f
+}
+
+// writePageAt writes one page image to file at off, honoring any installed
+// write fault.
+func writePageAt(file *os.File, path string, off int64, data []byte) error {
+	if writeFault != nil {
+		n, ferr := writeFault(path, off, data)
+		if n > len(data) {
+			n = len(data)
+		}
+		if n > 0 {
+			if _, err := file.WriteAt(data[:n], off); err != nil {
+				return GoDBError{OsError, err.Error()}
+			}
+		}
+		if ferr != nil {
+			return GoDBError{OsError, ferr.Error()}
+		}
+		if n < len(data) {
+			return GoDBError{OsError, "injected short write"}
+		}
+		return nil
+	}
+	if _, err := file.WriteAt(data, off); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
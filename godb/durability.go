@@ -0,0 +1,83 @@
+package godb
+
+import "os"
+
+// DurabilityMode controls when page writes reach stable storage. flushPage
+// writes through the OS page cache; without an fsync a crash of the
+// machine (not just the process) can lose writes the pool already
+// considers flushed. Each mode trades durability against throughput.
+type DurabilityMode int
+
+const (
+	// DurabilityAsync never fsyncs: writes are durable whenever the OS
+	// writes them back. This is the historical behavior and the default.
+	DurabilityAsync DurabilityMode = iota
+	// DurabilitySyncOnCommit fsyncs every file a committing transaction
+	// flushed pages to, once, at commit.
+	DurabilitySyncOnCommit
+	// DurabilitySyncAlways fsyncs after every page flush.
+	DurabilitySyncAlways
+)
+
+// SetDurability configures when the pool fsyncs flushed pages.
+func (bp *BufferPool) SetDurability(mode DurabilityMode) {
+	bp.durability.Store(int32(mode))
+}
+
+// syncEveryFlush reports whether flushPage should fsync before returning.
+// flushPage runs both inside and outside bp.mu, so the mode is read
+// atomically rather than under the pool lock.
+func (bp *BufferPool) syncEveryFlush() bool {
+	return DurabilityMode(bp.durability.Load()) == DurabilitySyncAlways
+}
+
+// syncOnCommit reports whether commit should fsync the files it flushed.
+func (bp *BufferPool) syncOnCommit() bool {
+	return DurabilityMode(bp.durability.Load()) == DurabilitySyncOnCommit
+}
+
+// syncFile fsyncs the file at path.
+func syncFile(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return GoDBError{OsError, err.Error()}
+	}
+	if err := f.Close(); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
+
+// Sync forces the heap file's writes to stable storage.
+func (f *HeapFile) Sync() error {
+	return syncFile(f.fileName)
+}
+
+// Sync forces every column's writes to stable storage.
+func (f *ColumnFile) Sync() error {
+	for i := range f.desc.Fields {
+		if err := syncFile(f.columnPath(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync is a no-op; a MemFile has no durable state.
+func (f *MemFile) Sync() error {
+	return nil
+}
+
+// Sync forces every partition's writes to stable storage.
+func (f *partitionedFile) Sync() error {
+	for _, p := range f.parts {
+		if err := p.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
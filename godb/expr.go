@@ -0,0 +1,141 @@
+package godb
+
+import "fmt"
+
+// Expr is an expression evaluated against one tuple at a time.
+type Expr interface {
+	// EvalExpr computes the value of the expression on tuple t.
+	EvalExpr(t *Tuple) (DBValue, error)
+	// GetExprType returns the name and type the expression produces.
+	GetExprType() FieldType
+}
+
+// FieldExpr evaluates to the value of one field of the input tuple.
+type FieldExpr struct {
+	selectField FieldType
+}
+
+// NewFieldExpr returns an expression selecting field f from its input.
+func NewFieldExpr(f FieldType) *FieldExpr {
+	return &FieldExpr{selectField: f}
+}
+
+func (f *FieldExpr) EvalExpr(t *Tuple) (DBValue, error) {
+	idx, err := findFieldInTd(f.selectField, &t.Desc)
+	if err != nil {
+		return nil, err
+	}
+	return t.Fields[idx], nil
+}
+
+func (f *FieldExpr) GetExprType() FieldType {
+	return f.selectField
+}
+
+// ConstExpr evaluates to a constant value.
+type ConstExpr struct {
+	val       DBValue
+	constType DBType
+}
+
+// NewConstExpr returns an expression producing val of type t.
+func NewConstExpr(val DBValue, t DBType) *ConstExpr {
+	return &ConstExpr{val: val, constType: t}
+}
+
+func (c *ConstExpr) EvalExpr(t *Tuple) (DBValue, error) {
+	return c.val, nil
+}
+
+func (c *ConstExpr) GetExprType() FieldType {
+	return FieldType{Fname: "const", Ftype: c.constType}
+}
+
+// ArithOp names a binary arithmetic operation on int fields.
+type ArithOp int
+
+const (
+	OpAdd ArithOp = iota
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+)
+
+// ArithExpr applies a binary arithmetic operator to two numeric
+// subexpressions; if either side is a decimal the result is a decimal,
+// computed exactly.
+type ArithExpr struct {
+	left  Expr
+	right Expr
+	op    ArithOp
+}
+
+// NewArithExpr returns an expression computing `left op right`.
+func NewArithExpr(left Expr, op ArithOp, right Expr) *ArithExpr {
+	return &ArithExpr{left: left, right: right, op: op}
+}
+
+func (a *ArithExpr) EvalExpr(t *Tuple) (DBValue, error) {
+	lv, err := a.left.EvalExpr(t)
+	if err != nil {
+		return nil, err
+	}
+	rv, err := a.right.EvalExpr(t)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := lv.(DecimalField); ok {
+		return evalDecimalArith(lv, rv, a.op)
+	}
+	if _, ok := rv.(DecimalField); ok {
+		return evalDecimalArith(lv, rv, a.op)
+	}
+	li, ok := lv.(IntField)
+	if !ok {
+		return nil, GoDBError{TypeMismatchError, "arithmetic on non-int operand"}
+	}
+	ri, ok := rv.(IntField)
+	if !ok {
+		return nil, GoDBError{TypeMismatchError, "arithmetic on non-int operand"}
+	}
+	switch a.op {
+	case OpAdd:
+		return IntField{li.Value + ri.Value}, nil
+	case OpSub:
+		return IntField{li.Value - ri.Value}, nil
+	case OpMul:
+		return IntField{li.Value * ri.Value}, nil
+	case OpDiv:
+		if ri.Value == 0 {
+			return nil, GoDBError{IllegalOperationError, "division by zero"}
+		}
+		return IntField{li.Value / ri.Value}, nil
+	case OpMod:
+		if ri.Value == 0 {
+			return nil, GoDBError{IllegalOperationError, "division by zero"}
+		}
+		return IntField{li.Value % ri.Value}, nil
+	}
+	return nil, GoDBError{IllegalOperationError, fmt.Sprintf("unknown arithmetic op %d", a.op)}
+}
+
+func (a *ArithExpr) GetExprType() FieldType {
+	lt, rt := a.left.GetExprType(), a.right.GetExprType()
+	if lt.Ftype == DecimalType || rt.Ftype == DecimalType {
+		// Mirror evalDecimalArith's result scales: multiplication adds
+		// the operand scales, everything else keeps the larger one.
+		scale := lt.Scale
+		if rt.Scale > scale {
+			scale = rt.Scale
+		}
+		if a.op == OpMul {
+			scale = lt.Scale + rt.Scale
+			if scale > maxDecimalScale {
+				scale = maxDecimalScale
+			}
+		}
+		return FieldType{Fname: "expr", Ftype: DecimalType, Scale: scale}
+	}
+	return FieldType{Fname: "expr", Ftype: IntType}
+}
@@ -0,0 +1,224 @@
+package godb
+
+import "time"
+
+// Multi-granularity locking. Page locks alone make table-level operations
+// (drop, truncate, bulk load) enumerate and lock every page; intent locks
+// let them take one file-level lock instead. GetPage acquires an intent
+// lock (IS for reads, IX for writes) on the file before its page lock, so
+// a file-level S or X lock taken with LockFile conflicts exactly with the
+// transactions it must — fine-grained readers under IS never conflict
+// with each other.
+
+// lockMode is a file-level lock mode.
+type lockMode int
+
+const (
+	lockIS lockMode = iota // intent shared: page-level reads below
+	lockIX                 // intent exclusive: page-level writes below
+	lockS                  // shared: whole-file read
+	lockX                  // exclusive: whole-file write
+)
+
+// lockCompat[a][b] reports whether a lock of mode a held by one
+// transaction is compatible with a request of mode b by another.
+var lockCompat = [4][4]bool{
+	lockIS: {lockIS: true, lockIX: true, lockS: true},
+	lockIX: {lockIS: true, lockIX: true},
+	lockS:  {lockIS: true, lockS: true},
+	lockX:  {},
+}
+
+// combineModes returns the mode covering both a and b for one holder. The
+// S+IX combination (classically SIX) is approximated by X.
+func combineModes(a, b lockMode) lockMode {
+	switch {
+	case a == b:
+		return a
+	case a == lockX || b == lockX:
+		return lockX
+	case a == lockIS:
+		return b
+	case b == lockIS:
+		return a
+	default: // {S, IX} in either order
+		return lockX
+	}
+}
+
+// fileLockKey identifies one DBFile in the lock table.
+type fileLockKey struct {
+	file DBFile
+}
+
+// lockTarget unwraps the constraint wrapper so that file locks taken
+// through a catalog handle and the intent locks taken by the underlying
+// file's page accesses agree on one lock key.
+func lockTarget(file DBFile) DBFile {
+	if cf, ok := file.(*constrainedFile); ok {
+		return cf.DBFile
+	}
+	return file
+}
+
+// fileLock records which transactions hold the file and in what mode; each
+// holder has one (possibly combined) mode.
+type fileLock struct {
+	holders map[TransactionID]lockMode
+}
+
+// tryFileLock attempts to acquire (or upgrade to) mode on key for tid. On
+// conflict it returns the holders blocking tid. Caller holds bp.mu.
+func (bp *BufferPool) tryFileLock(key any, tid TransactionID, mode lockMode) []TransactionID {
+	fl := bp.fileLocks[key]
+	if fl == nil {
+		fl = &fileLock{holders: make(map[TransactionID]lockMode)}
+		bp.fileLocks[key] = fl
+	}
+	want := mode
+	if cur, ok := fl.holders[tid]; ok {
+		want = combineModes(cur, mode)
+	}
+	var blockers []TransactionID
+	for h, held := range fl.holders {
+		if h != tid && !lockCompat[held][want] {
+			blockers = append(blockers, h)
+		}
+	}
+	if len(blockers) > 0 {
+		return blockers
+	}
+	fl.holders[tid] = want
+	if bp.filesHeld[tid] == nil {
+		bp.filesHeld[tid] = make(map[any]bool)
+	}
+	bp.filesHeld[tid][key] = true
+	return nil
+}
+
+// SetLockEscalationThreshold makes the pool escalate a transaction's page
+// locks on one file to a single file-level lock once it holds more than n
+// of them, capping lock-table memory during bulk operations; n = 0
+// disables escalation.
+func (bp *BufferPool) SetLockEscalationThreshold(n int) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	bp.escalateAt = n
+}
+
+// coveredByFileLock reports whether tid holds a file-level lock on key
+// strong enough to cover a page access with perm. Caller holds bp.mu.
+func (bp *BufferPool) coveredByFileLock(key any, tid TransactionID, perm RWPerm) bool {
+	fl, ok := bp.fileLocks[key]
+	if !ok {
+		return false
+	}
+	switch fl.holders[tid] {
+	case lockX:
+		return true
+	case lockS:
+		return perm == ReadPerm
+	}
+	return false
+}
+
+// trackPageLock records a newly acquired page lock against its file and
+// escalates to a file-level lock past the threshold. Caller holds bp.mu.
+func (bp *BufferPool) trackPageLock(fileKey, pageKey any, tid TransactionID) {
+	if bp.filePages[tid] == nil {
+		bp.filePages[tid] = make(map[any]map[any]bool)
+	}
+	if bp.filePages[tid][fileKey] == nil {
+		bp.filePages[tid][fileKey] = make(map[any]bool)
+	}
+	bp.filePages[tid][fileKey][pageKey] = true
+	if bp.escalateAt <= 0 || len(bp.filePages[tid][fileKey]) <= bp.escalateAt {
+		return
+	}
+	// Escalate: S when every page lock is shared, X as soon as any is a
+	// write (the file intent mode tracks this). Escalation is
+	// opportunistic — if another transaction blocks it, keep the page
+	// locks and try again on the next acquisition.
+	mode := lockS
+	if fl, ok := bp.fileLocks[fileKey]; ok && fl.holders[tid] != lockIS {
+		mode = lockX
+	}
+	if bp.tryFileLock(fileKey, tid, mode) != nil {
+		return
+	}
+	// The file lock now covers every page: drop the per-page entries from
+	// the lock table. pagesHeld keeps its entries — commit and abort walk
+	// it to find the transaction's dirty pages.
+	for pk := range bp.filePages[tid][fileKey] {
+		if pl, ok := bp.lockState[pk]; ok {
+			delete(pl.holders, tid)
+			if pl.writer == tid {
+				pl.writer = nil
+			}
+			if len(pl.holders) == 0 {
+				delete(bp.lockState, pk)
+			}
+		}
+	}
+	delete(bp.filePages[tid], fileKey)
+	logger.Debugf("tid %d: escalated page locks on %v to file level", *tid, fileKey)
+}
+
+// LockFile acquires a file-level lock on file for tid: shared for
+// ReadPerm, exclusive for WritePerm. It blocks like GetPage, with the same
+// deadlock detection and lock-wait timeout, and the lock is released with
+// the transaction's other locks at commit or abort. Pages tid then
+// accesses through GetPage are covered by the file lock but still
+// tracked, so pages dirtied under it are flushed at commit and discarded
+// at abort like any other.
+func (bp *BufferPool) LockFile(file DBFile, tid TransactionID, perm RWPerm) error {
+	mode := lockS
+	if perm == WritePerm {
+		mode = lockX
+	}
+	key := fileLockKey{file: lockTarget(file)}
+	waitStart := time.Time{}
+	bp.mu.Lock()
+	if perm == WritePerm && bp.readOnly[tid] {
+		bp.mu.Unlock()
+		return errReadOnlyWrite
+	}
+	for {
+		if derr, ok := bp.doomed[tid]; ok {
+			delete(bp.waitsFor, tid)
+			bp.mu.Unlock()
+			return derr
+		}
+		blockers := bp.tryFileLock(key, tid, mode)
+		if blockers == nil {
+			delete(bp.waitsFor, tid)
+			bp.mu.Unlock()
+			return nil
+		}
+		metrics.LockWait()
+		if bp.waitsFor[tid] == nil {
+			bp.waitsFor[tid] = make(map[TransactionID]bool)
+		}
+		for _, b := range blockers {
+			bp.waitsFor[tid][b] = true
+		}
+		if cycle := bp.findDeadlockCycle(tid); cycle != nil {
+			victim := bp.chooseVictim(cycle)
+			if victim == tid {
+				delete(bp.waitsFor, tid)
+				bp.mu.Unlock()
+				return GoDBError{DeadlockError, "deadlock detected; aborting waiter"}
+			}
+			bp.doomed[victim] = GoDBError{DeadlockError, "chosen as deadlock victim; aborting"}
+			bp.lockCond.Broadcast()
+		}
+		if waitStart.IsZero() {
+			waitStart = time.Now()
+		}
+		if err := bp.waitForLockRelease(tid, waitStart); err != nil {
+			delete(bp.waitsFor, tid)
+			bp.mu.Unlock()
+			return err
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package godb
+
+// Filter passes through the tuples of its child for which pred(left op right)
+// holds, where left is evaluated on each child tuple.
+type Filter struct {
+	op    BoolOp
+	left  Expr
+	right Expr
+	child Operator
+}
+
+// NewFilter returns a filter operator computing `left op right` over each
+// tuple of child.
+func NewFilter(right Expr, op BoolOp, left Expr, child Operator) (*Filter, error) {
+	return &Filter{op: op, left: left, right: right, child: child}, nil
+}
+
+func (f *Filter) Descriptor() *TupleDesc {
+	return f.child.Descriptor()
+}
+
+func (f *Filter) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	childIter, err := f.child.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	return func() (*Tuple, error) {
+		for {
+			t, err := childIter()
+			if err != nil || t == nil {
+				return nil, err
+			}
+			match, err := evalPred(t, f.left, t, f.right, f.op)
+			if err != nil {
+				return nil, err
+			}
+			if match {
+				return t, nil
+			}
+		}
+	}, nil
+}
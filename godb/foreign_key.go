@@ -0,0 +1,229 @@
+package godb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FKAction selects what a delete from the referenced table does to
+// referencing rows.
+type FKAction int
+
+const (
+	// FKRestrict rejects deletes of rows that are still referenced.
+	FKRestrict FKAction = iota
+	// FKCascade deletes referencing rows along with the referenced row.
+	FKCascade
+)
+
+// ForeignKeyConstraint declares that Cols of Table must match RefCols of an
+// existing row in RefTable.
+type ForeignKeyConstraint struct {
+	Table    string
+	Cols     []FieldType
+	RefTable string
+	RefCols  []FieldType
+	OnDelete FKAction
+}
+
+func (fk *ForeignKeyConstraint) name() string {
+	names := make([]string, len(fk.Cols))
+	for i, c := range fk.Cols {
+		names[i] = c.Fname
+	}
+	return fmt.Sprintf("%s_%s_fkey", fk.Table, strings.Join(names, "_"))
+}
+
+// AddForeignKey declares a foreign key from table(cols) to
+// refTable(refCols) with the supplied delete action. Both tables must
+// already be registered; enforcement happens on inserts into table and
+// deletes from refTable through the catalog's handles.
+func (c *Catalog) AddForeignKey(table string, cols []string, refTable string, refCols []string, onDelete FKAction) error {
+	if len(cols) != len(refCols) {
+		return GoDBError{IllegalOperationError, "foreign key column count mismatch"}
+	}
+	child, err := c.constrained(table)
+	if err != nil {
+		return err
+	}
+	parent, err := c.constrained(refTable)
+	if err != nil {
+		return err
+	}
+	resolvedCols, err := resolveCols(child.Descriptor(), cols)
+	if err != nil {
+		return err
+	}
+	resolvedRefCols, err := resolveCols(parent.Descriptor(), refCols)
+	if err != nil {
+		return err
+	}
+	child.foreignKeys = append(child.foreignKeys, &ForeignKeyConstraint{
+		Table:    table,
+		Cols:     resolvedCols,
+		RefTable: refTable,
+		RefCols:  resolvedRefCols,
+		OnDelete: onDelete,
+	})
+	return nil
+}
+
+// constrained returns the constraint-enforcing wrapper for table, creating
+// it if the table is not yet wrapped.
+func (c *Catalog) constrained(table string) (*constrainedFile, error) {
+	f, err := c.GetTable(table)
+	if err != nil {
+		return nil, err
+	}
+	if cf, ok := f.(*constrainedFile); ok {
+		cf.catalog = c
+		return cf, nil
+	}
+	cf := &constrainedFile{DBFile: f, table: table, catalog: c}
+	c.tables[table] = cf
+	return cf, nil
+}
+
+func resolveCols(desc *TupleDesc, cols []string) ([]FieldType, error) {
+	resolved := make([]FieldType, len(cols))
+	for i, col := range cols {
+		idx, err := findFieldInTd(FieldType{Fname: col}, desc)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = desc.Fields[idx]
+	}
+	return resolved, nil
+}
+
+// checkForeignKeys validates that every outgoing foreign key of t's table
+// references an existing parent row.
+func (f *constrainedFile) checkForeignKeys(t *Tuple, tid TransactionID) error {
+	for _, fk := range f.foreignKeys {
+		keyVals, err := extractKey(t, fk.Cols)
+		if err != nil {
+			return err
+		}
+		parent, err := f.catalog.GetTable(fk.RefTable)
+		if err != nil {
+			return err
+		}
+		found, err := containsKey(parent, fk.RefCols, keyVals, tid)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ConstraintViolationError{
+				Table:      f.table,
+				Constraint: fk.name(),
+				Msg:        fmt.Sprintf("key %v not present in %s", keyVals, fk.RefTable),
+			}
+		}
+	}
+	return nil
+}
+
+// enforceIncomingForeignKeys handles a delete from this table: every
+// foreign key in the catalog referencing it either rejects the delete or
+// cascades it, per its declared action.
+func (f *constrainedFile) enforceIncomingForeignKeys(t *Tuple, tid TransactionID) error {
+	if f.catalog == nil {
+		return nil
+	}
+	for _, childFile := range f.catalog.tables {
+		cf, ok := childFile.(*constrainedFile)
+		if !ok {
+			continue
+		}
+		for _, fk := range cf.foreignKeys {
+			if fk.RefTable != f.table {
+				continue
+			}
+			refVals, err := extractKey(t, fk.RefCols)
+			if err != nil {
+				return err
+			}
+			matches, err := collectMatching(cf, fk.Cols, refVals, tid)
+			if err != nil {
+				return err
+			}
+			if len(matches) == 0 {
+				continue
+			}
+			if fk.OnDelete == FKRestrict {
+				return ConstraintViolationError{
+					Table:      fk.Table,
+					Constraint: fk.name(),
+					Msg:        fmt.Sprintf("key %v is still referenced by %s", refVals, fk.Table),
+				}
+			}
+			for _, m := range matches {
+				if err := cf.deleteTuple(m, tid); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func extractKey(t *Tuple, cols []FieldType) ([]DBValue, error) {
+	vals := make([]DBValue, len(cols))
+	for i, c := range cols {
+		idx, err := findFieldInTd(c, &t.Desc)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = t.Fields[idx]
+	}
+	return vals, nil
+}
+
+// containsKey scans file for a row whose cols equal keyVals.
+func containsKey(file DBFile, cols []FieldType, keyVals []DBValue, tid TransactionID) (bool, error) {
+	iter, err := file.Iterator(tid)
+	if err != nil {
+		return false, err
+	}
+	for {
+		t, err := iter()
+		if err != nil {
+			return false, err
+		}
+		if t == nil {
+			return false, nil
+		}
+		vals, err := extractKey(t, cols)
+		if err != nil {
+			return false, err
+		}
+		if keysEqual(vals, keyVals) {
+			return true, nil
+		}
+	}
+}
+
+// collectMatching returns all rows of file whose cols equal keyVals.
+func collectMatching(file DBFile, cols []FieldType, keyVals []DBValue, tid TransactionID) ([]*Tuple, error) {
+	iter, err := file.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*Tuple
+	for {
+		t, err := iter()
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			return matches, nil
+		}
+		vals, err := extractKey(t, cols)
+		if err != nil {
+			return nil, err
+		}
+		if keysEqual(vals, keyVals) {
+			matches = append(matches, t)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package godb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// User-defined scalar functions. Applications register a Go function under
+// a name once at startup; a FuncExpr then calls it anywhere an expression
+// is accepted (projections, filters), e.g. normalizing phone numbers while
+// scanning. Argument counts and types are checked when the expression is
+// built, not on every tuple.
+
+// ScalarFunc is the Go signature of a user-defined scalar function.
+type ScalarFunc func(args ...DBValue) (DBValue, error)
+
+// function is one registry entry.
+type function struct {
+	fn       ScalarFunc
+	argTypes []DBType
+	retType  DBType
+}
+
+var (
+	funcMu    sync.RWMutex
+	functions = make(map[string]*function)
+)
+
+// RegisterFunction makes fn callable as name from FuncExpr. argTypes
+// declare the expected argument types in order and retType the result
+// type. Registering an existing name replaces it; names are
+// case-insensitive.
+func RegisterFunction(name string, fn ScalarFunc, argTypes []DBType, retType DBType) {
+	funcMu.Lock()
+	defer funcMu.Unlock()
+	functions[strings.ToLower(name)] = &function{fn: fn, argTypes: argTypes, retType: retType}
+}
+
+// FuncExpr calls a registered scalar function on the values of its
+// argument expressions.
+type FuncExpr struct {
+	name string
+	fn   *function
+	args []Expr
+}
+
+// NewFuncExpr returns an expression calling the registered function name
+// on args, checking arity and argument types against the registration.
+func NewFuncExpr(name string, args ...Expr) (*FuncExpr, error) {
+	funcMu.RLock()
+	fn, ok := functions[strings.ToLower(name)]
+	funcMu.RUnlock()
+	if !ok {
+		return nil, GoDBError{ParseError, fmt.Sprintf("no function named %s", name)}
+	}
+	if len(args) != len(fn.argTypes) {
+		return nil, GoDBError{TypeMismatchError, fmt.Sprintf("function %s takes %d arguments, got %d", name, len(fn.argTypes), len(args))}
+	}
+	for i, a := range args {
+		if a.GetExprType().Ftype != fn.argTypes[i] {
+			return nil, GoDBError{TypeMismatchError, fmt.Sprintf("function %s argument %d has wrong type", name, i+1)}
+		}
+	}
+	return &FuncExpr{name: strings.ToLower(name), fn: fn, args: args}, nil
+}
+
+func (f *FuncExpr) EvalExpr(t *Tuple) (DBValue, error) {
+	vals := make([]DBValue, len(f.args))
+	for i, a := range f.args {
+		v, err := a.EvalExpr(t)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = v
+	}
+	v, err := f.fn.fn(vals...)
+	if err != nil {
+		return nil, GoDBError{IllegalOperationError, fmt.Sprintf("function %s: %v", f.name, err)}
+	}
+	return v, nil
+}
+
+func (f *FuncExpr) GetExprType() FieldType {
+	return FieldType{Fname: f.name, Ftype: f.fn.retType}
+}
@@ -0,0 +1,35 @@
+package godb
+
+// GenerateSeries emits the integers from start through stop inclusive in
+// increments of step, as single-field tuples named "value". It is a
+// table-generating function in the spirit of PostgreSQL's generate_series,
+// handy for gap-filling joins, synthetic benchmark data, and exercising
+// operators without any backing storage. A negative step counts down; the
+// series is empty when step moves away from stop.
+type GenerateSeries struct {
+	start, stop, step int64
+}
+
+// NewGenerateSeries returns a series operator; step must be nonzero.
+func NewGenerateSeries(start, stop, step int64) (*GenerateSeries, error) {
+	if step == 0 {
+		return nil, GoDBError{IllegalOperationError, "generate_series step must be nonzero"}
+	}
+	return &GenerateSeries{start: start, stop: stop, step: step}, nil
+}
+
+func (g *GenerateSeries) Descriptor() *TupleDesc {
+	return &TupleDesc{Fields: []FieldType{{Fname: "value", Ftype: IntType}}}
+}
+
+func (g *GenerateSeries) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	next := g.start
+	return func() (*Tuple, error) {
+		if g.step > 0 && next > g.stop || g.step < 0 && next < g.stop {
+			return nil, nil
+		}
+		t := &Tuple{Desc: *g.Descriptor(), Fields: []DBValue{IntField{next}}}
+		next += g.step
+		return t, nil
+	}, nil
+}
@@ -0,0 +1,240 @@
+package godb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// HashIndex maps the values of one column to the record ids of the rows
+// holding them, for equality lookups that avoid a full scan. The index is
+// maintained by the catalog's insert and delete paths for the base table —
+// buffered per transaction and applied at commit, so aborted changes never
+// reach it — and persisted as a sidecar file, written through on every
+// applied mutation.
+type HashIndex struct {
+	table string
+	col   FieldType
+	path  string
+	base  *HeapFile
+
+	mu      sync.Mutex
+	buckets map[string][]RecordID // encoded key -> rids
+}
+
+// CreateHashIndex builds a hash index on col of table, backfilling it from
+// the table's current contents. Subsequent inserts and deletes through the
+// catalog keep it up to date.
+func (c *Catalog) CreateHashIndex(table string, col string) (*HashIndex, error) {
+	cf, err := c.constrained(table)
+	if err != nil {
+		return nil, err
+	}
+	base, err := c.heapFile(table)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := resolveCols(cf.Descriptor(), []string{col})
+	if err != nil {
+		return nil, err
+	}
+	idx := &HashIndex{
+		table:   table,
+		col:     resolved[0],
+		path:    c.tableFilePath(table) + "." + col + ".hashidx",
+		base:    base,
+		buckets: make(map[string][]RecordID),
+	}
+	if err := idx.load(); err != nil {
+		return nil, err
+	}
+	if len(idx.buckets) == 0 {
+		if err := idx.rebuild(c.bufPool, cf); err != nil {
+			return nil, err
+		}
+	}
+	c.indexTxn()
+	cf.indexes = append(cf.indexes, idx)
+	return idx, nil
+}
+
+// hashIndexFor returns an index on colName of file, if one is registered.
+func hashIndexFor(file DBFile, colName string) *HashIndex {
+	cf, ok := file.(*constrainedFile)
+	if !ok {
+		return nil
+	}
+	for _, idx := range cf.indexes {
+		if hi, ok := idx.(*HashIndex); ok && hi.col.Fname == colName {
+			return hi
+		}
+	}
+	return nil
+}
+
+// encodeKey renders a field value as a bucket key, tagged by type so that
+// int 1 and string "1" stay distinct. Strings are reduced to their
+// collation key, so lookups find every value equal under the active
+// collation; like the composite index, a hash index built under one
+// collation must be rebuilt after switching to another.
+func encodeKey(v DBValue) string {
+	switch x := v.(type) {
+	case IntField:
+		return "i:" + strconv.FormatInt(x.Value, 10)
+	case DecimalField:
+		return "d:" + x.String()
+	case StringField:
+		return "s:" + collate().Key(x.Value)
+	}
+	return fmt.Sprintf("?:%v", v)
+}
+
+// insert records rid under t's key value.
+func (idx *HashIndex) insert(t *Tuple) error {
+	i, err := findFieldInTd(idx.col, &t.Desc)
+	if err != nil {
+		return err
+	}
+	rid, ok := t.Rid.(RecordID)
+	if !ok {
+		return GoDBError{IllegalOperationError, "indexed insert produced no record id"}
+	}
+	key := encodeKey(t.Fields[i])
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.buckets[key] = append(idx.buckets[key], rid)
+	return idx.save()
+}
+
+// remove drops rid from t's key bucket.
+func (idx *HashIndex) remove(t *Tuple) error {
+	i, err := findFieldInTd(idx.col, &t.Desc)
+	if err != nil {
+		return err
+	}
+	rid, _ := t.Rid.(RecordID)
+	key := encodeKey(t.Fields[i])
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	rids := idx.buckets[key]
+	for j, r := range rids {
+		if r == rid {
+			idx.buckets[key] = append(rids[:j], rids[j+1:]...)
+			break
+		}
+	}
+	if len(idx.buckets[key]) == 0 {
+		delete(idx.buckets, key)
+	}
+	return idx.save()
+}
+
+// Lookup returns the record ids stored under key.
+func (idx *HashIndex) Lookup(key DBValue) []RecordID {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	rids := idx.buckets[encodeKey(key)]
+	out := make([]RecordID, len(rids))
+	copy(out, rids)
+	return out
+}
+
+// rebuild scans the base table and repopulates the index.
+func (idx *HashIndex) rebuild(bp *BufferPool, src Operator) error {
+	tid := NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		return err
+	}
+	defer bp.CommitTransaction(tid)
+	// Scan through the catalog wrapper rather than the base file, so a
+	// virtual computed column's values are present to key on.
+	iter, err := src.Iterator(tid)
+	if err != nil {
+		return err
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.buckets = make(map[string][]RecordID)
+	for {
+		t, err := iter()
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			break
+		}
+		i, err := findFieldInTd(idx.col, &t.Desc)
+		if err != nil {
+			return err
+		}
+		key := encodeKey(t.Fields[i])
+		idx.buckets[key] = append(idx.buckets[key], t.Rid.(RecordID))
+	}
+	return idx.save()
+}
+
+// save persists the buckets; caller holds idx.mu.
+func (idx *HashIndex) save() error {
+	buf, err := json.Marshal(idx.buckets)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(idx.path, buf, 0644); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
+
+func (idx *HashIndex) load() error {
+	buf, err := os.ReadFile(idx.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if err := json.Unmarshal(buf, &idx.buckets); err != nil {
+		return GoDBError{MalformedDataError, fmt.Sprintf("bad index file %s: %v", idx.path, err)}
+	}
+	return nil
+}
+
+// IndexScan produces the rows of the base table whose indexed column equals
+// key, fetching pages directly by record id.
+type IndexScan struct {
+	index *HashIndex
+	key   DBValue
+}
+
+// NewIndexScan returns an index scan for key over idx's base table.
+func NewIndexScan(idx *HashIndex, key DBValue) *IndexScan {
+	return &IndexScan{index: idx, key: key}
+}
+
+func (s *IndexScan) Descriptor() *TupleDesc {
+	return s.index.base.Descriptor()
+}
+
+func (s *IndexScan) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	rids := s.index.Lookup(s.key)
+	i := 0
+	return func() (*Tuple, error) {
+		for i < len(rids) {
+			rid := rids[i]
+			i++
+			t, err := s.index.base.getTupleByRid(rid, tid)
+			if err != nil {
+				return nil, err
+			}
+			if t != nil {
+				metrics.TuplesScanned("index_scan", 1)
+				return t, nil
+			}
+		}
+		return nil, nil
+	}, nil
+}
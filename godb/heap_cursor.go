@@ -0,0 +1,72 @@
+package godb
+
+// Resumable range scans over heap files. A paginated reader scans a batch
+// of pages, keeps the returned cursor (a small value that serializes
+// cleanly, e.g. into an HTTP response), and passes it back later to
+// continue where it stopped instead of restarting from page 0. Positions
+// are (page, slot) pairs, which the slotted page layout keeps stable
+// across flushes; tuples inserted behind the cursor are not revisited.
+
+// ScanCursor is a position in a heap file scan: the next (page, slot) to
+// read. The zero value means the start of the file; Done is set once the
+// scan has seen every page that existed when it finished.
+type ScanCursor struct {
+	PageNo int  `json:"p"`
+	SlotNo int  `json:"s"`
+	Done   bool `json:"done"`
+}
+
+// ResumableIterator returns an iterator starting at cur and stopping after
+// reading maxPages pages (0 means no limit), plus a cursor that tracks the
+// scan position as tuples are consumed. When the iterator is exhausted the
+// cursor either has Done set — the whole file was read — or points at the
+// next unread page and can seed another call.
+func (f *HeapFile) ResumableIterator(cur ScanCursor, maxPages int, tid TransactionID) (func() (*Tuple, error), *ScanCursor, error) {
+	pos := &ScanCursor{PageNo: cur.PageNo, SlotNo: cur.SlotNo}
+	if cur.Done {
+		pos.Done = true
+		return func() (*Tuple, error) { return nil, nil }, pos, nil
+	}
+	pagesRead := 0
+	var hp *heapPage
+	var pageVersion int32
+	return func() (*Tuple, error) {
+		for {
+			if hp == nil {
+				if pos.PageNo >= f.NumPages() {
+					pos.Done = true
+					return nil, nil
+				}
+				if maxPages > 0 && pagesRead >= maxPages {
+					return nil, nil
+				}
+				pg, err := f.bufPool.GetPage(f, pos.PageNo, tid, ReadPerm)
+				if err != nil {
+					return nil, err
+				}
+				hp = pg.(*heapPage)
+				pageVersion = hp.version
+				pagesRead++
+			}
+			hp.latch.RLock()
+			for pos.SlotNo < len(hp.tuples) {
+				t := hp.tuples[pos.SlotNo]
+				pos.SlotNo++
+				if t == nil {
+					continue
+				}
+				hp.latch.RUnlock()
+				t, err := f.upgradeTuple(t, pageVersion)
+				if err != nil {
+					return nil, err
+				}
+				metrics.TuplesScanned("heap_scan", 1)
+				return t, nil
+			}
+			hp.latch.RUnlock()
+			hp = nil
+			pos.PageNo++
+			pos.SlotNo = 0
+		}
+	}, pos, nil
+}
@@ -0,0 +1,407 @@
+package godb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// HeapFile stores the tuples of one table as an unordered collection of
+// pages in a single file on disk. All page access during query execution
+// goes through the buffer pool.
+type HeapFile struct {
+	fileName string
+	desc     *TupleDesc
+	bufPool  *BufferPool
+	// versions is the schema history; versions[len-1] == desc. Pages record
+	// the version they were written with and are upgraded lazily on read.
+	versions []*TupleDesc
+	// defaults holds backfill values for columns added by AddColumn.
+	defaults map[string]DBValue
+	// blooms holds per-page Bloom filters for columns enabled via
+	// EnableBloomFilter, keyed by column name.
+	blooms map[string]*tableBloom
+	// snapshots holds live copy-on-write snapshots; snapMu guards it.
+	snapMu    sync.Mutex
+	snapshots map[string]*heapSnapshot
+	// codec, when set by EnableCompression, compresses page images on flush.
+	codec PageCodec
+	// cipher, when set by EnableEncryption, seals page images on flush.
+	cipher *pageCipher
+	// doubleWrite, when set by EnableDoubleWrite, stages page images in a
+	// scratch file before the in-place write; dwMu serializes staged
+	// flushes so concurrent flushers do not share the scratch file.
+	doubleWrite bool
+	dwMu        sync.Mutex
+	// freePages hints pages that may have free slots: deleteTuple records
+	// them and insertTuple tries them before falling back to the linear
+	// page scan. Hints are advisory — stale ones are dropped when an
+	// insert finds the page full.
+	hintMu    sync.Mutex
+	freePages map[int]bool
+	// allocMu serializes file extension: the page number of a new page is
+	// computed and the page written under it, so concurrent inserters
+	// cannot allocate the same page number twice.
+	allocMu sync.Mutex
+}
+
+// heapFilePageKey identifies one page of one heap file in the buffer pool.
+type heapFilePageKey struct {
+	fileName string
+	pageNo   int
+}
+
+// NewHeapFile opens (creating if necessary) a heap file backed by fromFile.
+func NewHeapFile(fromFile string, desc *TupleDesc, bp *BufferPool) (*HeapFile, error) {
+	f, err := os.OpenFile(fromFile, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	f.Close()
+	hf := &HeapFile{fileName: fromFile, desc: desc, bufPool: bp}
+	// A staged double-write image left by a crash is replayed before any
+	// page of the file is read.
+	if err := hf.recoverDoubleWrite(); err != nil {
+		return nil, err
+	}
+	if err := hf.loadSchema(); err != nil {
+		return nil, err
+	}
+	return hf, nil
+}
+
+func (f *HeapFile) Descriptor() *TupleDesc {
+	return f.desc
+}
+
+// NumPages returns the number of pages in the file on disk.
+func (f *HeapFile) NumPages() int {
+	st, err := os.Stat(f.fileName)
+	if err != nil {
+		return 0
+	}
+	return int(st.Size()) / PageSize
+}
+
+func (f *HeapFile) pageKey(pgNo int) any {
+	return heapFilePageKey{fileName: f.fileName, pageNo: pgNo}
+}
+
+// readPage reads page pageNo from disk, bypassing the buffer pool. Page
+// numbers outside the file and short reads (a file truncated or torn
+// mid-page) are reported as typed errors rather than surfacing raw I/O
+// failures or building a page from partial data.
+func (f *HeapFile) readPage(pageNo int) (Page, error) {
+	if pageNo < 0 || pageNo >= f.NumPages() {
+		return nil, GoDBError{IllegalOperationError, fmt.Sprintf("page %d out of range for %s (%d pages)", pageNo, f.fileName, f.NumPages())}
+	}
+	file, err := os.Open(f.fileName)
+	if err != nil {
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	defer file.Close()
+	buf := make([]byte, PageSize)
+	if _, err := io.ReadFull(io.NewSectionReader(file, int64(pageNo)*int64(PageSize), int64(PageSize)), buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, GoDBError{MalformedDataError, fmt.Sprintf("short read of page %d of %s: file ends mid-page", pageNo, f.fileName)}
+		}
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	if buf, err = f.decodePage(buf); err != nil {
+		return nil, err
+	}
+	// The schema version lives at a fixed header offset; it selects the
+	// descriptor the page's tuples were encoded with.
+	version := int32(binary.LittleEndian.Uint32(buf[8:12]))
+	desc, err := f.descForVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	p := newHeapPage(desc, pageNo, f, version)
+	if err := p.initFromBuffer(bytes.NewBuffer(buf)); err != nil {
+		return nil, err
+	}
+	logger.Debugf("heap %s: read page %d", f.fileName, pageNo)
+	return p, nil
+}
+
+// flushPage writes the page back to disk and marks it clean.
+func (f *HeapFile) flushPage(page Page) error {
+	p := page.(*heapPage)
+	b, err := p.toBuffer()
+	if err != nil {
+		return err
+	}
+	if err := f.preserveForSnapshots(p.pageNo); err != nil {
+		return err
+	}
+	image, dirEntry, err := encodePageImage(f.codec, b.Bytes())
+	if err != nil {
+		return err
+	}
+	if f.cipher != nil {
+		if image, err = f.cipher.seal(image); err != nil {
+			return err
+		}
+	}
+	if f.doubleWrite {
+		f.dwMu.Lock()
+		defer f.dwMu.Unlock()
+		if err := f.stageDoubleWrite(p.pageNo, image); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(f.fileName, os.O_RDWR, 0644)
+	if err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	defer file.Close()
+	if err := writePageAt(file, f.fileName, int64(p.pageNo)*int64(PageSize), image); err != nil {
+		return err
+	}
+	// A compressed image is shorter than its slot; keep the file extended
+	// to whole pages so NumPages and reads of the last page stay correct.
+	if end := int64(p.pageNo+1) * int64(PageSize); int64(len(image)) < int64(PageSize) {
+		if st, err := file.Stat(); err == nil && st.Size() < end {
+			if err := file.Truncate(end); err != nil {
+				return GoDBError{OsError, err.Error()}
+			}
+		}
+	}
+	if f.codec != nil {
+		savePageDirEntry(f.fileName, p.pageNo, dirEntry)
+	}
+	if f.bufPool.syncEveryFlush() {
+		if err := file.Sync(); err != nil {
+			return GoDBError{OsError, err.Error()}
+		}
+	}
+	p.setDirty(nil, false)
+	f.refreshBloomFilters(p)
+	metrics.PageFlushed()
+	logger.Debugf("heap %s: flushed page %d", f.fileName, p.pageNo)
+	return nil
+}
+
+// insertTuple adds t to the first page with a free slot — trying pages
+// hinted free by earlier deletes first — appending a new page if every
+// existing page is full.
+func (f *HeapFile) insertTuple(t *Tuple, tid TransactionID) error {
+	n := f.NumPages()
+	for _, pgNo := range f.takeFreeHints() {
+		if pgNo >= n {
+			f.dropFreeHint(pgNo)
+			continue
+		}
+		ok, err := f.tryInsertOnPage(t, pgNo, tid)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		f.dropFreeHint(pgNo)
+	}
+	for pgNo := 0; pgNo < n; pgNo++ {
+		ok, err := f.tryInsertOnPage(t, pgNo, tid)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	// Every page is full: extend the file with a fresh page, then insert
+	// through the buffer pool so the new page is cached and locked. The
+	// page number is recomputed under the allocation latch — the count
+	// gathered during the scan may be stale if another inserter extended
+	// the file meanwhile, and writing the "new" page would wipe theirs.
+	for {
+		f.allocMu.Lock()
+		pgNo := f.NumPages()
+		np := newHeapPage(f.desc, pgNo, f, f.currentVersion())
+		if err := f.flushPage(np); err != nil {
+			f.allocMu.Unlock()
+			return err
+		}
+		f.allocMu.Unlock()
+		pg, err := f.bufPool.GetPage(f, pgNo, tid, WritePerm)
+		if err != nil {
+			return err
+		}
+		hp := pg.(*heapPage)
+		if _, err := hp.insertTuple(t); err != nil {
+			// Another inserter locked and filled the fresh page before we
+			// did; allocate again.
+			continue
+		}
+		hp.setDirty(tid, true)
+		if hp.used() < hp.getNumSlots() {
+			f.addFreeHint(pgNo)
+		}
+		return nil
+	}
+}
+
+// tryInsertOnPage inserts t into page pgNo if it has a free slot and is
+// encoded with the current schema; older pages are frozen until
+// rewritten. It reports whether the insert happened.
+func (f *HeapFile) tryInsertOnPage(t *Tuple, pgNo int, tid TransactionID) (bool, error) {
+	pg, err := f.bufPool.GetPage(f, pgNo, tid, WritePerm)
+	if err != nil {
+		return false, err
+	}
+	hp := pg.(*heapPage)
+	if hp.version != f.currentVersion() || hp.used() >= hp.getNumSlots() {
+		return false, nil
+	}
+	if _, err := hp.insertTuple(t); err != nil {
+		return false, err
+	}
+	hp.setDirty(tid, true)
+	if hp.used() >= hp.getNumSlots() {
+		f.dropFreeHint(pgNo)
+	}
+	return true, nil
+}
+
+// deleteTuple removes the tuple identified by t.Rid.
+func (f *HeapFile) deleteTuple(t *Tuple, tid TransactionID) error {
+	rid, ok := t.Rid.(RecordID)
+	if !ok {
+		return GoDBError{TupleNotFoundError, "tuple has no record id"}
+	}
+	pg, err := f.bufPool.GetPage(f, rid.PageNo, tid, WritePerm)
+	if err != nil {
+		return err
+	}
+	hp := pg.(*heapPage)
+	if err := hp.deleteTuple(rid); err != nil {
+		return err
+	}
+	hp.setDirty(tid, true)
+	f.addFreeHint(rid.PageNo)
+	return nil
+}
+
+// addFreeHint records that page pgNo may have a free slot.
+func (f *HeapFile) addFreeHint(pgNo int) {
+	f.hintMu.Lock()
+	defer f.hintMu.Unlock()
+	if f.freePages == nil {
+		f.freePages = make(map[int]bool)
+	}
+	f.freePages[pgNo] = true
+}
+
+// dropFreeHint forgets the hint for page pgNo.
+func (f *HeapFile) dropFreeHint(pgNo int) {
+	f.hintMu.Lock()
+	defer f.hintMu.Unlock()
+	delete(f.freePages, pgNo)
+}
+
+// takeFreeHints returns the hinted pages in ascending order.
+func (f *HeapFile) takeFreeHints() []int {
+	f.hintMu.Lock()
+	defer f.hintMu.Unlock()
+	pages := make([]int, 0, len(f.freePages))
+	for pgNo := range f.freePages {
+		pages = append(pages, pgNo)
+	}
+	sort.Ints(pages)
+	return pages
+}
+
+// resetFreeHints replaces the hints after a vacuum, when only the last
+// page can have free slots.
+func (f *HeapFile) resetFreeHints(numPages, numTuples int) {
+	f.hintMu.Lock()
+	defer f.hintMu.Unlock()
+	f.freePages = make(map[int]bool)
+	if numPages == 0 {
+		return
+	}
+	if slots := newHeapPage(f.desc, 0, f, f.currentVersion()).getNumSlots(); numTuples < numPages*slots {
+		f.freePages[numPages-1] = true
+	}
+}
+
+// getTupleByRid fetches the tuple at rid, or nil if it no longer exists.
+func (f *HeapFile) getTupleByRid(rid RecordID, tid TransactionID) (*Tuple, error) {
+	if rid.PageNo < 0 || rid.PageNo >= f.NumPages() {
+		return nil, nil
+	}
+	pg, err := f.bufPool.GetPage(f, rid.PageNo, tid, ReadPerm)
+	if err != nil {
+		return nil, err
+	}
+	hp := pg.(*heapPage)
+	hp.latch.RLock()
+	var t *Tuple
+	if rid.SlotNo >= 0 && rid.SlotNo < len(hp.tuples) {
+		t = hp.tuples[rid.SlotNo]
+	}
+	hp.latch.RUnlock()
+	if t == nil {
+		return nil, nil
+	}
+	return f.upgradeTuple(t, hp.version)
+}
+
+// Iterator returns a function iterating over every tuple in the file, page
+// by page, fetching pages through the buffer pool with read permission.
+func (f *HeapFile) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	pgNo := 0
+	var pageIter func() (*Tuple, error)
+	var pageVersion int32
+	logger.Tracef("heap %s: scan start (tid %d)", f.fileName, *tid)
+	return func() (*Tuple, error) {
+		for {
+			if pageIter == nil {
+				if pgNo >= f.NumPages() {
+					logger.Tracef("heap %s: scan finish (tid %d)", f.fileName, *tid)
+					return nil, nil
+				}
+				pg, err := f.bufPool.GetPage(f, pgNo, tid, ReadPerm)
+				if err != nil {
+					return nil, err
+				}
+				hp := pg.(*heapPage)
+				pageIter = hp.tupleIter()
+				pageVersion = hp.version
+				pgNo++
+			}
+			t, err := pageIter()
+			if err != nil {
+				return nil, err
+			}
+			if t != nil {
+				if t, err = f.upgradeTuple(t, pageVersion); err != nil {
+					return nil, err
+				}
+				metrics.TuplesScanned("heap_scan", 1)
+				return t, nil
+			}
+			pageIter = nil
+		}
+	}, nil
+}
+
+// LoadFromCSV bulk loads the file from a CSV-formatted reader, which may
+// be gzip-compressed. If hasHeader is true the first line is skipped. sep
+// is the field separator; skipLastField drops a trailing empty field
+// produced by lines ending in the separator. It is LoadFromCSVDialect
+// with a fixed strict dialect.
+func (f *HeapFile) LoadFromCSV(file io.Reader, hasHeader bool, sep string, skipLastField bool) error {
+	d := CSVDialect{HasHeader: hasHeader, SkipLastField: skipLastField}
+	if sep != "" {
+		d.Comma = []rune(sep)[0]
+	}
+	_, err := f.LoadFromCSVDialect(file, d)
+	return err
+}
@@ -0,0 +1,213 @@
+package godb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// heapPage is one page of a HeapFile: a 12-byte header (number of slots,
+// number of used slots, schema version), a slot-occupancy bitmap, and one
+// fixed-width slot per tuple in the layout of that schema version. Tuples
+// stay in their slot across writes — the bitmap records which slots are
+// occupied — so record ids remain valid after a flush/evict/reload cycle,
+// which indexes and long-running cursors depend on.
+type heapPage struct {
+	desc    *TupleDesc
+	pageNo  int
+	file    *HeapFile
+	version int32    // schema version the page's tuples are encoded with
+	tuples  []*Tuple // fixed length numSlots; nil entries are free slots
+	numUsed int
+	dirty   dirtyState
+	// latch protects the slot array during mutation and serialization. It
+	// is a short-duration physical latch, not a transaction lock: two
+	// operators of the same transaction hold the same logical page lock,
+	// so a parallel scan and insert need the latch to stay memory-safe.
+	latch sync.RWMutex
+}
+
+const heapPageHeaderSize = 12
+
+// newHeapPage returns an empty page pageNo of file f, encoded with the
+// supplied schema version and its descriptor.
+func newHeapPage(desc *TupleDesc, pageNo int, f *HeapFile, version int32) *heapPage {
+	avail := PageSize - heapPageHeaderSize
+	if f != nil && f.cipher != nil {
+		// Sealed pages need slot space for the encryption header and tag.
+		avail -= encryptionOverhead
+	}
+	// Each slot costs its tuple bytes plus one occupancy bit.
+	numSlots := avail / desc.bytesPerTuple()
+	for numSlots > 0 && (numSlots+7)/8+numSlots*desc.bytesPerTuple() > avail {
+		numSlots--
+	}
+	return &heapPage{
+		desc:    desc,
+		pageNo:  pageNo,
+		file:    f,
+		version: version,
+		tuples:  make([]*Tuple, numSlots),
+	}
+}
+
+func (p *heapPage) getNumSlots() int {
+	return len(p.tuples)
+}
+
+// used returns the number of occupied slots, read under the latch.
+func (p *heapPage) used() int {
+	p.latch.RLock()
+	defer p.latch.RUnlock()
+	return p.numUsed
+}
+
+// insertTuple stores t in the first free slot, assigning its record id.
+func (p *heapPage) insertTuple(t *Tuple) (any, error) {
+	p.latch.Lock()
+	defer p.latch.Unlock()
+	for i, slot := range p.tuples {
+		if slot == nil {
+			stored := &Tuple{Desc: t.Desc, Fields: t.Fields}
+			stored.Rid = RecordID{PageNo: p.pageNo, SlotNo: i}
+			p.tuples[i] = stored
+			p.numUsed++
+			t.Rid = stored.Rid
+			return stored.Rid, nil
+		}
+	}
+	return nil, GoDBError{PageFullError, fmt.Sprintf("page %d is full", p.pageNo)}
+}
+
+// deleteTuple removes the tuple with the supplied record id.
+func (p *heapPage) deleteTuple(rid any) error {
+	p.latch.Lock()
+	defer p.latch.Unlock()
+	for i, slot := range p.tuples {
+		if slot != nil && slot.Rid == rid {
+			p.tuples[i] = nil
+			p.numUsed--
+			return nil
+		}
+	}
+	return GoDBError{TupleNotFoundError, fmt.Sprintf("rid %v not found on page %d", rid, p.pageNo)}
+}
+
+func (p *heapPage) isDirty() bool {
+	return p.dirty.isDirty()
+}
+
+func (p *heapPage) setDirty(tid TransactionID, dirty bool) {
+	p.dirty.set(tid, dirty)
+}
+
+func (p *heapPage) dirtier() TransactionID {
+	return p.dirty.dirtier()
+}
+
+func (p *heapPage) getFile() DBFile {
+	return p.file
+}
+
+// bitmapLen returns the size in bytes of the slot-occupancy bitmap.
+func (p *heapPage) bitmapLen() int {
+	return (len(p.tuples) + 7) / 8
+}
+
+// toBuffer serializes the page: header, occupancy bitmap, then every slot
+// at its fixed offset (free slots as zero bytes), so slot numbers — and
+// with them record ids — survive the round trip.
+func (p *heapPage) toBuffer() (*bytes.Buffer, error) {
+	p.latch.RLock()
+	defer p.latch.RUnlock()
+	b := new(bytes.Buffer)
+	if err := binary.Write(b, binary.LittleEndian, int32(len(p.tuples))); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(b, binary.LittleEndian, int32(p.numUsed)); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(b, binary.LittleEndian, p.version); err != nil {
+		return nil, err
+	}
+	bitmap := make([]byte, p.bitmapLen())
+	for i, t := range p.tuples {
+		if t != nil {
+			bitmap[i/8] |= 1 << (i % 8)
+		}
+	}
+	b.Write(bitmap)
+	empty := make([]byte, p.desc.bytesPerTuple())
+	for _, t := range p.tuples {
+		if t == nil {
+			b.Write(empty)
+			continue
+		}
+		if err := t.writeTo(b); err != nil {
+			return nil, err
+		}
+	}
+	if b.Len() > PageSize {
+		return nil, GoDBError{MalformedDataError, "serialized page exceeds PageSize"}
+	}
+	b.Write(make([]byte, PageSize-b.Len()))
+	return b, nil
+}
+
+// initFromBuffer populates the page from a PageSize-byte buffer produced by
+// toBuffer, restoring every tuple to its original slot.
+func (p *heapPage) initFromBuffer(b *bytes.Buffer) error {
+	p.latch.Lock()
+	defer p.latch.Unlock()
+	var numSlots, numUsed, version int32
+	if err := binary.Read(b, binary.LittleEndian, &numSlots); err != nil {
+		return err
+	}
+	if err := binary.Read(b, binary.LittleEndian, &numUsed); err != nil {
+		return err
+	}
+	if err := binary.Read(b, binary.LittleEndian, &version); err != nil {
+		return err
+	}
+	p.version = version
+	if int(numSlots) != len(p.tuples) {
+		return GoDBError{MalformedDataError, "page slot count does not match descriptor"}
+	}
+	bitmap := make([]byte, p.bitmapLen())
+	if _, err := b.Read(bitmap); err != nil {
+		return err
+	}
+	tupleLen := p.desc.bytesPerTuple()
+	for i := 0; i < int(numSlots); i++ {
+		if bitmap[i/8]&(1<<(i%8)) == 0 {
+			b.Next(tupleLen)
+			continue
+		}
+		t, err := readTupleFrom(b, p.desc)
+		if err != nil {
+			return err
+		}
+		t.Rid = RecordID{PageNo: p.pageNo, SlotNo: i}
+		p.tuples[i] = t
+	}
+	p.numUsed = int(numUsed)
+	return nil
+}
+
+// tupleIter returns an iterator over the used slots of the page.
+func (p *heapPage) tupleIter() func() (*Tuple, error) {
+	i := 0
+	return func() (*Tuple, error) {
+		p.latch.RLock()
+		defer p.latch.RUnlock()
+		for i < len(p.tuples) {
+			t := p.tuples[i]
+			i++
+			if t != nil {
+				return t, nil
+			}
+		}
+		return nil, nil
+	}
+}
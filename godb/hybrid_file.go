@@ -0,0 +1,244 @@
+package godb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HybridFile pairs a row-store delta with a column-store main: inserts
+// land in a small heap file, where a single row costs one page write
+// instead of one write per column, and MergeDelta (or the background
+// merger) moves accumulated rows into the column file in bulk, where
+// scans are cheap. Iterators union the merged rows with the delta, so
+// readers always see every row. Deletes only reach rows still in the
+// delta; once merged, rows inherit the column store's append-only
+// contract.
+type HybridFile struct {
+	baseName string
+	desc     *TupleDesc
+	bufPool  *BufferPool
+	delta    *HeapFile
+	main     *ColumnFile
+	// mergeMu serializes merges; inserts and scans are unaffected.
+	mergeMu sync.Mutex
+}
+
+// hybridRid tags a row's record id with the store it lives in, so
+// deleteTuple can route by origin.
+type hybridRid struct {
+	delta bool
+	rid   any
+}
+
+// NewHybridFile opens (creating if necessary) a hybrid file rooted at
+// baseName: the delta heap at <base>.delta.dat and the column files at
+// <base>.<field>.col.
+func NewHybridFile(baseName string, desc *TupleDesc, bp *BufferPool) (*HybridFile, error) {
+	delta, err := NewHeapFile(baseName+".delta.dat", desc.copy(), bp)
+	if err != nil {
+		return nil, err
+	}
+	main, err := NewColumnFile(baseName, desc.copy(), bp)
+	if err != nil {
+		return nil, err
+	}
+	return &HybridFile{baseName: baseName, desc: desc, bufPool: bp, delta: delta, main: main}, nil
+}
+
+// Delta exposes the row-store side, e.g. for inspection.
+func (f *HybridFile) Delta() *HeapFile {
+	return f.delta
+}
+
+// Main exposes the column-store side, e.g. for batch readers.
+func (f *HybridFile) Main() *ColumnFile {
+	return f.main
+}
+
+func (f *HybridFile) Descriptor() *TupleDesc {
+	return f.desc
+}
+
+// insertTuple lands t in the delta heap.
+func (f *HybridFile) insertTuple(t *Tuple, tid TransactionID) error {
+	if err := f.delta.insertTuple(t, tid); err != nil {
+		return err
+	}
+	t.Rid = hybridRid{delta: true, rid: t.Rid}
+	return nil
+}
+
+// deleteTuple removes t if it still lives in the delta; merged rows are
+// immutable.
+func (f *HybridFile) deleteTuple(t *Tuple, tid TransactionID) error {
+	hr, ok := t.Rid.(hybridRid)
+	if !ok {
+		return GoDBError{TupleNotFoundError, fmt.Sprintf("tuple has record id %T, not from a hybrid file", t.Rid)}
+	}
+	if !hr.delta {
+		return GoDBError{IllegalOperationError, "row has been merged into the column store and is immutable"}
+	}
+	inner := &Tuple{Desc: t.Desc, Fields: t.Fields, Rid: hr.rid}
+	return f.delta.deleteTuple(inner, tid)
+}
+
+// Page access goes to the underlying stores through their own DBFile
+// identities; the hybrid file itself owns no pages.
+func (f *HybridFile) readPage(pageNo int) (Page, error) {
+	return nil, GoDBError{IllegalOperationError, "hybrid files have no pages of their own"}
+}
+
+func (f *HybridFile) flushPage(page Page) error {
+	return GoDBError{IllegalOperationError, "hybrid files have no pages of their own"}
+}
+
+func (f *HybridFile) pageKey(pgNo int) any {
+	return fmt.Sprintf("hybrid:%s:%d", f.baseName, pgNo)
+}
+
+// NumPages reports both stores' pages, for bookkeeping only.
+func (f *HybridFile) NumPages() int {
+	return f.main.NumPages() + f.delta.NumPages()
+}
+
+func (f *HybridFile) Sync() error {
+	if err := f.main.Sync(); err != nil {
+		return err
+	}
+	return f.delta.Sync()
+}
+
+// Iterator yields the merged rows first, then the delta.
+func (f *HybridFile) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	mainIter, err := f.main.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	var deltaIter func() (*Tuple, error)
+	return func() (*Tuple, error) {
+		if mainIter != nil {
+			t, err := mainIter()
+			if err != nil {
+				return nil, err
+			}
+			if t != nil {
+				// Copy before retagging: page iterators hand out their
+				// stored tuples, whose record ids must stay untouched.
+				return &Tuple{Desc: t.Desc, Fields: t.Fields, Rid: hybridRid{delta: false, rid: t.Rid}}, nil
+			}
+			mainIter = nil
+			// The delta opens only after the main is exhausted, so a
+			// concurrent merge cannot show a row in both.
+			if deltaIter, err = f.delta.Iterator(tid); err != nil {
+				return nil, err
+			}
+		}
+		t, err := deltaIter()
+		if err != nil || t == nil {
+			return nil, err
+		}
+		return &Tuple{Desc: t.Desc, Fields: t.Fields, Rid: hybridRid{delta: true, rid: t.Rid}}, nil
+	}, nil
+}
+
+// DeltaRows returns the number of rows currently in the delta.
+func (f *HybridFile) DeltaRows(tid TransactionID) (int, error) {
+	iter, err := f.delta.Iterator(tid)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for {
+		t, err := iter()
+		if err != nil {
+			return 0, err
+		}
+		if t == nil {
+			return n, nil
+		}
+		n++
+	}
+}
+
+// MergeDelta moves every delta row into the column store in one
+// transaction: readers see either the old layout or the new, never a row
+// twice or not at all.
+func (f *HybridFile) MergeDelta() error {
+	f.mergeMu.Lock()
+	defer f.mergeMu.Unlock()
+	tid := NewTID()
+	if err := f.bufPool.BeginTransaction(tid); err != nil {
+		return err
+	}
+	moved := 0
+	err := func() error {
+		iter, err := f.delta.Iterator(tid)
+		if err != nil {
+			return err
+		}
+		for {
+			t, err := iter()
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return nil
+			}
+			if err := f.main.insertTuple(&Tuple{Desc: t.Desc, Fields: t.Fields}, tid); err != nil {
+				return err
+			}
+			if err := f.delta.deleteTuple(t, tid); err != nil {
+				return err
+			}
+			moved++
+		}
+	}()
+	if err != nil {
+		f.bufPool.AbortTransaction(tid)
+		return err
+	}
+	f.bufPool.CommitTransaction(tid)
+	if moved > 0 {
+		logger.Debugf("hybrid %s: merged %d delta rows into the column store", f.baseName, moved)
+	}
+	return nil
+}
+
+// StartBackgroundMerge merges the delta whenever it holds at least
+// threshold rows, checking every interval. The returned function stops
+// the merger.
+func (f *HybridFile) StartBackgroundMerge(interval time.Duration, threshold int) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				tid := NewTID()
+				if f.bufPool.BeginTransaction(tid) != nil {
+					continue
+				}
+				n, err := f.DeltaRows(tid)
+				f.bufPool.CommitTransaction(tid)
+				if err != nil || n < threshold {
+					continue
+				}
+				if err := f.MergeDelta(); err != nil {
+					logger.Debugf("hybrid %s: background merge failed: %v", f.baseName, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func init() {
+	RegisterStorageEngine("hybrid", func(path string, desc *TupleDesc, bp *BufferPool) (DBFile, error) {
+		return NewHybridFile(strings.TrimSuffix(path, ".dat"), desc, bp)
+	})
+}
@@ -0,0 +1,90 @@
+package godb
+
+import "sync"
+
+// Transactional secondary index maintenance. The catalog's insert and
+// delete paths do not mutate HashIndex or CompositeIndex structures
+// directly: like the change stream, each operation is buffered per
+// transaction and applied from a buffer pool commit hook when (and only
+// when) the writing transaction commits. An aborted delete keeps its
+// index entry and an aborted insert never publishes a record id that
+// could alias a later reuse of the slot. The flip side is that, as with
+// change events, a transaction's own index lookups do not see its
+// uncommitted writes.
+
+// indexOp is one buffered index mutation.
+type indexOp struct {
+	idx    tableIndex
+	t      *Tuple
+	remove bool
+}
+
+// indexJournal holds each in-flight transaction's pending index ops.
+type indexJournal struct {
+	mu      sync.Mutex
+	pending map[TransactionID][]indexOp
+}
+
+// indexTxn lazily creates the catalog's index journal and hooks it into
+// transaction completion. Called when an index is created, so the
+// journal exists before any maintenance is buffered.
+func (c *Catalog) indexTxn() *indexJournal {
+	if c.idxJournal == nil {
+		c.idxJournal = &indexJournal{pending: make(map[TransactionID][]indexOp)}
+		c.bufPool.RegisterCommitHook(c.idxJournal.transactionDone)
+	}
+	return c.idxJournal
+}
+
+// queueIndexOps buffers the index maintenance t requires under tid,
+// extending it with virtual computed values that expression indexes key
+// on. Without a catalog (and so without a commit hook) the maintenance
+// is applied immediately.
+func (f *constrainedFile) queueIndexOps(t *Tuple, tid TransactionID, remove bool) error {
+	if len(f.indexes) == 0 {
+		return nil
+	}
+	it, err := f.withVirtual(t)
+	if err != nil {
+		return err
+	}
+	for _, idx := range f.indexes {
+		if f.catalog == nil {
+			if err := applyIndexOp(indexOp{idx: idx, t: it, remove: remove}); err != nil {
+				return err
+			}
+			continue
+		}
+		j := f.catalog.indexTxn()
+		j.mu.Lock()
+		j.pending[tid] = append(j.pending[tid], indexOp{idx: idx, t: it, remove: remove})
+		j.mu.Unlock()
+	}
+	return nil
+}
+
+func applyIndexOp(op indexOp) error {
+	if op.remove {
+		return op.idx.remove(op.t)
+	}
+	return op.idx.insert(op.t)
+}
+
+// transactionDone applies (on commit) or discards (on abort) the index
+// ops buffered for tid, in execution order. The transaction has already
+// committed, so a failure here cannot roll it back; it is logged and the
+// affected index can be repaired by dropping and recreating it.
+func (j *indexJournal) transactionDone(tid TransactionID, committed bool) {
+	j.mu.Lock()
+	ops := j.pending[tid]
+	delete(j.pending, tid)
+	j.mu.Unlock()
+	if !committed {
+		return
+	}
+	for _, op := range ops {
+		if err := applyIndexOp(op); err != nil {
+			logger.Debugf("tid %d: applying committed index change: %v", *tid, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package godb
+
+import "fmt"
+
+// PartialError reports a per-tuple failure part way through a bulk insert
+// or delete: Applied rows succeeded before Err stopped the operation.
+// Callers deciding whether to retry or abort can recover the count with a
+// type assertion and the cause with errors.Unwrap.
+type PartialError struct {
+	Applied int64
+	Err     error
+}
+
+func (e PartialError) Error() string {
+	return fmt.Sprintf("failed after %d rows: %v", e.Applied, e.Err)
+}
+
+func (e PartialError) Unwrap() error {
+	return e.Err
+}
+
+// InsertOp inserts every tuple of its child into insertFile and produces a
+// single tuple containing the number of rows inserted. Nothing is written
+// until the first call to the iterator, so construction is side-effect
+// free; all insertions then happen under the caller's transaction.
+type InsertOp struct {
+	insertFile  DBFile
+	child       Operator
+	collectRids bool
+	returning   bool
+	rids        []RecordID
+}
+
+// NewInsertOp returns an insert operator writing child's tuples to insertFile.
+func NewInsertOp(insertFile DBFile, child Operator) *InsertOp {
+	return &InsertOp{insertFile: insertFile, child: child}
+}
+
+// CollectRids makes the operator record the record id of every inserted
+// tuple for retrieval via AffectedRids after the iterator completes.
+func (i *InsertOp) CollectRids() *InsertOp {
+	i.collectRids = true
+	return i
+}
+
+// AffectedRids returns the record ids collected during the last execution;
+// it is only populated when CollectRids was called first.
+func (i *InsertOp) AffectedRids() []RecordID {
+	return i.rids
+}
+
+// Returning switches the operator into RETURNING mode: instead of a count
+// it emits each inserted tuple as it is written, carrying its assigned
+// record id and any auto-increment value filled in by the table, so
+// applications can retrieve generated keys without a follow-up scan.
+func (i *InsertOp) Returning() *InsertOp {
+	i.returning = true
+	return i
+}
+
+func (i *InsertOp) Descriptor() *TupleDesc {
+	if i.returning {
+		return i.insertFile.Descriptor()
+	}
+	return &TupleDesc{Fields: []FieldType{{Fname: "count", Ftype: IntType}}}
+}
+
+func (i *InsertOp) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	childIter, err := i.child.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	if i.returning {
+		// Insert one tuple per call and emit it; the file has assigned its
+		// record id and auto-increment value by the time it is returned.
+		count := int64(0)
+		return func() (*Tuple, error) {
+			t, err := childIter()
+			if err != nil {
+				return nil, PartialError{Applied: count, Err: err}
+			}
+			if t == nil {
+				return nil, nil
+			}
+			if err := i.insertFile.insertTuple(t, tid); err != nil {
+				return nil, PartialError{Applied: count, Err: err}
+			}
+			count++
+			return t, nil
+		}, nil
+	}
+	done := false
+	return func() (*Tuple, error) {
+		if done {
+			return nil, nil
+		}
+		if i.collectRids {
+			i.rids = nil
+		}
+		count := int64(0)
+		for {
+			t, err := childIter()
+			if err != nil {
+				return nil, PartialError{Applied: count, Err: err}
+			}
+			if t == nil {
+				break
+			}
+			if err := i.insertFile.insertTuple(t, tid); err != nil {
+				return nil, PartialError{Applied: count, Err: err}
+			}
+			if i.collectRids {
+				if rid, ok := t.Rid.(RecordID); ok {
+					i.rids = append(i.rids, rid)
+				}
+			}
+			count++
+		}
+		done = true
+		return &Tuple{Desc: *i.Descriptor(), Fields: []DBValue{IntField{count}}}, nil
+	}, nil
+}
@@ -0,0 +1,99 @@
+package godb
+
+// EqualityJoin joins its two children on equality of leftField and
+// rightField. The implementation is a blocking hash join: the left child is
+// consumed in batches of maxBufferSize tuples that are hashed and probed
+// with the right child.
+type EqualityJoin struct {
+	leftField, rightField Expr
+	left, right           *Operator
+	maxBufferSize         int
+}
+
+// NewJoin returns an equality join of left and right on the supplied fields.
+// maxBufferSize caps the number of left tuples hashed in memory at once.
+func NewJoin(left Operator, leftField Expr, right Operator, rightField Expr, maxBufferSize int) (*EqualityJoin, error) {
+	return &EqualityJoin{leftField, rightField, &left, &right, maxBufferSize}, nil
+}
+
+func (j *EqualityJoin) Descriptor() *TupleDesc {
+	return (*j.left).Descriptor().merge((*j.right).Descriptor())
+}
+
+func (j *EqualityJoin) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	leftIter, err := (*j.left).Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	var hash map[DBValue][]*Tuple
+	var rightIter func() (*Tuple, error)
+	var matches []*Tuple
+	leftDone := false
+
+	loadBatch := func() error {
+		hash = make(map[DBValue][]*Tuple)
+		for n := 0; n < j.maxBufferSize; n++ {
+			t, err := leftIter()
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				leftDone = true
+				break
+			}
+			if err := chargeMaterialized(tid, t); err != nil {
+				return err
+			}
+			v, err := j.leftField.EvalExpr(t)
+			if err != nil {
+				return err
+			}
+			// Bucket on the collation key so strings equal under the
+			// active collation join with each other.
+			k := joinKey(v)
+			hash[k] = append(hash[k], t)
+		}
+		var err error
+		rightIter, err = (*j.right).Iterator(tid)
+		return err
+	}
+
+	return func() (*Tuple, error) {
+		for {
+			if len(matches) > 0 {
+				t := matches[0]
+				matches = matches[1:]
+				return t, nil
+			}
+			if rightIter == nil {
+				if leftDone {
+					return nil, nil
+				}
+				if err := loadBatch(); err != nil {
+					return nil, err
+				}
+				if len(hash) == 0 {
+					return nil, nil
+				}
+			}
+			rt, err := rightIter()
+			if err != nil {
+				return nil, err
+			}
+			if rt == nil {
+				rightIter = nil
+				if leftDone {
+					return nil, nil
+				}
+				continue
+			}
+			v, err := j.rightField.EvalExpr(rt)
+			if err != nil {
+				return nil, err
+			}
+			for _, lt := range hash[joinKey(v)] {
+				matches = append(matches, joinTuples(lt, rt))
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,128 @@
+package godb
+
+// LateMaterializedJoin is an equality join specialized for column store
+// inputs. Phase one scans only the join-key column of each side, joining
+// key values and row positions; phase two fetches the projected output
+// columns from the column files for just the rows that actually join.
+// For wide tables with selective joins this moves a fraction of the data
+// an early-materializing join would.
+type LateMaterializedJoin struct {
+	left, right       *ColumnFile
+	leftKey, rightKey int
+	// leftCols and rightCols are the column indexes materialized into the
+	// output, in order.
+	leftCols, rightCols []int
+}
+
+// NewLateMaterializedJoin returns a late-materializing equality join of
+// left and right on the supplied key column indexes, producing the listed
+// output columns of each side.
+func NewLateMaterializedJoin(left *ColumnFile, leftKey int, right *ColumnFile, rightKey int, leftCols, rightCols []int) (*LateMaterializedJoin, error) {
+	for _, c := range append([]int{leftKey}, leftCols...) {
+		if c < 0 || c >= len(left.desc.Fields) {
+			return nil, GoDBError{FieldNotFoundError, "left column index out of range"}
+		}
+	}
+	for _, c := range append([]int{rightKey}, rightCols...) {
+		if c < 0 || c >= len(right.desc.Fields) {
+			return nil, GoDBError{FieldNotFoundError, "right column index out of range"}
+		}
+	}
+	return &LateMaterializedJoin{left: left, right: right, leftKey: leftKey, rightKey: rightKey, leftCols: leftCols, rightCols: rightCols}, nil
+}
+
+func (j *LateMaterializedJoin) Descriptor() *TupleDesc {
+	fields := make([]FieldType, 0, len(j.leftCols)+len(j.rightCols))
+	for _, c := range j.leftCols {
+		fields = append(fields, j.left.desc.Fields[c])
+	}
+	for _, c := range j.rightCols {
+		fields = append(fields, j.right.desc.Fields[c])
+	}
+	return &TupleDesc{Fields: fields}
+}
+
+// fetchRow materializes the values of columns cols for one row, fetching
+// each column's page through the buffer pool.
+func (f *ColumnFile) fetchRow(row int, cols []int, tid TransactionID) ([]DBValue, error) {
+	vals := make([]DBValue, len(cols))
+	for i, colNo := range cols {
+		colPageNo, slot := f.colLocation(colNo, row)
+		pg, err := f.bufPool.GetPage(f, f.pageNoFor(colNo, colPageNo), tid, ReadPerm)
+		if err != nil {
+			return nil, err
+		}
+		cp := pg.(*columnPage)
+		if slot >= cp.numValues() {
+			return nil, GoDBError{TupleNotFoundError, "row past end of column"}
+		}
+		vals[i] = cp.valueAt(slot)
+	}
+	return vals, nil
+}
+
+func (j *LateMaterializedJoin) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	desc := j.Descriptor()
+	// Phase one: hash the right key column (key value -> row positions),
+	// then stream the left key column against it. Only key bytes move.
+	var hash map[DBValue][]int
+	build := func() error {
+		iter, err := j.right.iteratorForColumns(tid, []int{j.rightKey})
+		if err != nil {
+			return err
+		}
+		hash = make(map[DBValue][]int)
+		for {
+			t, err := iter()
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return nil
+			}
+			hash[t.Fields[0]] = append(hash[t.Fields[0]], j.right.rowOf(t.Rid.(RecordID)))
+		}
+	}
+	var leftIter func() (*Tuple, error)
+	leftRow := -1
+	var rightRows []int
+	return func() (*Tuple, error) {
+		if hash == nil {
+			if err := build(); err != nil {
+				return nil, err
+			}
+			var err error
+			if leftIter, err = j.left.iteratorForColumns(tid, []int{j.leftKey}); err != nil {
+				return nil, err
+			}
+		}
+		for {
+			if len(rightRows) > 0 {
+				// Phase two: materialize the output columns for one
+				// joined row pair.
+				rr := rightRows[0]
+				rightRows = rightRows[1:]
+				lv, err := j.left.fetchRow(leftRow, j.leftCols, tid)
+				if err != nil {
+					return nil, err
+				}
+				rv, err := j.right.fetchRow(rr, j.rightCols, tid)
+				if err != nil {
+					return nil, err
+				}
+				return &Tuple{Desc: *desc.copy(), Fields: append(lv, rv...)}, nil
+			}
+			t, err := leftIter()
+			if err != nil {
+				return nil, err
+			}
+			if t == nil {
+				return nil, nil
+			}
+			if rows := hash[t.Fields[0]]; len(rows) > 0 {
+				leftRow = j.left.rowOf(t.Rid.(RecordID))
+				rightRows = rows
+			}
+		}
+	}, nil
+}
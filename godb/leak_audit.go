@@ -0,0 +1,162 @@
+package godb
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Leak audit. Under NO STEAL a long transaction's dirty pages cannot be
+// evicted, so an iterator that is opened but never driven to exhaustion —
+// holding its transaction open with it — quietly fills the buffer pool.
+// The audit is a debug mode: when enabled, every iterator opened through
+// the catalog's tables records the stack that opened it, and a
+// transaction that ends with unexhausted iterators is reported with those
+// stacks. AuditPins complements it with a live snapshot of how many
+// pages, and how many dirty pages, each running transaction is holding.
+
+// IteratorLeak describes one iterator whose transaction ended before the
+// iterator was exhausted.
+type IteratorLeak struct {
+	Tid      TransactionID
+	Table    string
+	OpenedAt time.Time
+	// Stack is the goroutine stack captured when the iterator was opened.
+	Stack string
+}
+
+// PinAudit is one running transaction's page-pinning snapshot.
+type PinAudit struct {
+	Tid        TransactionID
+	Started    time.Time
+	PagesHeld  int
+	DirtyPages int
+}
+
+// maxLeakRecords bounds the retained leak history; older records are
+// dropped first.
+const maxLeakRecords = 256
+
+// leakAudit is the audit state; nil on a pool until enableLeakAudit.
+type leakAudit struct {
+	mu     sync.Mutex
+	nextID int64
+	open   map[TransactionID]map[int64]*IteratorLeak
+	leaked []IteratorLeak
+}
+
+// EnableLeakAudit starts tracking iterators opened over the catalog's
+// tables. Existing tables are wrapped so their iterators are seen; enable
+// after the catalog's tables are registered.
+func (c *Catalog) EnableLeakAudit() error {
+	for name := range c.tables {
+		if _, err := c.constrained(name); err != nil {
+			return err
+		}
+	}
+	c.bufPool.enableLeakAudit()
+	return nil
+}
+
+// enableLeakAudit turns the audit on; enabling twice is a no-op.
+func (bp *BufferPool) enableLeakAudit() {
+	bp.mu.Lock()
+	if bp.leaks != nil {
+		bp.mu.Unlock()
+		return
+	}
+	la := &leakAudit{open: make(map[TransactionID]map[int64]*IteratorLeak)}
+	bp.leaks = la
+	bp.mu.Unlock()
+	bp.RegisterCommitHook(func(tid TransactionID, committed bool) {
+		la.transactionDone(tid)
+	})
+}
+
+// auditIterator wraps iter to record its opening stack and mark it done
+// once it is exhausted or fails; iter is returned unchanged when the
+// audit is disabled.
+func (bp *BufferPool) auditIterator(tid TransactionID, table string, iter func() (*Tuple, error)) func() (*Tuple, error) {
+	bp.mu.Lock()
+	la := bp.leaks
+	bp.mu.Unlock()
+	if la == nil {
+		return iter
+	}
+	buf := make([]byte, 4096)
+	n := runtime.Stack(buf, false)
+	la.mu.Lock()
+	la.nextID++
+	id := la.nextID
+	if la.open[tid] == nil {
+		la.open[tid] = make(map[int64]*IteratorLeak)
+	}
+	la.open[tid][id] = &IteratorLeak{Tid: tid, Table: table, OpenedAt: time.Now(), Stack: string(buf[:n])}
+	la.mu.Unlock()
+	return func() (*Tuple, error) {
+		t, err := iter()
+		if err != nil || t == nil {
+			la.mu.Lock()
+			if m := la.open[tid]; m != nil {
+				delete(m, id)
+				if len(m) == 0 {
+					delete(la.open, tid)
+				}
+			}
+			la.mu.Unlock()
+		}
+		return t, err
+	}
+}
+
+// transactionDone turns tid's still-open iterators into leak records.
+func (la *leakAudit) transactionDone(tid TransactionID) {
+	la.mu.Lock()
+	defer la.mu.Unlock()
+	for _, rec := range la.open[tid] {
+		la.leaked = append(la.leaked, *rec)
+		logger.Debugf("leak audit: tid %d ended with an unexhausted iterator over %s, opened at:\n%s", *tid, rec.Table, rec.Stack)
+	}
+	delete(la.open, tid)
+	if len(la.leaked) > maxLeakRecords {
+		la.leaked = la.leaked[len(la.leaked)-maxLeakRecords:]
+	}
+}
+
+// LeakedIterators returns the recorded leaks, oldest first. The history
+// keeps the most recent maxLeakRecords entries.
+func (bp *BufferPool) LeakedIterators() []IteratorLeak {
+	bp.mu.Lock()
+	la := bp.leaks
+	bp.mu.Unlock()
+	if la == nil {
+		return nil
+	}
+	la.mu.Lock()
+	defer la.mu.Unlock()
+	out := make([]IteratorLeak, len(la.leaked))
+	copy(out, la.leaked)
+	return out
+}
+
+// AuditPins reports, per running transaction, how many pages it holds
+// locks on and how many of those are dirty in the cache, oldest
+// transaction first. A transaction pinning many dirty pages is the usual
+// reason the pool reports itself full.
+func (bp *BufferPool) AuditPins() []PinAudit {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	out := make([]PinAudit, 0, len(bp.running))
+	for tid, started := range bp.running {
+		a := PinAudit{Tid: tid, Started: started, PagesHeld: len(bp.pagesHeld[tid])}
+		for key := range bp.pagesHeld[tid] {
+			if pg, ok := bp.cachedPage(key); ok && pg.isDirty() {
+				a.DirtyPages++
+			}
+		}
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Started.Before(out[j].Started) })
+	return out
+}
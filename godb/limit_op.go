@@ -0,0 +1,48 @@
+package godb
+
+// LimitOp passes through at most limitTups tuples of its child.
+type LimitOp struct {
+	child     Operator
+	limitTups Expr
+}
+
+// NewLimitOp returns a limit operator; limitTups is evaluated once, against
+// the first tuple, and must produce an int.
+func NewLimitOp(limitTups Expr, child Operator) *LimitOp {
+	return &LimitOp{child: child, limitTups: limitTups}
+}
+
+func (l *LimitOp) Descriptor() *TupleDesc {
+	return l.child.Descriptor()
+}
+
+func (l *LimitOp) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	childIter, err := l.child.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	returned := 0
+	limit := -1
+	return func() (*Tuple, error) {
+		t, err := childIter()
+		if err != nil || t == nil {
+			return nil, err
+		}
+		if limit < 0 {
+			v, err := l.limitTups.EvalExpr(t)
+			if err != nil {
+				return nil, err
+			}
+			iv, ok := v.(IntField)
+			if !ok {
+				return nil, GoDBError{TypeMismatchError, "limit must be an int"}
+			}
+			limit = int(iv.Value)
+		}
+		if returned >= limit {
+			return nil, nil
+		}
+		returned++
+		return t, nil
+	}, nil
+}
@@ -0,0 +1,123 @@
+package godb
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Per-transaction resource limits. Blocking operators (order-by, the hash
+// join's build side, aggregation) materialize intermediate state in memory;
+// SetTransactionLimits caps how much one transaction may accumulate, and
+// how long it may run, so a runaway query fails with a
+// ResourceExceededError instead of taking the process down. A zero field
+// leaves that limit unenforced.
+type TransactionLimits struct {
+	// MaxTuples caps the total tuples materialized by blocking operators.
+	MaxTuples int64
+	// MaxBytes caps the serialized size of materialized intermediate state.
+	MaxBytes int64
+	// MaxDuration caps wall-clock time from SetTransactionLimits; exceeding
+	// it surfaces as a TimeoutError from page access and blocking operators.
+	MaxDuration time.Duration
+	// LockWaitTimeout caps how long one GetPage call may wait for a
+	// conflicting page lock before failing with a TimeoutError instead of
+	// blocking indefinitely.
+	LockWaitTimeout time.Duration
+}
+
+// limitState tracks one limited transaction's consumption.
+type limitState struct {
+	limits TransactionLimits
+	start  time.Time
+	tuples int64
+	bytes  int64
+}
+
+var (
+	limitsMu sync.Mutex
+	limited  map[TransactionID]*limitState
+)
+
+// SetTransactionLimits applies limits to tid; the duration clock starts
+// now. Call ClearTransactionLimits once the transaction finishes to
+// release the entry.
+func SetTransactionLimits(tid TransactionID, limits TransactionLimits) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	if limited == nil {
+		limited = make(map[TransactionID]*limitState)
+	}
+	limited[tid] = &limitState{limits: limits, start: time.Now()}
+}
+
+// ClearTransactionLimits removes any limits set on tid.
+func ClearTransactionLimits(tid TransactionID) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	delete(limited, tid)
+}
+
+// chargeMaterialized records that tid materialized t in a blocking
+// operator and returns a ResourceExceededError if any limit is exceeded.
+// Transactions without limits are never charged.
+func chargeMaterialized(tid TransactionID, t *Tuple) error {
+	limitsMu.Lock()
+	st, ok := limited[tid]
+	if !ok {
+		limitsMu.Unlock()
+		return nil
+	}
+	st.tuples++
+	st.bytes += int64(t.Desc.bytesPerTuple())
+	l, tuples, bytes, start := st.limits, st.tuples, st.bytes, st.start
+	limitsMu.Unlock()
+	if l.MaxTuples > 0 && tuples > l.MaxTuples {
+		return GoDBError{ResourceExceededError, fmt.Sprintf("transaction materialized more than %d tuples", l.MaxTuples)}
+	}
+	if l.MaxBytes > 0 && bytes > l.MaxBytes {
+		return GoDBError{ResourceExceededError, fmt.Sprintf("transaction materialized more than %d bytes", l.MaxBytes)}
+	}
+	if l.MaxDuration > 0 && time.Since(start) > l.MaxDuration {
+		return GoDBError{TimeoutError, fmt.Sprintf("transaction exceeded %v execution time", l.MaxDuration)}
+	}
+	return nil
+}
+
+// lockWaitTimeout returns tid's lock-wait timeout, zero if unlimited.
+func lockWaitTimeout(tid TransactionID) time.Duration {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	if st, ok := limited[tid]; ok {
+		return st.limits.LockWaitTimeout
+	}
+	return 0
+}
+
+// statementDeadline returns the wall-clock deadline implied by tid's
+// MaxDuration limit, if one is set.
+func statementDeadline(tid TransactionID) (time.Time, bool) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	if st, ok := limited[tid]; ok && st.limits.MaxDuration > 0 {
+		return st.start.Add(st.limits.MaxDuration), true
+	}
+	return time.Time{}, false
+}
+
+// checkDeadline returns a TimeoutError if tid has exceeded its statement
+// deadline; transactions without limits never time out.
+func checkDeadline(tid TransactionID) error {
+	limitsMu.Lock()
+	st, ok := limited[tid]
+	if !ok {
+		limitsMu.Unlock()
+		return nil
+	}
+	l, start := st.limits, st.start
+	limitsMu.Unlock()
+	if l.MaxDuration > 0 && time.Since(start) > l.MaxDuration {
+		return GoDBError{TimeoutError, fmt.Sprintf("transaction exceeded %v execution time", l.MaxDuration)}
+	}
+	return nil
+}
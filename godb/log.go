@@ -0,0 +1,61 @@
+package godb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Logger receives debug and trace output from the storage and concurrency
+// layers. Debugf covers page I/O and transaction outcomes; Tracef covers
+// per-tuple and per-lock events and is extremely chatty. The default logger
+// discards everything.
+type Logger interface {
+	Debugf(format string, args ...any)
+	Tracef(format string, args ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, args ...any) {}
+func (nopLogger) Tracef(format string, args ...any) {}
+
+// logger is the installed logger. Like the metrics sink, it is expected to
+// be installed once at startup, before transactions run.
+var logger Logger = nopLogger{}
+
+// SetLogger installs l as the process-wide logger. Pass nil to restore the
+// discarding default.
+func SetLogger(l Logger) {
+	if l == nil {
+		logger = nopLogger{}
+		return
+	}
+	logger = l
+}
+
+// LevelTrace is the slog level used for trace output by the slog adapter,
+// one step below slog.LevelDebug.
+const LevelTrace = slog.LevelDebug - 4
+
+type slogAdapter struct {
+	s *slog.Logger
+}
+
+// NewSlogLogger adapts a *slog.Logger to the godb Logger interface. Debugf
+// maps to slog.LevelDebug and Tracef to LevelTrace.
+func NewSlogLogger(s *slog.Logger) Logger {
+	return &slogAdapter{s: s}
+}
+
+func (a *slogAdapter) Debugf(format string, args ...any) {
+	if a.s.Enabled(context.Background(), slog.LevelDebug) {
+		a.s.Debug(fmt.Sprintf(format, args...))
+	}
+}
+
+func (a *slogAdapter) Tracef(format string, args ...any) {
+	if a.s.Enabled(context.Background(), LevelTrace) {
+		a.s.Log(context.Background(), LevelTrace, fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,106 @@
+package godb
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MemFile is a DBFile held entirely in memory: no backing file, no pages,
+// and no buffer pool involvement. It is intended for temp tables,
+// intermediate materialization, and tests. Contents are lost on process
+// exit and are not transactional: inserts and deletes are visible
+// immediately and survive an abort.
+type MemFile struct {
+	desc *TupleDesc
+
+	mu      sync.Mutex
+	tuples  []*Tuple // nil entries are deleted slots
+	numUsed int
+}
+
+// NewMemFile returns an empty in-memory table with the supplied schema.
+func NewMemFile(desc *TupleDesc) *MemFile {
+	return &MemFile{desc: desc}
+}
+
+func (f *MemFile) Descriptor() *TupleDesc {
+	return f.desc
+}
+
+// insertTuple appends t, assigning a record id of the form "mem.<slot>".
+func (f *MemFile) insertTuple(t *Tuple, tid TransactionID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored := &Tuple{Desc: t.Desc, Fields: t.Fields}
+	stored.Rid = RecordID{PageNo: 0, SlotNo: len(f.tuples)}
+	f.tuples = append(f.tuples, stored)
+	f.numUsed++
+	t.Rid = stored.Rid
+	return nil
+}
+
+// deleteTuple removes the tuple identified by t.Rid.
+func (f *MemFile) deleteTuple(t *Tuple, tid TransactionID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, stored := range f.tuples {
+		if stored != nil && stored.Rid == t.Rid {
+			f.tuples[i] = nil
+			f.numUsed--
+			return nil
+		}
+	}
+	return GoDBError{TupleNotFoundError, fmt.Sprintf("rid %v not found", t.Rid)}
+}
+
+// readPage is not meaningful for an in-memory file.
+func (f *MemFile) readPage(pageNo int) (Page, error) {
+	return nil, GoDBError{IllegalOperationError, "MemFile has no pages"}
+}
+
+// flushPage is a no-op; there is nothing durable to write.
+func (f *MemFile) flushPage(page Page) error {
+	return nil
+}
+
+func (f *MemFile) pageKey(pgNo int) any {
+	return memFilePageKey{file: f, pageNo: pgNo}
+}
+
+type memFilePageKey struct {
+	file   *MemFile
+	pageNo int
+}
+
+// NumPages reports zero: MemFile bypasses the page abstraction entirely.
+func (f *MemFile) NumPages() int {
+	return 0
+}
+
+// Len returns the number of live tuples.
+func (f *MemFile) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.numUsed
+}
+
+// Iterator yields the live tuples in insertion order. It iterates over a
+// snapshot of the slot array, so inserts and deletes during iteration do
+// not disturb it.
+func (f *MemFile) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	f.mu.Lock()
+	snapshot := make([]*Tuple, len(f.tuples))
+	copy(snapshot, f.tuples)
+	f.mu.Unlock()
+	i := 0
+	return func() (*Tuple, error) {
+		for i < len(snapshot) {
+			t := snapshot[i]
+			i++
+			if t != nil {
+				return t, nil
+			}
+		}
+		return nil, nil
+	}, nil
+}
@@ -0,0 +1,50 @@
+package godb
+
+// Metrics receives counter increments from the storage and execution layers.
+// Implementations must be safe for concurrent use; the default sink discards
+// everything, so instrumentation costs nothing unless a sink is installed.
+type Metrics interface {
+	// BufferPoolHit is called when GetPage finds the page cached.
+	BufferPoolHit()
+	// BufferPoolMiss is called when GetPage must read the page from disk.
+	BufferPoolMiss()
+	// BufferPoolEviction is called when a page is evicted to make room.
+	BufferPoolEviction()
+	// PageFlushed is called each time a page is written back to disk.
+	PageFlushed()
+	// TransactionCommitted and TransactionAborted are called once per
+	// transaction outcome.
+	TransactionCommitted()
+	TransactionAborted()
+	// LockWait is called each time a transaction blocks on a page lock.
+	LockWait()
+	// TuplesScanned is called by scan operators; op names the scan kind
+	// (e.g. "heap_scan", "column_scan").
+	TuplesScanned(op string, n int)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) BufferPoolHit()                 {}
+func (nopMetrics) BufferPoolMiss()                {}
+func (nopMetrics) BufferPoolEviction()            {}
+func (nopMetrics) PageFlushed()                   {}
+func (nopMetrics) TransactionCommitted()          {}
+func (nopMetrics) TransactionAborted()            {}
+func (nopMetrics) LockWait()                      {}
+func (nopMetrics) TuplesScanned(op string, n int) {}
+
+// metrics is the installed sink. It is written once at startup via
+// SetMetrics, before any transactions run.
+var metrics Metrics = nopMetrics{}
+
+// SetMetrics installs m as the process-wide metrics sink. Pass nil to
+// restore the discarding default. SetMetrics should be called before the
+// database starts serving transactions.
+func SetMetrics(m Metrics) {
+	if m == nil {
+		metrics = nopMetrics{}
+		return
+	}
+	metrics = m
+}
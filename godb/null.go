@@ -0,0 +1,87 @@
+package godb
+
+// NULL support for expressions. NullField is a DBValue carrying no value;
+// it arises from expressions like NULLIF rather than from storage, whose
+// pages remain fixed-width and null-free. Comparisons involving NULL are
+// false (SQL's UNKNOWN collapses to false in a filter) except under
+// OpNotDistinct, which treats two NULLs as equal.
+
+// NullField is the SQL NULL value.
+type NullField struct{}
+
+func (NullField) isDBValue() {}
+
+// isNull reports whether v is the NULL value.
+func isNull(v DBValue) bool {
+	_, ok := v.(NullField)
+	return ok
+}
+
+// CoalesceExpr evaluates its arguments in order and produces the first
+// non-null value, or NULL if every argument is null.
+type CoalesceExpr struct {
+	args []Expr
+}
+
+// NewCoalesceExpr returns a COALESCE over args, which must be non-empty.
+func NewCoalesceExpr(args ...Expr) (*CoalesceExpr, error) {
+	if len(args) == 0 {
+		return nil, GoDBError{IllegalOperationError, "coalesce needs at least one argument"}
+	}
+	return &CoalesceExpr{args: args}, nil
+}
+
+func (c *CoalesceExpr) EvalExpr(t *Tuple) (DBValue, error) {
+	for _, e := range c.args {
+		v, err := e.EvalExpr(t)
+		if err != nil {
+			return nil, err
+		}
+		if !isNull(v) {
+			return v, nil
+		}
+	}
+	return NullField{}, nil
+}
+
+func (c *CoalesceExpr) GetExprType() FieldType {
+	ft := c.args[0].GetExprType()
+	ft.Fname = "coalesce"
+	return ft
+}
+
+// NullIfExpr produces NULL when its two arguments are equal and the first
+// argument otherwise.
+type NullIfExpr struct {
+	a, b Expr
+}
+
+// NewNullIfExpr returns a NULLIF over a and b.
+func NewNullIfExpr(a, b Expr) *NullIfExpr {
+	return &NullIfExpr{a: a, b: b}
+}
+
+func (n *NullIfExpr) EvalExpr(t *Tuple) (DBValue, error) {
+	va, err := n.a.EvalExpr(t)
+	if err != nil {
+		return nil, err
+	}
+	vb, err := n.b.EvalExpr(t)
+	if err != nil {
+		return nil, err
+	}
+	eq, err := compareValues(va, vb, OpNotDistinct)
+	if err != nil {
+		return nil, err
+	}
+	if eq {
+		return NullField{}, nil
+	}
+	return va, nil
+}
+
+func (n *NullIfExpr) GetExprType() FieldType {
+	ft := n.a.GetExprType()
+	ft.Fname = "nullif"
+	return ft
+}
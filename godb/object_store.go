@@ -0,0 +1,96 @@
+package godb
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ObjectStore is a flat keyed blob store, the backend for tiered column
+// storage. Keys are slash-separated paths; Put overwrites. The built-in
+// DirObjectStore keeps objects in a local directory tree; an S3 (or other
+// remote) client can implement ObjectStore without adding dependencies to
+// the core.
+type ObjectStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+	// List returns the keys with the given prefix, sorted.
+	List(prefix string) ([]string, error)
+}
+
+// DirObjectStore stores objects as files under a root directory. It is
+// the reference ObjectStore implementation and stands in for a remote
+// store in tests and single-machine deployments (the root can be a
+// network mount).
+type DirObjectStore struct {
+	root string
+}
+
+// NewDirObjectStore returns a store rooted at root, creating it if
+// necessary.
+func NewDirObjectStore(root string) (*DirObjectStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	return &DirObjectStore{root: root}, nil
+}
+
+func (s *DirObjectStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+func (s *DirObjectStore) Put(key string, data []byte) error {
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
+
+func (s *DirObjectStore) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	return data, nil
+}
+
+func (s *DirObjectStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
+
+func (s *DirObjectStore) List(prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) && !strings.HasSuffix(key, ".tmp") {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
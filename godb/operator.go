@@ -0,0 +1,94 @@
+package godb
+
+// Operator is a node in a query plan. Every operator exposes the schema of
+// the tuples it produces and an iterator over its results; iterators are
+// single-use closures that return (nil, nil) when exhausted.
+type Operator interface {
+	Descriptor() *TupleDesc
+	Iterator(tid TransactionID) (func() (*Tuple, error), error)
+}
+
+// BoolOp names a comparison operator used by filters and joins.
+type BoolOp int
+
+const (
+	OpGt BoolOp = iota
+	OpLt
+	OpGe
+	OpLe
+	OpEq
+	OpNeq
+	// OpNotDistinct is null-aware equality (IS NOT DISTINCT FROM): two
+	// NULLs compare equal, a NULL and a value compare unequal.
+	OpNotDistinct
+)
+
+// evalPred evaluates e1 on t1 and e2 on t2 and applies comparison op to the
+// resulting values.
+func evalPred(t1 *Tuple, e1 Expr, t2 *Tuple, e2 Expr, op BoolOp) (bool, error) {
+	v1, err := e1.EvalExpr(t1)
+	if err != nil {
+		return false, err
+	}
+	v2, err := e2.EvalExpr(t2)
+	if err != nil {
+		return false, err
+	}
+	return compareValues(v1, v2, op)
+}
+
+// compareValues applies op to two field values of the same type.
+func compareValues(v1, v2 DBValue, op BoolOp) (bool, error) {
+	if isNull(v1) || isNull(v2) {
+		if op == OpNotDistinct {
+			return isNull(v1) && isNull(v2), nil
+		}
+		return false, nil
+	}
+	if op == OpNotDistinct {
+		op = OpEq
+	}
+	var cmp int
+	switch x1 := v1.(type) {
+	case IntField:
+		x2, ok := v2.(IntField)
+		if !ok {
+			return false, GoDBError{TypeMismatchError, "cannot compare int to non-int"}
+		}
+		switch {
+		case x1.Value < x2.Value:
+			cmp = -1
+		case x1.Value > x2.Value:
+			cmp = 1
+		}
+	case DecimalField:
+		x2, ok := v2.(DecimalField)
+		if !ok {
+			return false, GoDBError{TypeMismatchError, "cannot compare decimal to non-decimal"}
+		}
+		cmp = compareDecimals(x1, x2)
+	case StringField:
+		x2, ok := v2.(StringField)
+		if !ok {
+			return false, GoDBError{TypeMismatchError, "cannot compare string to non-string"}
+		}
+		cmp = collate().Compare(x1.Value, x2.Value)
+	default:
+		return false, GoDBError{TypeMismatchError, "unknown field type"}
+	}
+	switch op {
+	case OpGt:
+		return cmp > 0, nil
+	case OpLt:
+		return cmp < 0, nil
+	case OpGe:
+		return cmp >= 0, nil
+	case OpLe:
+		return cmp <= 0, nil
+	case OpEq:
+		return cmp == 0, nil
+	case OpNeq:
+		return cmp != 0, nil
+	}
+	return false, GoDBError{IllegalOperationError, "unknown comparison op"}
+}
@@ -0,0 +1,80 @@
+package godb
+
+import "sort"
+
+// OrderBy sorts the tuples of its child on a list of expressions, each
+// ascending or descending. Sorting is blocking: the child is fully consumed
+// on the first call to the iterator.
+type OrderBy struct {
+	orderBy   []Expr
+	child     Operator
+	ascending []bool
+}
+
+// NewOrderBy returns an order-by operator sorting child on orderByFields;
+// ascending[i] selects the direction for field i.
+func NewOrderBy(orderByFields []Expr, child Operator, ascending []bool) (*OrderBy, error) {
+	if len(orderByFields) != len(ascending) {
+		return nil, GoDBError{ParseError, "mismatched order-by field and direction counts"}
+	}
+	return &OrderBy{orderBy: orderByFields, child: child, ascending: ascending}, nil
+}
+
+func (o *OrderBy) Descriptor() *TupleDesc {
+	return o.child.Descriptor()
+}
+
+func (o *OrderBy) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	childIter, err := o.child.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	var tuples []*Tuple
+	loaded := false
+	i := 0
+	return func() (*Tuple, error) {
+		if !loaded {
+			for {
+				t, err := childIter()
+				if err != nil {
+					return nil, err
+				}
+				if t == nil {
+					break
+				}
+				if err := chargeMaterialized(tid, t); err != nil {
+					return nil, err
+				}
+				tuples = append(tuples, t)
+			}
+			var sortErr error
+			sort.SliceStable(tuples, func(a, b int) bool {
+				for k, e := range o.orderBy {
+					ord, err := tuples[a].compareField(tuples[b], e)
+					if err != nil {
+						sortErr = err
+						return false
+					}
+					if ord == OrderedEqual {
+						continue
+					}
+					if o.ascending[k] {
+						return ord == OrderedLessThan
+					}
+					return ord == OrderedGreaterThan
+				}
+				return false
+			})
+			if sortErr != nil {
+				return nil, sortErr
+			}
+			loaded = true
+		}
+		if i >= len(tuples) {
+			return nil, nil
+		}
+		t := tuples[i]
+		i++
+		return t, nil
+	}, nil
+}
@@ -0,0 +1,60 @@
+package godb
+
+import "bytes"
+
+// Page is an in-memory copy of one on-disk page, managed by the buffer pool.
+type Page interface {
+	// isDirty reports whether the page has been modified since it was read
+	// or last flushed.
+	isDirty() bool
+	// setDirty marks the page dirty or clean on behalf of transaction tid.
+	setDirty(tid TransactionID, dirty bool)
+	// dirtier returns the transaction that dirtied the page, nil if clean.
+	dirtier() TransactionID
+	// getFile returns the DBFile the page belongs to.
+	getFile() DBFile
+	// toBuffer serializes the page into a PageSize-byte buffer for flushing.
+	toBuffer() (*bytes.Buffer, error)
+}
+
+// DBFile is any file of pages storing the tuples of one table.
+type DBFile interface {
+	// Descriptor returns the schema of the tuples stored in the file.
+	Descriptor() *TupleDesc
+
+	// insertTuple adds t to the file on behalf of tid, setting t.Rid.
+	insertTuple(t *Tuple, tid TransactionID) error
+	// deleteTuple removes the tuple identified by t.Rid on behalf of tid.
+	deleteTuple(t *Tuple, tid TransactionID) error
+
+	// readPage reads page pageNo directly from disk, bypassing the buffer
+	// pool. Callers should normally go through BufferPool.GetPage.
+	readPage(pageNo int) (Page, error)
+	// flushPage writes the page back to disk and marks it clean.
+	flushPage(page Page) error
+	// pageKey returns the key that uniquely identifies page pgNo of this
+	// file in the buffer pool.
+	pageKey(pgNo int) any
+
+	// NumPages returns the number of pages currently in the file.
+	NumPages() int
+
+	// Sync forces the file's completed writes to stable storage; flushPage
+	// alone only writes through the OS page cache.
+	Sync() error
+
+	// Iterator returns a function that yields the file's tuples one at a
+	// time, returning nil when exhausted.
+	Iterator(tid TransactionID) (func() (*Tuple, error), error)
+}
+
+// InsertTuple adds t to f on behalf of tid. It is the exported entry point
+// for packages outside godb; within the package use f.insertTuple.
+func InsertTuple(f DBFile, t *Tuple, tid TransactionID) error {
+	return f.insertTuple(t, tid)
+}
+
+// DeleteTuple removes the tuple identified by t.Rid from f on behalf of tid.
+func DeleteTuple(f DBFile, t *Tuple, tid TransactionID) error {
+	return f.deleteTuple(t, tid)
+}
@@ -0,0 +1,459 @@
+package godb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseQuery compiles a small SQL subset into an operator tree over the
+// tables of c. The supported grammar is:
+//
+//	SELECT select_list FROM table [JOIN table ON field = field]
+//	       [WHERE field op literal [AND ...]]
+//	       [GROUP BY field] [ORDER BY field [ASC|DESC]] [LIMIT n]
+//
+// where select_list is * or a comma-separated list of fields and aggregates
+// (count/sum/avg/min/max). Identifiers are case-insensitive keywords aside,
+// string literals are single-quoted, and only literals may appear on the
+// right side of WHERE comparisons.
+func ParseQuery(c *Catalog, query string) (Operator, error) {
+	toks, err := lexSQL(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &sqlParser{catalog: c, toks: toks}
+	op, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, GoDBError{ParseError, fmt.Sprintf("unexpected token %q", p.peek())}
+	}
+	c.NoteQuery(op)
+	return op, nil
+}
+
+// lexSQL splits query into tokens: identifiers, numbers, single-quoted
+// strings (kept quoted), and punctuation.
+func lexSQL(query string) ([]string, error) {
+	var toks []string
+	i := 0
+	for i < len(query) {
+		ch := query[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ';':
+			i++
+		case ch == '\'':
+			j := i + 1
+			for j < len(query) && query[j] != '\'' {
+				j++
+			}
+			if j >= len(query) {
+				return nil, GoDBError{ParseError, "unterminated string literal"}
+			}
+			toks = append(toks, query[i:j+1])
+			i = j + 1
+		case ch == '(' || ch == ')' || ch == ',' || ch == '*' || ch == '.':
+			toks = append(toks, string(ch))
+			i++
+		case ch == '<' || ch == '>' || ch == '=' || ch == '!':
+			if i+1 < len(query) && query[i+1] == '=' {
+				toks = append(toks, query[i:i+2])
+				i += 2
+			} else {
+				toks = append(toks, string(ch))
+				i++
+			}
+		default:
+			j := i
+			for j < len(query) && !strings.ContainsRune(" \t\n\r;(),*<>=!'.", rune(query[j])) {
+				j++
+			}
+			if j == i {
+				return nil, GoDBError{ParseError, fmt.Sprintf("unexpected character %q", ch)}
+			}
+			toks = append(toks, query[i:j])
+			i = j
+		}
+	}
+	return toks, nil
+}
+
+type sqlParser struct {
+	catalog *Catalog
+	toks    []string
+	pos     int
+	// prepared is the statement being compiled by Prepare; placeholders are
+	// only legal when it is set.
+	prepared *PreparedQuery
+}
+
+func (p *sqlParser) done() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *sqlParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+func (p *sqlParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// matchKw consumes the next token if it equals kw case-insensitively.
+func (p *sqlParser) matchKw(kw string) bool {
+	if strings.EqualFold(p.peek(), kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expect(tok string) error {
+	if !p.matchKw(tok) {
+		return GoDBError{ParseError, fmt.Sprintf("expected %q, got %q", tok, p.peek())}
+	}
+	return nil
+}
+
+// selectItem is one entry of the select list before name resolution.
+type selectItem struct {
+	agg   string // "" for a plain field
+	field string // field name, or "*" for count(*)
+	alias string
+}
+
+func (p *sqlParser) parseSelect() (Operator, error) {
+	if err := p.expect("select"); err != nil {
+		return nil, err
+	}
+	star := false
+	var items []selectItem
+	if p.matchKw("*") {
+		star = true
+	} else {
+		for {
+			item, err := p.parseSelectItem()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			if !p.matchKw(",") {
+				break
+			}
+		}
+	}
+	if err := p.expect("from"); err != nil {
+		return nil, err
+	}
+	plan, err := p.catalog.tableOrView(p.next())
+	if err != nil {
+		return nil, err
+	}
+	if p.matchKw("join") {
+		right, err := p.catalog.tableOrView(p.next())
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("on"); err != nil {
+			return nil, err
+		}
+		leftField, err := p.parseFieldRef(plan.Descriptor(), right.Descriptor())
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		rightField, err := p.parseFieldRef(plan.Descriptor(), right.Descriptor())
+		if err != nil {
+			return nil, err
+		}
+		plan, err = NewJoin(plan, leftField, right, rightField, 10000)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.matchKw("where") {
+		for {
+			plan, err = p.parseWhereClause(plan)
+			if err != nil {
+				return nil, err
+			}
+			if !p.matchKw("and") {
+				break
+			}
+		}
+	}
+	var groupBy []Expr
+	if p.matchKw("group") {
+		if err := p.expect("by"); err != nil {
+			return nil, err
+		}
+		e, err := p.parseFieldRef(plan.Descriptor())
+		if err != nil {
+			return nil, err
+		}
+		groupBy = append(groupBy, e)
+	}
+	plan, err = p.applySelectList(plan, star, items, groupBy)
+	if err != nil {
+		return nil, err
+	}
+	if p.matchKw("order") {
+		if err := p.expect("by"); err != nil {
+			return nil, err
+		}
+		e, err := p.parseFieldRef(plan.Descriptor())
+		if err != nil {
+			return nil, err
+		}
+		asc := true
+		if p.matchKw("desc") {
+			asc = false
+		} else {
+			p.matchKw("asc")
+		}
+		plan, err = NewOrderBy([]Expr{e}, plan, []bool{asc})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.matchKw("limit") {
+		n, err := strconv.ParseInt(p.next(), 10, 64)
+		if err != nil {
+			return nil, GoDBError{ParseError, "LIMIT requires an integer"}
+		}
+		plan = NewLimitOp(NewConstExpr(IntField{n}, IntType), plan)
+	}
+	return plan, nil
+}
+
+func (p *sqlParser) parseSelectItem() (selectItem, error) {
+	name := p.next()
+	if name == "" {
+		return selectItem{}, GoDBError{ParseError, "unexpected end of select list"}
+	}
+	var item selectItem
+	switch strings.ToLower(name) {
+	case "count", "sum", "avg", "min", "max":
+		if p.peek() == "(" {
+			p.next()
+			item.agg = strings.ToLower(name)
+			item.field = p.next()
+			if err := p.expect(")"); err != nil {
+				return selectItem{}, err
+			}
+		} else {
+			item.field = name
+		}
+	default:
+		item.field = name
+	}
+	if p.matchKw("as") {
+		item.alias = p.next()
+	}
+	return item, nil
+}
+
+// parseFieldRef reads a (possibly table-qualified) field name and resolves
+// it against the supplied descriptors.
+func (p *sqlParser) parseFieldRef(descs ...*TupleDesc) (Expr, error) {
+	name := p.next()
+	qualifier := ""
+	if p.peek() == "." {
+		p.next()
+		qualifier = name
+		name = p.next()
+	}
+	ref := FieldType{Fname: name, TableQualifier: qualifier}
+	for _, d := range descs {
+		if idx, err := findFieldInTd(ref, d); err == nil {
+			return NewFieldExpr(d.Fields[idx]), nil
+		}
+	}
+	return nil, GoDBError{FieldNotFoundError, fmt.Sprintf("field %s not found", name)}
+}
+
+// parseWhereClause parses one `field op literal` comparison and wraps plan
+// in the corresponding filter.
+func (p *sqlParser) parseWhereClause(plan Operator) (Operator, error) {
+	left, err := p.parseFieldRef(plan.Descriptor())
+	if err != nil {
+		return nil, err
+	}
+	var op BoolOp
+	switch p.next() {
+	case "=":
+		op = OpEq
+	case "<":
+		op = OpLt
+	case ">":
+		op = OpGt
+	case "<=":
+		op = OpLe
+	case ">=":
+		op = OpGe
+	case "!=":
+		op = OpNeq
+	default:
+		return nil, GoDBError{ParseError, "expected comparison operator"}
+	}
+	right, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	// Predicates on a partitioned table's key scan only the partitions that
+	// can match; the exact filter stays on top.
+	if pf, ok := plan.(*partitionedFile); ok {
+		if fe, ok := left.(*FieldExpr); ok && fe.selectField.Fname == pf.keyCol {
+			if ce, ok := right.(*ConstExpr); ok {
+				if parts := pf.prunedPartitions(op, ce.val); parts != nil {
+					return NewFilter(right, op, left, &partitionScan{file: pf, parts: parts})
+				}
+			}
+		}
+	}
+	// Equality predicates directly over a base table use a hash index when
+	// one exists on the column.
+	if op == OpEq {
+		if file, ok := plan.(DBFile); ok {
+			if fe, ok := left.(*FieldExpr); ok {
+				if idx := hashIndexFor(file, fe.selectField.Fname); idx != nil {
+					if ce, ok := right.(*ConstExpr); ok {
+						return NewIndexScan(idx, ce.val), nil
+					}
+				}
+				// Without an index, a Bloom-filtered scan can still skip
+				// pages; the exact filter stays on top.
+				inner := file
+				if cf, ok := inner.(*constrainedFile); ok {
+					inner = cf.DBFile
+				}
+				if hf, ok := inner.(*HeapFile); ok && hf.bloomFor(fe.selectField.Fname) != nil {
+					if ce, ok := right.(*ConstExpr); ok {
+						scan, err := NewBloomScan(hf, fe.selectField.Fname, ce.val)
+						if err == nil {
+							return NewFilter(right, op, left, scan)
+						}
+					}
+				}
+			}
+		}
+	}
+	return NewFilter(right, op, left, plan)
+}
+
+func (p *sqlParser) parseLiteral() (Expr, error) {
+	tok := p.next()
+	if tok == "?" || strings.HasPrefix(tok, "$") {
+		return p.parsePlaceholder(tok)
+	}
+	if strings.HasPrefix(tok, "'") {
+		return NewConstExpr(StringField{strings.Trim(tok, "'")}, StringType), nil
+	}
+	v, err := strconv.ParseInt(tok, 10, 64)
+	if err != nil {
+		return nil, GoDBError{ParseError, fmt.Sprintf("expected literal, got %q", tok)}
+	}
+	return NewConstExpr(IntField{v}, IntType), nil
+}
+
+// applySelectList wraps plan in the projection or aggregation implied by
+// the select list.
+func (p *sqlParser) applySelectList(plan Operator, star bool, items []selectItem, groupBy []Expr) (Operator, error) {
+	if star {
+		if len(groupBy) > 0 {
+			return nil, GoDBError{ParseError, "SELECT * cannot be combined with GROUP BY"}
+		}
+		return plan, nil
+	}
+	hasAgg := false
+	for _, it := range items {
+		if it.agg != "" {
+			hasAgg = true
+		}
+	}
+	if !hasAgg {
+		if len(groupBy) > 0 {
+			return nil, GoDBError{ParseError, "GROUP BY requires an aggregate in the select list"}
+		}
+		// If a composite index covers every projected column, answer from
+		// the index alone instead of scanning the base file.
+		if file, ok := plan.(DBFile); ok {
+			names := make([]string, len(items))
+			for i, it := range items {
+				names[i] = it.field
+			}
+			if ci := compositeIndexCovering(file, names); ci != nil {
+				plan = NewIndexOnlyScan(ci, nil)
+			}
+		}
+		exprs := make([]Expr, len(items))
+		names := make([]string, len(items))
+		for i, it := range items {
+			idx, err := findFieldInTd(FieldType{Fname: it.field}, plan.Descriptor())
+			if err != nil {
+				return nil, err
+			}
+			exprs[i] = NewFieldExpr(plan.Descriptor().Fields[idx])
+			names[i] = it.field
+			if it.alias != "" {
+				names[i] = it.alias
+			}
+		}
+		return NewProjectOp(exprs, names, false, plan)
+	}
+	var states []AggState
+	for _, it := range items {
+		if it.agg == "" {
+			// Non-aggregated fields must match the GROUP BY expression and
+			// are produced by the aggregator itself.
+			continue
+		}
+		var expr Expr
+		if it.field == "*" {
+			if it.agg != "count" {
+				return nil, GoDBError{ParseError, fmt.Sprintf("%s(*) is not supported", it.agg)}
+			}
+			expr = NewConstExpr(IntField{1}, IntType)
+		} else {
+			idx, err := findFieldInTd(FieldType{Fname: it.field}, plan.Descriptor())
+			if err != nil {
+				return nil, err
+			}
+			expr = NewFieldExpr(plan.Descriptor().Fields[idx])
+		}
+		alias := it.alias
+		if alias == "" {
+			alias = it.agg
+		}
+		var state AggState
+		switch it.agg {
+		case "count":
+			state = &CountAggState{}
+		case "sum":
+			state = &SumAggState{}
+		case "avg":
+			state = &AvgAggState{}
+		case "min":
+			state = &MinAggState{}
+		case "max":
+			state = &MaxAggState{}
+		}
+		if err := state.Init(alias, expr); err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	if len(groupBy) > 0 {
+		return NewGroupedAggregator(states, groupBy, plan), nil
+	}
+	return NewAggregator(states, plan), nil
+}
@@ -0,0 +1,290 @@
+package godb
+
+import (
+	"fmt"
+)
+
+// Partitioned tables. A partitioned table looks like a single DBFile but
+// stores its rows in several underlying heap files, one per partition.
+// insertTuple routes each row by the partition key — by range over sorted
+// upper bounds, or by hash — and scans over a key predicate prune the
+// partitions that cannot match, so both ingest and selective queries touch
+// fewer pages. Each partition lives in its own file (name.p0.dat,
+// name.p1.dat, ...), so partitions can also be loaded in parallel.
+
+type partitionKind int
+
+const (
+	rangePartitioned partitionKind = iota
+	hashPartitioned
+)
+
+// partitionedFile is the DBFile facade over a table's partitions.
+type partitionedFile struct {
+	name   string
+	desc   *TupleDesc
+	keyCol string
+	kind   partitionKind
+	// bounds are the ascending exclusive upper bounds of the first
+	// len(bounds) range partitions; the last partition takes the rest.
+	bounds []int64
+	parts  []DBFile
+}
+
+// partitionRid wraps a partition-local record id with its partition index
+// so deletes can be routed back.
+type partitionRid struct {
+	part  int
+	inner any
+}
+
+// AddRangePartitionedTable creates a table partitioned on the int column
+// keyCol by range: row i goes to the first partition whose bound exceeds
+// its key, or to a final catch-all partition. len(bounds)+1 partitions are
+// created; bounds must be ascending.
+func (c *Catalog) AddRangePartitionedTable(name string, desc TupleDesc, keyCol string, bounds []int64) (DBFile, error) {
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i] <= bounds[i-1] {
+			return nil, GoDBError{IllegalOperationError, "partition bounds must be ascending"}
+		}
+	}
+	return c.addPartitionedTable(name, desc, keyCol, rangePartitioned, bounds, len(bounds)+1)
+}
+
+// AddHashPartitionedTable creates a table partitioned on keyCol into
+// numPartitions by hash.
+func (c *Catalog) AddHashPartitionedTable(name string, desc TupleDesc, keyCol string, numPartitions int) (DBFile, error) {
+	if numPartitions < 1 {
+		return nil, GoDBError{IllegalOperationError, "need at least one partition"}
+	}
+	return c.addPartitionedTable(name, desc, keyCol, hashPartitioned, nil, numPartitions)
+}
+
+func (c *Catalog) addPartitionedTable(name string, desc TupleDesc, keyCol string, kind partitionKind, bounds []int64, n int) (DBFile, error) {
+	if _, ok := c.tables[name]; ok {
+		return nil, GoDBError{DuplicateNameError, fmt.Sprintf("table %s already exists", name)}
+	}
+	for i := range desc.Fields {
+		desc.Fields[i].TableQualifier = name
+	}
+	if _, err := findFieldInTd(FieldType{Fname: keyCol}, &desc); err != nil {
+		return nil, err
+	}
+	pf := &partitionedFile{name: name, desc: desc.copy(), keyCol: keyCol, kind: kind, bounds: bounds}
+	for i := 0; i < n; i++ {
+		path := c.tableFilePath(fmt.Sprintf("%s.p%d", name, i))
+		hf, err := NewHeapFile(path, desc.copy(), c.bufPool)
+		if err != nil {
+			return nil, err
+		}
+		pf.parts = append(pf.parts, hf)
+	}
+	c.tables[name] = pf
+	return pf, nil
+}
+
+func (f *partitionedFile) Descriptor() *TupleDesc {
+	return f.desc
+}
+
+// partitionFor returns the index of the partition t's key routes to.
+func (f *partitionedFile) partitionFor(t *Tuple) (int, error) {
+	idx, err := findFieldInTd(FieldType{Fname: f.keyCol}, &t.Desc)
+	if err != nil {
+		return 0, err
+	}
+	switch f.kind {
+	case rangePartitioned:
+		iv, ok := t.Fields[idx].(IntField)
+		if !ok {
+			return 0, GoDBError{TypeMismatchError, "range partition key must be an int column"}
+		}
+		return f.rangePartition(iv.Value), nil
+	default:
+		return hashPartition(t.Fields[idx], len(f.parts)), nil
+	}
+}
+
+// rangePartition returns the partition index for key v.
+func (f *partitionedFile) rangePartition(v int64) int {
+	for i, b := range f.bounds {
+		if v < b {
+			return i
+		}
+	}
+	return len(f.bounds)
+}
+
+// hashPartition buckets v into one of n partitions.
+func hashPartition(v DBValue, n int) int {
+	var h uint64 = 14695981039346656037
+	mix := func(b byte) {
+		h ^= uint64(b)
+		h *= 1099511628211
+	}
+	switch x := v.(type) {
+	case IntField:
+		for i := 0; i < 8; i++ {
+			mix(byte(x.Value >> (8 * i)))
+		}
+	case StringField:
+		for i := 0; i < len(x.Value); i++ {
+			mix(x.Value[i])
+		}
+	}
+	return int(h % uint64(n))
+}
+
+func (f *partitionedFile) insertTuple(t *Tuple, tid TransactionID) error {
+	p, err := f.partitionFor(t)
+	if err != nil {
+		return err
+	}
+	if err := f.parts[p].insertTuple(t, tid); err != nil {
+		return err
+	}
+	t.Rid = partitionRid{part: p, inner: t.Rid}
+	return nil
+}
+
+func (f *partitionedFile) deleteTuple(t *Tuple, tid TransactionID) error {
+	rid, ok := t.Rid.(partitionRid)
+	if !ok {
+		return GoDBError{TupleNotFoundError, "tuple has no partition record id"}
+	}
+	inner := *t
+	inner.Rid = rid.inner
+	return f.parts[rid.part].deleteTuple(&inner, tid)
+}
+
+func (f *partitionedFile) readPage(pageNo int) (Page, error) {
+	return nil, GoDBError{IllegalOperationError, "partitioned tables have no pages of their own"}
+}
+
+func (f *partitionedFile) flushPage(p Page) error {
+	return GoDBError{IllegalOperationError, "partitioned tables have no pages of their own"}
+}
+
+func (f *partitionedFile) pageKey(pgNo int) any {
+	return f.parts[0].pageKey(pgNo)
+}
+
+// NumPages returns the total page count across partitions.
+func (f *partitionedFile) NumPages() int {
+	n := 0
+	for _, p := range f.parts {
+		n += p.NumPages()
+	}
+	return n
+}
+
+// Iterator scans every partition in order, tagging record ids with their
+// partition so deletes route correctly.
+func (f *partitionedFile) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	return f.iterateParts(tid, nil)
+}
+
+// iterateParts scans the partitions whose indexes appear in parts (all of
+// them when parts is nil).
+func (f *partitionedFile) iterateParts(tid TransactionID, parts []int) (func() (*Tuple, error), error) {
+	if parts == nil {
+		parts = make([]int, len(f.parts))
+		for i := range parts {
+			parts[i] = i
+		}
+	}
+	i := 0
+	var iter func() (*Tuple, error)
+	return func() (*Tuple, error) {
+		for {
+			if iter == nil {
+				if i >= len(parts) {
+					return nil, nil
+				}
+				var err error
+				iter, err = f.parts[parts[i]].Iterator(tid)
+				if err != nil {
+					return nil, err
+				}
+			}
+			t, err := iter()
+			if err != nil {
+				return nil, err
+			}
+			if t != nil {
+				wrapped := *t
+				wrapped.Rid = partitionRid{part: parts[i], inner: t.Rid}
+				return &wrapped, nil
+			}
+			iter = nil
+			i++
+		}
+	}, nil
+}
+
+// prunedPartitions returns the partitions that can hold rows satisfying
+// `keyCol op v`, or nil if every partition must be scanned.
+func (f *partitionedFile) prunedPartitions(op BoolOp, v DBValue) []int {
+	switch f.kind {
+	case hashPartitioned:
+		if op != OpEq {
+			return nil
+		}
+		return []int{hashPartition(v, len(f.parts))}
+	case rangePartitioned:
+		iv, ok := v.(IntField)
+		if !ok {
+			return nil
+		}
+		var parts []int
+		for i := range f.parts {
+			if f.rangeOverlaps(i, op, iv.Value) {
+				parts = append(parts, i)
+			}
+		}
+		return parts
+	}
+	return nil
+}
+
+// rangeOverlaps reports whether range partition i can contain a key
+// satisfying `key op v`.
+func (f *partitionedFile) rangeOverlaps(i int, op BoolOp, v int64) bool {
+	// Partition i holds keys in [lo, hi); the first has no lower bound and
+	// the last no upper bound.
+	hasLo, hasHi := i > 0, i < len(f.bounds)
+	var lo, hi int64
+	if hasLo {
+		lo = f.bounds[i-1]
+	}
+	if hasHi {
+		hi = f.bounds[i]
+	}
+	switch op {
+	case OpEq:
+		return (!hasLo || v >= lo) && (!hasHi || v < hi)
+	case OpLt:
+		return !hasLo || lo < v
+	case OpLe:
+		return !hasLo || lo <= v
+	case OpGt, OpGe:
+		return !hasHi || v < hi
+	case OpNeq:
+		return true
+	}
+	return true
+}
+
+// partitionScan is the operator form of a pruned partition scan.
+type partitionScan struct {
+	file  *partitionedFile
+	parts []int
+}
+
+func (s *partitionScan) Descriptor() *TupleDesc {
+	return s.file.desc
+}
+
+func (s *partitionScan) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	return s.file.iterateParts(tid, s.parts)
+}
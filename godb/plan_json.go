@@ -0,0 +1,490 @@
+package godb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Query plan serialization. SerializePlan renders an operator tree as
+// JSON and DeserializePlan rebuilds it against a catalog, so plans can be
+// logged, cached across restarts, or shipped to remote workers. The
+// format covers the core relational operators — scans, filters,
+// projections, equality joins, aggregates, order by, limit — and the
+// field/const/arith expressions; operators or expressions outside that
+// set fail serialization with a descriptive error rather than producing a
+// plan that cannot be rebuilt. Table scans are recorded by name (taken
+// from the descriptor's table qualifier) and resolved through the
+// catalog on the way back in, so the deserialized plan reads whatever
+// that catalog's table holds.
+
+// planNode is the JSON form of one operator.
+type planNode struct {
+	Op    string    `json:"op"`
+	Table string    `json:"table,omitempty"`
+	Child *planNode `json:"child,omitempty"`
+	Left  *planNode `json:"left,omitempty"`
+	Right *planNode `json:"right,omitempty"`
+	// Filter predicate and join fields.
+	LeftExpr  *exprNode `json:"left_expr,omitempty"`
+	RightExpr *exprNode `json:"right_expr,omitempty"`
+	BoolOp    string    `json:"bool_op,omitempty"`
+	MaxBuffer int       `json:"max_buffer,omitempty"`
+	// Projection.
+	Exprs    []*exprNode `json:"exprs,omitempty"`
+	Names    []string    `json:"names,omitempty"`
+	Distinct bool        `json:"distinct,omitempty"`
+	// Aggregation.
+	Aggs    []aggNode   `json:"aggs,omitempty"`
+	GroupBy []*exprNode `json:"group_by,omitempty"`
+	// Order by and limit.
+	Ascending []bool    `json:"ascending,omitempty"`
+	Limit     *exprNode `json:"limit,omitempty"`
+}
+
+// exprNode is the JSON form of one expression.
+type exprNode struct {
+	Kind  string     `json:"kind"` // "field", "const", or "arith"
+	Field *FieldType `json:"field,omitempty"`
+	// Const value: exactly one of Int or Str is meaningful, per Type.
+	Type string `json:"type,omitempty"`
+	Int  int64  `json:"int,omitempty"`
+	Str  string `json:"str,omitempty"`
+	// Arith.
+	ArithOp string    `json:"arith_op,omitempty"`
+	Left    *exprNode `json:"left,omitempty"`
+	Right   *exprNode `json:"right,omitempty"`
+}
+
+// aggNode is the JSON form of one aggregate.
+type aggNode struct {
+	Kind  string    `json:"kind"` // "count", "sum", "avg", "min", "max"
+	Alias string    `json:"alias"`
+	Expr  *exprNode `json:"expr,omitempty"`
+}
+
+// SerializePlan renders op as JSON.
+func SerializePlan(op Operator) ([]byte, error) {
+	node, err := encodePlan(op)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(node, "", "  ")
+}
+
+// DeserializePlan rebuilds a plan serialized with SerializePlan,
+// resolving table scans through c.
+func DeserializePlan(c *Catalog, data []byte) (Operator, error) {
+	var node planNode
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("bad plan JSON: %v", err)}
+	}
+	return decodePlan(c, &node)
+}
+
+func encodePlan(op Operator) (*planNode, error) {
+	switch o := op.(type) {
+	case DBFile:
+		desc := o.Descriptor()
+		if len(desc.Fields) == 0 || desc.Fields[0].TableQualifier == "" {
+			return nil, GoDBError{IllegalOperationError, "cannot serialize a scan of a table with no name; register it in a catalog first"}
+		}
+		return &planNode{Op: "scan", Table: desc.Fields[0].TableQualifier}, nil
+	case *Filter:
+		child, err := encodePlan(o.child)
+		if err != nil {
+			return nil, err
+		}
+		le, err := encodeExpr(o.left)
+		if err != nil {
+			return nil, err
+		}
+		re, err := encodeExpr(o.right)
+		if err != nil {
+			return nil, err
+		}
+		return &planNode{Op: "filter", Child: child, LeftExpr: le, RightExpr: re, BoolOp: boolOpName(o.op)}, nil
+	case *Project:
+		child, err := encodePlan(o.child)
+		if err != nil {
+			return nil, err
+		}
+		exprs, err := encodeExprs(o.selectFields)
+		if err != nil {
+			return nil, err
+		}
+		return &planNode{Op: "project", Child: child, Exprs: exprs, Names: o.outputNames, Distinct: o.distinct}, nil
+	case *EqualityJoin:
+		left, err := encodePlan(*o.left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := encodePlan(*o.right)
+		if err != nil {
+			return nil, err
+		}
+		le, err := encodeExpr(o.leftField)
+		if err != nil {
+			return nil, err
+		}
+		re, err := encodeExpr(o.rightField)
+		if err != nil {
+			return nil, err
+		}
+		return &planNode{Op: "join", Left: left, Right: right, LeftExpr: le, RightExpr: re, MaxBuffer: o.maxBufferSize}, nil
+	case *Aggregator:
+		child, err := encodePlan(o.child)
+		if err != nil {
+			return nil, err
+		}
+		groupBy, err := encodeExprs(o.groupByFields)
+		if err != nil {
+			return nil, err
+		}
+		aggs := make([]aggNode, len(o.newAggState))
+		for i, st := range o.newAggState {
+			n, err := encodeAgg(st)
+			if err != nil {
+				return nil, err
+			}
+			aggs[i] = n
+		}
+		return &planNode{Op: "agg", Child: child, Aggs: aggs, GroupBy: groupBy}, nil
+	case *OrderBy:
+		child, err := encodePlan(o.child)
+		if err != nil {
+			return nil, err
+		}
+		exprs, err := encodeExprs(o.orderBy)
+		if err != nil {
+			return nil, err
+		}
+		return &planNode{Op: "order_by", Child: child, Exprs: exprs, Ascending: o.ascending}, nil
+	case *LimitOp:
+		child, err := encodePlan(o.child)
+		if err != nil {
+			return nil, err
+		}
+		limit, err := encodeExpr(o.limitTups)
+		if err != nil {
+			return nil, err
+		}
+		return &planNode{Op: "limit", Child: child, Limit: limit}, nil
+	}
+	return nil, GoDBError{IllegalOperationError, fmt.Sprintf("operator %T is not serializable", op)}
+}
+
+func decodePlan(c *Catalog, n *planNode) (Operator, error) {
+	switch n.Op {
+	case "scan":
+		return c.GetTable(n.Table)
+	case "filter":
+		child, err := decodePlan(c, n.Child)
+		if err != nil {
+			return nil, err
+		}
+		le, err := decodeExpr(n.LeftExpr)
+		if err != nil {
+			return nil, err
+		}
+		re, err := decodeExpr(n.RightExpr)
+		if err != nil {
+			return nil, err
+		}
+		op, err := boolOpFromName(n.BoolOp)
+		if err != nil {
+			return nil, err
+		}
+		return NewFilter(re, op, le, child)
+	case "project":
+		child, err := decodePlan(c, n.Child)
+		if err != nil {
+			return nil, err
+		}
+		exprs, err := decodeExprs(n.Exprs)
+		if err != nil {
+			return nil, err
+		}
+		return NewProjectOp(exprs, n.Names, n.Distinct, child)
+	case "join":
+		left, err := decodePlan(c, n.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := decodePlan(c, n.Right)
+		if err != nil {
+			return nil, err
+		}
+		le, err := decodeExpr(n.LeftExpr)
+		if err != nil {
+			return nil, err
+		}
+		re, err := decodeExpr(n.RightExpr)
+		if err != nil {
+			return nil, err
+		}
+		return NewJoin(left, le, right, re, n.MaxBuffer)
+	case "agg":
+		child, err := decodePlan(c, n.Child)
+		if err != nil {
+			return nil, err
+		}
+		states := make([]AggState, len(n.Aggs))
+		for i, a := range n.Aggs {
+			st, err := decodeAgg(a)
+			if err != nil {
+				return nil, err
+			}
+			states[i] = st
+		}
+		if len(n.GroupBy) == 0 {
+			return NewAggregator(states, child), nil
+		}
+		groupBy, err := decodeExprs(n.GroupBy)
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupedAggregator(states, groupBy, child), nil
+	case "order_by":
+		child, err := decodePlan(c, n.Child)
+		if err != nil {
+			return nil, err
+		}
+		exprs, err := decodeExprs(n.Exprs)
+		if err != nil {
+			return nil, err
+		}
+		return NewOrderBy(exprs, child, n.Ascending)
+	case "limit":
+		child, err := decodePlan(c, n.Child)
+		if err != nil {
+			return nil, err
+		}
+		limit, err := decodeExpr(n.Limit)
+		if err != nil {
+			return nil, err
+		}
+		return NewLimitOp(limit, child), nil
+	}
+	return nil, GoDBError{MalformedDataError, fmt.Sprintf("unknown plan operator %q", n.Op)}
+}
+
+func encodeExprs(exprs []Expr) ([]*exprNode, error) {
+	out := make([]*exprNode, len(exprs))
+	for i, e := range exprs {
+		n, err := encodeExpr(e)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+func decodeExprs(nodes []*exprNode) ([]Expr, error) {
+	out := make([]Expr, len(nodes))
+	for i, n := range nodes {
+		e, err := decodeExpr(n)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = e
+	}
+	return out, nil
+}
+
+func encodeExpr(e Expr) (*exprNode, error) {
+	switch x := e.(type) {
+	case *FieldExpr:
+		ft := x.selectField
+		return &exprNode{Kind: "field", Field: &ft}, nil
+	case *ConstExpr:
+		n := &exprNode{Kind: "const"}
+		switch v := x.val.(type) {
+		case IntField:
+			n.Type = "int"
+			n.Int = v.Value
+		case StringField:
+			n.Type = "string"
+			n.Str = v.Value
+		default:
+			return nil, GoDBError{IllegalOperationError, fmt.Sprintf("constant %T is not serializable", x.val)}
+		}
+		return n, nil
+	case *ArithExpr:
+		left, err := encodeExpr(x.left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := encodeExpr(x.right)
+		if err != nil {
+			return nil, err
+		}
+		return &exprNode{Kind: "arith", ArithOp: arithOpName(x.op), Left: left, Right: right}, nil
+	}
+	return nil, GoDBError{IllegalOperationError, fmt.Sprintf("expression %T is not serializable", e)}
+}
+
+func decodeExpr(n *exprNode) (Expr, error) {
+	if n == nil {
+		return nil, GoDBError{MalformedDataError, "missing expression in plan"}
+	}
+	switch n.Kind {
+	case "field":
+		if n.Field == nil {
+			return nil, GoDBError{MalformedDataError, "field expression without a field"}
+		}
+		return NewFieldExpr(*n.Field), nil
+	case "const":
+		switch n.Type {
+		case "int":
+			return NewConstExpr(IntField{n.Int}, IntType), nil
+		case "string":
+			return NewConstExpr(StringField{n.Str}, StringType), nil
+		}
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("unknown constant type %q", n.Type)}
+	case "arith":
+		left, err := decodeExpr(n.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := decodeExpr(n.Right)
+		if err != nil {
+			return nil, err
+		}
+		op, err := arithOpFromName(n.ArithOp)
+		if err != nil {
+			return nil, err
+		}
+		return NewArithExpr(left, op, right), nil
+	}
+	return nil, GoDBError{MalformedDataError, fmt.Sprintf("unknown expression kind %q", n.Kind)}
+}
+
+func encodeAgg(st AggState) (aggNode, error) {
+	switch a := st.(type) {
+	case *CountAggState:
+		return aggEncode("count", a.alias, a.expr)
+	case *SumAggState:
+		return aggEncode("sum", a.alias, a.expr)
+	case *AvgAggState:
+		return aggEncode("avg", a.alias, a.expr)
+	case *MinAggState:
+		return aggEncode("min", a.alias, a.expr)
+	case *MaxAggState:
+		return aggEncode("max", a.alias, a.expr)
+	}
+	return aggNode{}, GoDBError{IllegalOperationError, fmt.Sprintf("aggregate %T is not serializable", st)}
+}
+
+func aggEncode(kind, alias string, expr Expr) (aggNode, error) {
+	n := aggNode{Kind: kind, Alias: alias}
+	if expr != nil {
+		e, err := encodeExpr(expr)
+		if err != nil {
+			return aggNode{}, err
+		}
+		n.Expr = e
+	}
+	return n, nil
+}
+
+func decodeAgg(n aggNode) (AggState, error) {
+	var st AggState
+	switch n.Kind {
+	case "count":
+		st = &CountAggState{}
+	case "sum":
+		st = &SumAggState{}
+	case "avg":
+		st = &AvgAggState{}
+	case "min":
+		st = &MinAggState{}
+	case "max":
+		st = &MaxAggState{}
+	default:
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("unknown aggregate kind %q", n.Kind)}
+	}
+	var expr Expr
+	if n.Expr != nil {
+		var err error
+		if expr, err = decodeExpr(n.Expr); err != nil {
+			return nil, err
+		}
+	}
+	if err := st.Init(n.Alias, expr); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func boolOpName(op BoolOp) string {
+	switch op {
+	case OpGt:
+		return "gt"
+	case OpLt:
+		return "lt"
+	case OpGe:
+		return "ge"
+	case OpLe:
+		return "le"
+	case OpEq:
+		return "eq"
+	case OpNeq:
+		return "neq"
+	case OpNotDistinct:
+		return "not_distinct"
+	}
+	return "unknown"
+}
+
+func boolOpFromName(name string) (BoolOp, error) {
+	switch name {
+	case "gt":
+		return OpGt, nil
+	case "lt":
+		return OpLt, nil
+	case "ge":
+		return OpGe, nil
+	case "le":
+		return OpLe, nil
+	case "eq":
+		return OpEq, nil
+	case "neq":
+		return OpNeq, nil
+	case "not_distinct":
+		return OpNotDistinct, nil
+	}
+	return 0, GoDBError{MalformedDataError, fmt.Sprintf("unknown comparison op %q", name)}
+}
+
+func arithOpName(op ArithOp) string {
+	switch op {
+	case OpAdd:
+		return "add"
+	case OpSub:
+		return "sub"
+	case OpMul:
+		return "mul"
+	case OpDiv:
+		return "div"
+	case OpMod:
+		return "mod"
+	}
+	return "unknown"
+}
+
+func arithOpFromName(name string) (ArithOp, error) {
+	switch name {
+	case "add":
+		return OpAdd, nil
+	case "sub":
+		return OpSub, nil
+	case "mul":
+		return OpMul, nil
+	case "div":
+		return OpDiv, nil
+	case "mod":
+		return OpMod, nil
+	}
+	return 0, GoDBError{MalformedDataError, fmt.Sprintf("unknown arithmetic op %q", name)}
+}
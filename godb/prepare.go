@@ -0,0 +1,121 @@
+package godb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PreparedQuery is a query compiled once by Prepare and executed many
+// times with different parameter bindings, avoiding re-parsing and
+// re-planning for hot statements. Placeholders are written ? (bound in
+// order) or $1, $2, ... (bound by position); both styles map onto the
+// positional parameters of Iterator. A PreparedQuery may be reused
+// sequentially but not executed concurrently with itself, matching the
+// per-connection statement discipline of database/sql drivers.
+type PreparedQuery struct {
+	query   string
+	plan    Operator
+	nParams int
+	bound   []DBValue
+}
+
+// Prepare compiles query into a reusable plan, returning a cached
+// PreparedQuery if the identical statement was prepared before.
+func (c *Catalog) Prepare(query string) (*PreparedQuery, error) {
+	key := strings.Join(strings.Fields(query), " ")
+	c.preparedMu.Lock()
+	if pq, ok := c.prepared[key]; ok {
+		c.preparedMu.Unlock()
+		return pq, nil
+	}
+	c.preparedMu.Unlock()
+	toks, err := lexSQL(query)
+	if err != nil {
+		return nil, err
+	}
+	pq := &PreparedQuery{query: query}
+	p := &sqlParser{catalog: c, toks: toks, prepared: pq}
+	op, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, GoDBError{ParseError, fmt.Sprintf("unexpected token %q", p.peek())}
+	}
+	pq.plan = op
+	pq.bound = make([]DBValue, pq.nParams)
+	c.preparedMu.Lock()
+	defer c.preparedMu.Unlock()
+	if cached, ok := c.prepared[key]; ok {
+		return cached, nil
+	}
+	if c.prepared == nil {
+		c.prepared = make(map[string]*PreparedQuery)
+	}
+	c.prepared[key] = pq
+	return pq, nil
+}
+
+// NumParams returns the number of placeholders in the statement.
+func (pq *PreparedQuery) NumParams() int {
+	return pq.nParams
+}
+
+// Descriptor returns the schema of the statement's result.
+func (pq *PreparedQuery) Descriptor() *TupleDesc {
+	return pq.plan.Descriptor()
+}
+
+// Iterator binds params and returns an iterator over the statement's
+// results under tid. The bindings stay in effect until the next call.
+func (pq *PreparedQuery) Iterator(tid TransactionID, params ...DBValue) (func() (*Tuple, error), error) {
+	if len(params) != pq.nParams {
+		return nil, GoDBError{ParseError, fmt.Sprintf("statement has %d parameters, got %d", pq.nParams, len(params))}
+	}
+	copy(pq.bound, params)
+	return pq.plan.Iterator(tid)
+}
+
+// paramExpr is a placeholder in a prepared statement; it evaluates to the
+// value currently bound at its position.
+type paramExpr struct {
+	pq  *PreparedQuery
+	idx int
+}
+
+func (e *paramExpr) EvalExpr(t *Tuple) (DBValue, error) {
+	v := e.pq.bound[e.idx]
+	if v == nil {
+		return nil, GoDBError{IllegalOperationError, fmt.Sprintf("parameter %d is not bound", e.idx+1)}
+	}
+	return v, nil
+}
+
+func (e *paramExpr) GetExprType() FieldType {
+	ftype := IntType
+	if _, ok := e.pq.bound[e.idx].(StringField); ok {
+		ftype = StringType
+	}
+	return FieldType{Fname: fmt.Sprintf("$%d", e.idx+1), Ftype: ftype}
+}
+
+// parsePlaceholder turns a ? or $N token into a paramExpr, growing the
+// statement's parameter count as needed.
+func (p *sqlParser) parsePlaceholder(tok string) (Expr, error) {
+	if p.prepared == nil {
+		return nil, GoDBError{ParseError, "placeholders are only allowed in prepared statements"}
+	}
+	idx := p.prepared.nParams
+	if tok != "?" {
+		n, err := strconv.Atoi(tok[1:])
+		if err != nil || n < 1 {
+			return nil, GoDBError{ParseError, fmt.Sprintf("bad placeholder %q", tok)}
+		}
+		idx = n - 1
+	}
+	if idx >= p.prepared.nParams {
+		p.prepared.nParams = idx + 1
+	}
+	return &paramExpr{pq: p.prepared, idx: idx}, nil
+}
@@ -0,0 +1,103 @@
+package godb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Query progress reporting. Wrapping the nodes of an operator tree in a
+// QueryProgress attaches runtime counters — tuples produced, iterator
+// calls, time spent inside the node — that are safe to read while the
+// query is still running (a monitoring goroutine polling Snapshot) and
+// afterwards, when String formats them EXPLAIN ANALYZE style for
+// diagnosing slow queries. Time in a node includes its children, as in
+// most EXPLAIN ANALYZE outputs.
+
+// OpStats is a point-in-time snapshot of one wrapped operator's counters.
+type OpStats struct {
+	Name    string
+	Tuples  int64
+	Calls   int64
+	Elapsed time.Duration
+}
+
+// QueryProgress collects counters for the nodes of one query.
+type QueryProgress struct {
+	mu    sync.Mutex
+	nodes []*progressNode
+}
+
+// progressNode carries one operator's counters; fields are updated with
+// atomics so readers never block the query.
+type progressNode struct {
+	name   string
+	child  Operator
+	tuples atomic.Int64
+	calls  atomic.Int64
+	nanos  atomic.Int64
+}
+
+// NewQueryProgress returns an empty progress collector.
+func NewQueryProgress() *QueryProgress {
+	return &QueryProgress{}
+}
+
+// Wrap returns op instrumented under name; build the plan with wrapped
+// nodes wherever counters are wanted.
+func (p *QueryProgress) Wrap(name string, op Operator) Operator {
+	n := &progressNode{name: name, child: op}
+	p.mu.Lock()
+	p.nodes = append(p.nodes, n)
+	p.mu.Unlock()
+	return n
+}
+
+func (n *progressNode) Descriptor() *TupleDesc {
+	return n.child.Descriptor()
+}
+
+func (n *progressNode) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	iter, err := n.child.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	return func() (*Tuple, error) {
+		start := time.Now()
+		t, err := iter()
+		n.nanos.Add(int64(time.Since(start)))
+		n.calls.Add(1)
+		if t != nil {
+			n.tuples.Add(1)
+		}
+		return t, err
+	}, nil
+}
+
+// Snapshot returns the current counters of every wrapped node, in wrap
+// order; it may be called while the query runs.
+func (p *QueryProgress) Snapshot() []OpStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]OpStats, len(p.nodes))
+	for i, n := range p.nodes {
+		out[i] = OpStats{
+			Name:    n.name,
+			Tuples:  n.tuples.Load(),
+			Calls:   n.calls.Load(),
+			Elapsed: time.Duration(n.nanos.Load()),
+		}
+	}
+	return out
+}
+
+// String formats the counters one node per line, EXPLAIN ANALYZE style.
+func (p *QueryProgress) String() string {
+	var b strings.Builder
+	for _, s := range p.Snapshot() {
+		fmt.Fprintf(&b, "%s: rows=%d calls=%d time=%s\n", s.Name, s.Tuples, s.Calls, s.Elapsed.Round(time.Microsecond))
+	}
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package godb
+
+// Project evaluates a list of select expressions over each tuple of its
+// child, optionally de-duplicating the results (SELECT DISTINCT).
+type Project struct {
+	selectFields []Expr
+	outputNames  []string
+	distinct     bool
+	child        Operator
+}
+
+// NewProjectOp returns a projection producing one output field per select
+// expression, renamed to outputNames.
+func NewProjectOp(selectFields []Expr, outputNames []string, distinct bool, child Operator) (*Project, error) {
+	if len(selectFields) != len(outputNames) {
+		return nil, GoDBError{ParseError, "mismatched select field and output name counts"}
+	}
+	return &Project{selectFields: selectFields, outputNames: outputNames, distinct: distinct, child: child}, nil
+}
+
+func (p *Project) Descriptor() *TupleDesc {
+	fields := make([]FieldType, len(p.selectFields))
+	for i, e := range p.selectFields {
+		ft := e.GetExprType()
+		ft.Fname = p.outputNames[i]
+		fields[i] = ft
+	}
+	return &TupleDesc{Fields: fields}
+}
+
+// pushdownIndexes returns the child field indexes to push into a projected
+// heap scan, or nil when pushdown does not apply (an expression is not a
+// plain column reference, or references a column twice).
+func (p *Project) pushdownIndexes(childDesc *TupleDesc) []int {
+	idxs := make([]int, 0, len(p.selectFields))
+	seen := make(map[int]bool)
+	for _, e := range p.selectFields {
+		fe, ok := e.(*FieldExpr)
+		if !ok {
+			return nil
+		}
+		idx, err := findFieldInTd(fe.selectField, childDesc)
+		if err != nil || seen[idx] {
+			return nil
+		}
+		seen[idx] = true
+		idxs = append(idxs, idx)
+	}
+	return idxs
+}
+
+func (p *Project) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	var childIter func() (*Tuple, error)
+	var err error
+	// When scanning a heap table and selecting plain columns, push the
+	// projection into the scan so only those columns are deserialized. The
+	// narrow tuples keep their field names, so the expressions below still
+	// resolve against them.
+	if hf := heapForPushdown(p.child); hf != nil {
+		if idxs := p.pushdownIndexes(hf.Descriptor()); idxs != nil {
+			childIter, err = hf.ProjectedIterator(idxs, tid)
+		}
+	}
+	if childIter == nil && err == nil {
+		childIter, err = p.child.Iterator(tid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	desc := p.Descriptor()
+	seen := make(map[string]bool)
+	return func() (*Tuple, error) {
+		for {
+			t, err := childIter()
+			if err != nil || t == nil {
+				return nil, err
+			}
+			fields := make([]DBValue, len(p.selectFields))
+			for i, e := range p.selectFields {
+				v, err := e.EvalExpr(t)
+				if err != nil {
+					return nil, err
+				}
+				fields[i] = v
+			}
+			out := &Tuple{Desc: *desc.copy(), Fields: fields, Rid: t.Rid}
+			if p.distinct {
+				key := out.PrettyPrintString()
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			return out, nil
+		}
+	}, nil
+}
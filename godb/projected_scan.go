@@ -0,0 +1,144 @@
+package godb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"sort"
+)
+
+// Projection pushdown for heap scans. A full scan materializes every column
+// of every tuple; for wide tables where a query touches a handful of
+// columns that is mostly wasted work. ProjectedIterator deserializes only
+// the requested field offsets from each fixed-width record, skipping the
+// rest, and Project uses it automatically when its child is a heap table
+// and every select expression is a plain column reference.
+
+// ProjectedIterator returns an iterator like Iterator that produces tuples
+// containing only the fields at the supplied indexes (in schema order) of
+// the current descriptor. Pages still travel through the buffer pool for
+// locking; clean pages at the current schema version are then decoded
+// field-selectively from their on-disk image, which under the pool's
+// no-steal/force policy matches the cached page. Dirty or old-version
+// pages fall back to their materialized tuples.
+func (f *HeapFile) ProjectedIterator(fields []int, tid TransactionID) (func() (*Tuple, error), error) {
+	fields = append([]int(nil), fields...)
+	sort.Ints(fields)
+	narrow := &TupleDesc{}
+	for i, idx := range fields {
+		if idx < 0 || idx >= len(f.desc.Fields) {
+			return nil, GoDBError{FieldNotFoundError, "projected field index out of range"}
+		}
+		if i > 0 && fields[i-1] == idx {
+			return nil, GoDBError{IllegalOperationError, "duplicate projected field index"}
+		}
+		narrow.Fields = append(narrow.Fields, f.desc.Fields[idx])
+	}
+	pgNo := 0
+	var pending []*Tuple
+	return func() (*Tuple, error) {
+		for {
+			if len(pending) > 0 {
+				t := pending[0]
+				pending = pending[1:]
+				metrics.TuplesScanned("heap_scan", 1)
+				return t, nil
+			}
+			if pgNo >= f.NumPages() {
+				return nil, nil
+			}
+			pg, err := f.bufPool.GetPage(f, pgNo, tid, ReadPerm)
+			if err != nil {
+				return nil, err
+			}
+			hp := pg.(*heapPage)
+			if hp.isDirty() || hp.version != f.currentVersion() {
+				pending, err = f.projectMaterialized(hp, fields, narrow)
+			} else {
+				pending, err = f.projectFromDisk(pgNo, narrow, fields)
+			}
+			if err != nil {
+				return nil, err
+			}
+			pgNo++
+		}
+	}, nil
+}
+
+// projectMaterialized projects a page's already-parsed tuples, upgrading
+// old-version tuples to the current schema first.
+func (f *HeapFile) projectMaterialized(hp *heapPage, fields []int, narrow *TupleDesc) ([]*Tuple, error) {
+	hp.latch.RLock()
+	tuples := make([]*Tuple, len(hp.tuples))
+	copy(tuples, hp.tuples)
+	hp.latch.RUnlock()
+	var out []*Tuple
+	for _, t := range tuples {
+		if t == nil {
+			continue
+		}
+		t, err := f.upgradeTuple(t, hp.version)
+		if err != nil {
+			return nil, err
+		}
+		vals := make([]DBValue, len(fields))
+		for i, idx := range fields {
+			vals[i] = t.Fields[idx]
+		}
+		out = append(out, &Tuple{Desc: *narrow.copy(), Fields: vals, Rid: t.Rid})
+	}
+	return out, nil
+}
+
+// projectFromDisk decodes only the requested fields of each occupied slot
+// of page pgNo's on-disk image.
+func (f *HeapFile) projectFromDisk(pgNo int, narrow *TupleDesc, fields []int) ([]*Tuple, error) {
+	file, err := os.Open(f.fileName)
+	if err != nil {
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	buf := make([]byte, PageSize)
+	_, err = file.ReadAt(buf, int64(pgNo)*int64(PageSize))
+	file.Close()
+	if err != nil {
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	if buf, err = f.decodePage(buf); err != nil {
+		return nil, err
+	}
+	b := bytes.NewBuffer(buf[heapPageHeaderSize:])
+	numSlots := int(int32(binary.LittleEndian.Uint32(buf[0:4])))
+	bitmap := make([]byte, (numSlots+7)/8)
+	if _, err := b.Read(bitmap); err != nil {
+		return nil, err
+	}
+	tupleLen := f.desc.bytesPerTuple()
+	var out []*Tuple
+	for i := 0; i < numSlots; i++ {
+		if bitmap[i/8]&(1<<(i%8)) == 0 {
+			b.Next(tupleLen)
+			continue
+		}
+		t, err := readTupleFieldsFrom(b, f.desc, narrow, fields)
+		if err != nil {
+			return nil, err
+		}
+		t.Rid = RecordID{PageNo: pgNo, SlotNo: i}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// heapForPushdown unwraps child to a heap file when projection pushdown is
+// applicable: the table's own file, possibly behind its constraint wrapper.
+func heapForPushdown(child Operator) *HeapFile {
+	switch c := child.(type) {
+	case *HeapFile:
+		return c
+	case *constrainedFile:
+		if hf, ok := c.DBFile.(*HeapFile); ok {
+			return hf
+		}
+	}
+	return nil
+}
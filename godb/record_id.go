@@ -0,0 +1,16 @@
+package godb
+
+import "fmt"
+
+// RecordID identifies the physical location of a tuple: its page number
+// and slot within the page. It is comparable, so it keys maps and
+// compares with == directly; hot paths never parse strings. Column files
+// use the row's position in column 0 as (page, slot).
+type RecordID struct {
+	PageNo int `json:"p"`
+	SlotNo int `json:"s"`
+}
+
+func (r RecordID) String() string {
+	return fmt.Sprintf("%d.%d", r.PageNo, r.SlotNo)
+}
@@ -0,0 +1,188 @@
+package godb
+
+import (
+	"strings"
+	"sync"
+)
+
+// ResultCache memoizes the materialized results of read-only queries.
+// Entries are keyed by a fingerprint of the query text together with the
+// modification versions of every table it references, so a cached result
+// is served only while none of its underlying tables has changed. Total
+// cached bytes are bounded; least-recently-used entries are evicted first.
+// Useful for dashboards that re-run the same aggregates continuously.
+type ResultCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	curBytes int64
+	clock    int64
+	entries  map[string]*cacheEntry
+	hits     int64
+	misses   int64
+}
+
+type cacheEntry struct {
+	rows     []*Tuple
+	bytes    int64
+	versions map[string]int64
+	lastUsed int64
+}
+
+// NewResultCache returns a cache holding at most maxBytes of materialized
+// results.
+func NewResultCache(maxBytes int64) *ResultCache {
+	return &ResultCache{maxBytes: maxBytes, entries: make(map[string]*cacheEntry)}
+}
+
+// Query runs sql against c under tid, returning its full result set. If an
+// identical query was cached and none of its tables has been modified
+// since, the cached rows are returned without touching storage.
+func (rc *ResultCache) Query(c *Catalog, query string, tid TransactionID) ([]*Tuple, error) {
+	key := strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	tables, err := tablesReferenced(c, query, 0)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]int64, len(tables))
+	for _, t := range tables {
+		versions[t] = c.tableVersion(t)
+	}
+	rc.mu.Lock()
+	if e, ok := rc.entries[key]; ok && versionsMatch(e.versions, versions) {
+		rc.clock++
+		e.lastUsed = rc.clock
+		rc.hits++
+		rows := e.rows
+		rc.mu.Unlock()
+		return rows, nil
+	}
+	rc.misses++
+	rc.mu.Unlock()
+	plan, err := ParseQuery(c, query)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := plan.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	var rows []*Tuple
+	var bytes int64
+	for {
+		t, err := iter()
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			break
+		}
+		rows = append(rows, t)
+		bytes += int64(t.Desc.bytesPerTuple())
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if old, ok := rc.entries[key]; ok {
+		rc.curBytes -= old.bytes
+	}
+	rc.clock++
+	rc.entries[key] = &cacheEntry{rows: rows, bytes: bytes, versions: versions, lastUsed: rc.clock}
+	rc.curBytes += bytes
+	rc.evictLocked()
+	return rows, nil
+}
+
+// Stats returns the cache's cumulative hit and miss counts.
+func (rc *ResultCache) Stats() (hits, misses int64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.hits, rc.misses
+}
+
+// evictLocked removes least-recently-used entries until the cache fits its
+// byte budget. Caller holds rc.mu.
+func (rc *ResultCache) evictLocked() {
+	for rc.curBytes > rc.maxBytes && len(rc.entries) > 0 {
+		var oldestKey string
+		var oldest int64 = -1
+		for k, e := range rc.entries {
+			if oldest < 0 || e.lastUsed < oldest {
+				oldest = e.lastUsed
+				oldestKey = k
+			}
+		}
+		rc.curBytes -= rc.entries[oldestKey].bytes
+		delete(rc.entries, oldestKey)
+	}
+}
+
+func versionsMatch(a, b map[string]int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// bumpTableVersion notes a modification to table, invalidating any cached
+// results that depend on it.
+func (c *Catalog) bumpTableVersion(table string) {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+	c.tableVersions[table]++
+}
+
+// tableVersion returns the current modification counter for table.
+func (c *Catalog) tableVersion(table string) int64 {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+	return c.tableVersions[table]
+}
+
+// tablesReferenced extracts the base tables a query reads: identifiers
+// following FROM or JOIN, with views expanded recursively. It also ensures
+// each table is routed through the catalog's constrained wrapper so
+// modifications bump its version counter.
+func tablesReferenced(c *Catalog, query string, depth int) ([]string, error) {
+	if depth > 8 {
+		return nil, GoDBError{ParseError, "view definitions nested too deeply"}
+	}
+	toks, err := lexSQL(query)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var tables []string
+	for i, tok := range toks {
+		low := strings.ToLower(tok)
+		if (low != "from" && low != "join") || i+1 >= len(toks) {
+			continue
+		}
+		name := toks[i+1]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if view, ok := c.views[name]; ok {
+			sub, err := tablesReferenced(c, view, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range sub {
+				if !seen[s] {
+					seen[s] = true
+					tables = append(tables, s)
+				}
+			}
+			continue
+		}
+		if _, err := c.constrained(name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, nil
+}
@@ -0,0 +1,35 @@
+package godb
+
+// Row addressing for column files. A column file's record ids are
+// (rowGroup, slot) pairs: row group g holds the rowGroupSize consecutive
+// rows starting at g*rowGroupSize, and slot is the row's position within
+// its group. The group size currently coincides with column 0's page
+// capacity, but every translation between record ids, global row
+// positions, and per-column page locations goes through the helpers here —
+// nothing else may assume sibling columns share page numbers or page
+// boundaries, which stops holding as soon as column widths differ.
+
+// rowGroupSize returns the number of rows in one row group.
+func (f *ColumnFile) rowGroupSize() int {
+	return f.slotsPerPage(0)
+}
+
+// ridForRow returns the (rowGroup, slot) record id of the row at global
+// position row.
+func (f *ColumnFile) ridForRow(row int) RecordID {
+	return RecordID{PageNo: row / f.rowGroupSize(), SlotNo: row % f.rowGroupSize()}
+}
+
+// rowOf recovers the global row position encoded in a record id.
+func (f *ColumnFile) rowOf(rid RecordID) int {
+	return rid.PageNo*f.rowGroupSize() + rid.SlotNo
+}
+
+// colLocation returns the page ordinal (within column colNo's own file)
+// and slot holding the value of the row at global position row. Columns
+// pack different numbers of values per page, so the same row lives at
+// different page ordinals in different columns.
+func (f *ColumnFile) colLocation(colNo, row int) (colPageNo, slot int) {
+	spp := f.slotsPerPage(colNo)
+	return row / spp, row % spp
+}
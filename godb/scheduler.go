@@ -0,0 +1,111 @@
+package godb
+
+import "sync"
+
+// Admission control for query execution. A QueryScheduler caps how many
+// operator trees execute at once; excess queries queue until a slot frees,
+// higher priorities first and FIFO within a priority. A flood of
+// analytical scans therefore competes for a fixed number of slots instead
+// of for every page of the buffer pool and every lock at once.
+
+// QueryScheduler limits concurrently executing queries.
+type QueryScheduler struct {
+	mu      sync.Mutex
+	limit   int
+	running int
+	waiters []*schedWaiter
+}
+
+// schedWaiter is one queued query waiting for an execution slot.
+type schedWaiter struct {
+	priority int
+	ready    chan struct{}
+}
+
+// NewQueryScheduler returns a scheduler allowing up to limit queries to
+// execute at once; limit must be positive.
+func NewQueryScheduler(limit int) (*QueryScheduler, error) {
+	if limit <= 0 {
+		return nil, GoDBError{IllegalOperationError, "scheduler limit must be positive"}
+	}
+	return &QueryScheduler{limit: limit}, nil
+}
+
+// Acquire blocks until an execution slot is free. Queued callers are
+// released in priority order (higher first), FIFO within a priority.
+func (s *QueryScheduler) Acquire(priority int) {
+	s.mu.Lock()
+	if s.running < s.limit && len(s.waiters) == 0 {
+		s.running++
+		s.mu.Unlock()
+		return
+	}
+	w := &schedWaiter{priority: priority, ready: make(chan struct{})}
+	// Insert after the last waiter with priority >= ours.
+	pos := len(s.waiters)
+	for i, other := range s.waiters {
+		if other.priority < priority {
+			pos = i
+			break
+		}
+	}
+	s.waiters = append(s.waiters, nil)
+	copy(s.waiters[pos+1:], s.waiters[pos:])
+	s.waiters[pos] = w
+	s.mu.Unlock()
+	<-w.ready
+}
+
+// Release returns an execution slot, dispatching the highest-priority
+// waiter if any.
+func (s *QueryScheduler) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiters) > 0 {
+		w := s.waiters[0]
+		s.waiters = s.waiters[1:]
+		close(w.ready)
+		return
+	}
+	if s.running > 0 {
+		s.running--
+	}
+}
+
+// Queued returns the number of queries waiting for a slot.
+func (s *QueryScheduler) Queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.waiters)
+}
+
+// Running returns the number of queries currently holding slots.
+func (s *QueryScheduler) Running() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// Run admits op under the scheduler and returns its iterator. The slot is
+// held until the iterator is exhausted or returns an error, so callers
+// must drain (or error out of) every admitted query.
+func (s *QueryScheduler) Run(op Operator, tid TransactionID, priority int) (func() (*Tuple, error), error) {
+	s.Acquire(priority)
+	iter, err := op.Iterator(tid)
+	if err != nil {
+		s.Release()
+		return nil, err
+	}
+	released := false
+	return func() (*Tuple, error) {
+		if released {
+			return nil, nil
+		}
+		t, err := iter()
+		if err != nil || t == nil {
+			released = true
+			s.Release()
+		}
+		return t, err
+	}, nil
+}
@@ -0,0 +1,259 @@
+package godb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Schema evolution for heap files. A heap file carries an ordered list of
+// schema versions; each page records the version its tuples were encoded
+// with. ALTER TABLE appends a new version without rewriting existing pages:
+// old-layout tuples are upgraded lazily as they are read, with added
+// columns backfilled from their declared defaults.
+
+// schemaFileData is the JSON layout of the <table>.dat.schema sidecar file.
+// It is only written once a table has been altered; files without a sidecar
+// have a single version, their create-time schema.
+type schemaFileData struct {
+	Versions [][]schemaCol     `json:"versions"`
+	Defaults map[string]string `json:"defaults"`
+}
+
+type schemaCol struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Scale is the decimal scale; zero (and omitted) for other types.
+	Scale int `json:"scale,omitempty"`
+}
+
+// currentVersion returns the schema version new pages are written with.
+func (f *HeapFile) currentVersion() int32 {
+	return int32(len(f.versions) - 1)
+}
+
+// descForVersion returns the descriptor tuples of version v were encoded
+// with.
+func (f *HeapFile) descForVersion(v int32) (*TupleDesc, error) {
+	if int(v) < 0 || int(v) >= len(f.versions) {
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("unknown schema version %d in %s", v, f.fileName)}
+	}
+	return f.versions[v], nil
+}
+
+// AddColumn appends a schema version with an extra column of the supplied
+// type. Existing rows are not rewritten; reads backfill the column with
+// dflt (which must match ftype). The column becomes visible immediately in
+// Descriptor().
+func (f *HeapFile) AddColumn(name string, ftype DBType, dflt DBValue) error {
+	tq := ""
+	if len(f.desc.Fields) > 0 {
+		tq = f.desc.Fields[0].TableQualifier
+	}
+	for _, fld := range f.desc.Fields {
+		if fld.Fname == name {
+			return GoDBError{DuplicateNameError, fmt.Sprintf("column %s already exists", name)}
+		}
+	}
+	switch dflt.(type) {
+	case IntField:
+		if ftype != IntType {
+			return GoDBError{TypeMismatchError, "default value does not match column type"}
+		}
+	case StringField:
+		if ftype != StringType {
+			return GoDBError{TypeMismatchError, "default value does not match column type"}
+		}
+	default:
+		return GoDBError{TypeMismatchError, "unsupported default value"}
+	}
+	next := f.desc.copy()
+	next.Fields = append(next.Fields, FieldType{Fname: name, TableQualifier: tq, Ftype: ftype})
+	f.versions = append(f.versions, next)
+	f.desc = next
+	if f.defaults == nil {
+		f.defaults = make(map[string]DBValue)
+	}
+	f.defaults[name] = dflt
+	return f.saveSchema()
+}
+
+// DropColumn appends a schema version without the named column. Existing
+// pages keep the old layout; reads project the column away.
+func (f *HeapFile) DropColumn(name string) error {
+	idx := -1
+	for i, fld := range f.desc.Fields {
+		if fld.Fname == name {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return GoDBError{FieldNotFoundError, fmt.Sprintf("no column %s", name)}
+	}
+	if len(f.desc.Fields) == 1 {
+		return GoDBError{IllegalOperationError, "cannot drop the only column"}
+	}
+	next := f.desc.copy()
+	next.Fields = append(next.Fields[:idx], next.Fields[idx+1:]...)
+	f.versions = append(f.versions, next)
+	f.desc = next
+	return f.saveSchema()
+}
+
+// upgradeTuple rewrites t (encoded with schema version v) into the current
+// schema, dropping removed columns and backfilling added ones with their
+// defaults.
+func (f *HeapFile) upgradeTuple(t *Tuple, v int32) (*Tuple, error) {
+	if v == f.currentVersion() {
+		return t, nil
+	}
+	old, err := f.descForVersion(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]DBValue, len(f.desc.Fields))
+	for i, fld := range f.desc.Fields {
+		if idx, err := findFieldInTd(FieldType{Fname: fld.Fname}, old); err == nil {
+			fields[i] = t.Fields[idx]
+			continue
+		}
+		dflt, ok := f.defaults[fld.Fname]
+		if !ok {
+			// No recorded default (e.g. sidecar edited by hand): zero value.
+			if fld.Ftype == IntType {
+				dflt = IntField{0}
+			} else {
+				dflt = StringField{""}
+			}
+		}
+		fields[i] = dflt
+	}
+	return &Tuple{Desc: *f.desc.copy(), Fields: fields, Rid: t.Rid}, nil
+}
+
+func (f *HeapFile) schemaPath() string {
+	return f.fileName + ".schema"
+}
+
+// saveSchema writes the version history sidecar.
+func (f *HeapFile) saveSchema() error {
+	data := schemaFileData{Defaults: make(map[string]string)}
+	for _, v := range f.versions {
+		cols := make([]schemaCol, len(v.Fields))
+		for i, fld := range v.Fields {
+			cols[i] = schemaCol{Name: fld.Fname, Type: fld.Ftype.String()}
+			if fld.Ftype == DecimalType {
+				cols[i].Scale = fld.Scale
+			}
+		}
+		data.Versions = append(data.Versions, cols)
+	}
+	for name, dv := range f.defaults {
+		switch x := dv.(type) {
+		case IntField:
+			data.Defaults[name] = strconv.FormatInt(x.Value, 10)
+		case StringField:
+			data.Defaults[name] = x.Value
+		}
+	}
+	buf, err := json.Marshal(&data)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(f.schemaPath(), buf, 0644); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
+
+// loadSchema restores the version history sidecar if present; otherwise the
+// file has a single version, the create-time descriptor.
+func (f *HeapFile) loadSchema() error {
+	buf, err := os.ReadFile(f.schemaPath())
+	if os.IsNotExist(err) {
+		f.versions = []*TupleDesc{f.desc}
+		return nil
+	}
+	if err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	var data schemaFileData
+	if err := json.Unmarshal(buf, &data); err != nil {
+		return GoDBError{MalformedDataError, fmt.Sprintf("bad schema sidecar %s: %v", f.schemaPath(), err)}
+	}
+	tq := ""
+	if len(f.desc.Fields) > 0 {
+		tq = f.desc.Fields[0].TableQualifier
+	}
+	f.versions = nil
+	for _, cols := range data.Versions {
+		d := &TupleDesc{}
+		for _, col := range cols {
+			ft := FieldType{Fname: col.Name, TableQualifier: tq, Ftype: IntType}
+			switch col.Type {
+			case "string":
+				ft.Ftype = StringType
+			case "decimal":
+				ft.Ftype = DecimalType
+				ft.Scale = col.Scale
+			}
+			d.Fields = append(d.Fields, ft)
+		}
+		f.versions = append(f.versions, d)
+	}
+	if len(f.versions) == 0 {
+		f.versions = []*TupleDesc{f.desc}
+		return nil
+	}
+	f.desc = f.versions[len(f.versions)-1]
+	f.defaults = make(map[string]DBValue)
+	for name, raw := range data.Defaults {
+		if idx, err := findFieldInTd(FieldType{Fname: name}, f.desc); err == nil && f.desc.Fields[idx].Ftype == IntType {
+			v, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return GoDBError{MalformedDataError, fmt.Sprintf("bad default for %s: %v", name, err)}
+			}
+			f.defaults[name] = IntField{v}
+		} else {
+			f.defaults[name] = StringField{raw}
+		}
+	}
+	return nil
+}
+
+// AlterTableAddColumn adds a column with a default to a heap-backed table.
+func (c *Catalog) AlterTableAddColumn(table, col string, ftype DBType, dflt DBValue) error {
+	hf, err := c.heapFile(table)
+	if err != nil {
+		return err
+	}
+	return hf.AddColumn(col, ftype, dflt)
+}
+
+// AlterTableDropColumn drops a column from a heap-backed table.
+func (c *Catalog) AlterTableDropColumn(table, col string) error {
+	hf, err := c.heapFile(table)
+	if err != nil {
+		return err
+	}
+	return hf.DropColumn(col)
+}
+
+// heapFile returns the underlying *HeapFile for table, unwrapping any
+// constraint wrapper.
+func (c *Catalog) heapFile(table string) (*HeapFile, error) {
+	f, err := c.GetTable(table)
+	if err != nil {
+		return nil, err
+	}
+	if cf, ok := f.(*constrainedFile); ok {
+		f = cf.DBFile
+	}
+	hf, ok := f.(*HeapFile)
+	if !ok {
+		return nil, GoDBError{IllegalOperationError, fmt.Sprintf("table %s is not heap-backed", table)}
+	}
+	return hf, nil
+}
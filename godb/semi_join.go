@@ -0,0 +1,83 @@
+package godb
+
+// SemiJoin emits each left tuple that has at least one match on the right,
+// and AntiJoin each left tuple that has none. Both hash the right child's
+// key values into a set and stream the left child against it, which makes
+// them the execution backbone for EXISTS, NOT EXISTS, and IN subqueries:
+// the output schema is the left schema, and a left tuple is emitted at
+// most once however many right tuples match.
+type SemiJoin struct {
+	leftField, rightField Expr
+	left, right           *Operator
+	// anti inverts the membership test, turning the semi join into an
+	// anti join.
+	anti bool
+}
+
+// NewSemiJoin returns a semi join of left and right on the supplied fields.
+func NewSemiJoin(left Operator, leftField Expr, right Operator, rightField Expr) (*SemiJoin, error) {
+	return &SemiJoin{leftField, rightField, &left, &right, false}, nil
+}
+
+// NewAntiJoin returns an anti join of left and right on the supplied fields.
+func NewAntiJoin(left Operator, leftField Expr, right Operator, rightField Expr) (*SemiJoin, error) {
+	return &SemiJoin{leftField, rightField, &left, &right, true}, nil
+}
+
+func (j *SemiJoin) Descriptor() *TupleDesc {
+	return (*j.left).Descriptor()
+}
+
+func (j *SemiJoin) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	leftIter, err := (*j.left).Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	var keys map[DBValue]bool
+	// The right side is consumed once, lazily, on the first call; only its
+	// key values are retained.
+	build := func() error {
+		rightIter, err := (*j.right).Iterator(tid)
+		if err != nil {
+			return err
+		}
+		keys = make(map[DBValue]bool)
+		for {
+			rt, err := rightIter()
+			if err != nil {
+				return err
+			}
+			if rt == nil {
+				return nil
+			}
+			v, err := j.rightField.EvalExpr(rt)
+			if err != nil {
+				return err
+			}
+			keys[v] = true
+		}
+	}
+	return func() (*Tuple, error) {
+		if keys == nil {
+			if err := build(); err != nil {
+				return nil, err
+			}
+		}
+		for {
+			lt, err := leftIter()
+			if err != nil {
+				return nil, err
+			}
+			if lt == nil {
+				return nil, nil
+			}
+			v, err := j.leftField.EvalExpr(lt)
+			if err != nil {
+				return nil, err
+			}
+			if keys[v] != j.anti {
+				return lt, nil
+			}
+		}
+	}, nil
+}
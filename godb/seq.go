@@ -0,0 +1,84 @@
+package godb
+
+// Push-style iteration API. Seq2 mirrors iter.Seq2 from Go 1.23's iter
+// package; this module still builds with older toolchains, so the type is
+// declared here with the identical shape. Once the go directive reaches
+// 1.23 these sequences work directly with range-over-func —
+//
+//	for t, err := range f.All(tid) { ... }
+//
+// — and until then a sequence is invoked with an explicit yield func.
+// Either way callers are spared the manual closure-draining loop
+// repeated around every Iterator call.
+type Seq2[K, V any] func(yield func(K, V) bool)
+
+// AllTuples returns op's tuples as a sequence under tid. Iteration stops
+// at the first error, which is yielded with a nil tuple; a nil tuple with
+// a nil error is never yielded.
+func AllTuples(op Operator, tid TransactionID) Seq2[*Tuple, error] {
+	return func(yield func(*Tuple, error) bool) {
+		iter, err := op.Iterator(tid)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for {
+			t, err := iter()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if t == nil {
+				return
+			}
+			if !yield(t, nil) {
+				return
+			}
+		}
+	}
+}
+
+// All returns the file's tuples as a sequence under tid.
+func (f *HeapFile) All(tid TransactionID) Seq2[*Tuple, error] {
+	return AllTuples(f, tid)
+}
+
+// All returns the file's tuples as a sequence under tid.
+func (f *ColumnFile) All(tid TransactionID) Seq2[*Tuple, error] {
+	return AllTuples(f, tid)
+}
+
+// All returns the file's tuples as a sequence under tid.
+func (f *MemFile) All(tid TransactionID) Seq2[*Tuple, error] {
+	return AllTuples(f, tid)
+}
+
+// All returns the file's tuples as a sequence under tid.
+func (f *HybridFile) All(tid TransactionID) Seq2[*Tuple, error] {
+	return AllTuples(f, tid)
+}
+
+// All executes plan under the session's transaction as a sequence; like
+// Execute, any yielded error has already aborted the transaction.
+func (s *Session) All(plan Operator) Seq2[*Tuple, error] {
+	return func(yield func(*Tuple, error) bool) {
+		iter, err := s.Execute(plan)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for {
+			t, err := iter()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if t == nil {
+				return
+			}
+			if !yield(t, nil) {
+				return
+			}
+		}
+	}
+}
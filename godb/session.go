@@ -0,0 +1,178 @@
+package godb
+
+import (
+	"sync"
+)
+
+// Session is the multi-statement transaction API at the query layer. It
+// owns one TransactionID across any number of operator executions, so
+// callers write Begin / run queries / Commit instead of juggling raw tids
+// and the BufferPool. Any error surfaced through the session — a failed
+// iterator step, insert, or delete — aborts the transaction immediately
+// (holding locks after a failure only invites deadlock), and iterators
+// handed out during a transaction refuse to run once it has ended. A
+// session is safe for use from one goroutine at a time.
+type Session struct {
+	catalog *Catalog
+	bufPool *BufferPool
+	mu      sync.Mutex
+	tid     TransactionID
+	// epoch increments every time a transaction ends; iterators capture
+	// it at creation and go stale when it moves on.
+	epoch int64
+}
+
+// NewSession returns an idle session over the catalog's buffer pool.
+func NewSession(c *Catalog) *Session {
+	return &Session{catalog: c, bufPool: c.bufPool}
+}
+
+// Begin starts a new transaction. The session must be idle.
+func (s *Session) Begin() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tid != nil {
+		return GoDBError{IllegalTransactionError, "session already has a running transaction"}
+	}
+	tid := NewTID()
+	if err := s.bufPool.BeginTransaction(tid); err != nil {
+		return err
+	}
+	s.tid = tid
+	return nil
+}
+
+// Commit commits the running transaction and invalidates the session's
+// outstanding iterators.
+func (s *Session) Commit() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tid == nil {
+		return GoDBError{IllegalTransactionError, "session has no running transaction"}
+	}
+	s.bufPool.CommitTransaction(s.tid)
+	s.tid = nil
+	s.epoch++
+	return nil
+}
+
+// Rollback aborts the running transaction and invalidates the session's
+// outstanding iterators. Rolling back an idle session is a no-op, so it
+// is safe to defer unconditionally.
+func (s *Session) Rollback() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.abortLocked()
+}
+
+// abortLocked ends the running transaction, if any. Caller holds s.mu.
+func (s *Session) abortLocked() {
+	if s.tid == nil {
+		return
+	}
+	s.bufPool.AbortTransaction(s.tid)
+	s.tid = nil
+	s.epoch++
+}
+
+// InTransaction reports whether the session has a running transaction.
+func (s *Session) InTransaction() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tid != nil
+}
+
+// Tid exposes the running transaction's id for APIs that still want one,
+// or nil when the session is idle.
+func (s *Session) Tid() TransactionID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tid
+}
+
+// current returns the running transaction's id and epoch. Begin is NOT
+// implicit: executing on an idle session is an error, so a caller cannot
+// silently run each statement in its own transaction.
+func (s *Session) current() (TransactionID, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tid == nil {
+		return nil, 0, GoDBError{IllegalTransactionError, "session has no running transaction"}
+	}
+	return s.tid, s.epoch, nil
+}
+
+// Execute opens an iterator over plan under the session's transaction.
+// The iterator returns an error once the transaction has committed or
+// rolled back, and any error it yields aborts the transaction.
+func (s *Session) Execute(plan Operator) (func() (*Tuple, error), error) {
+	tid, epoch, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	iter, err := plan.Iterator(tid)
+	if err != nil {
+		s.fail(epoch)
+		return nil, err
+	}
+	return func() (*Tuple, error) {
+		s.mu.Lock()
+		stale := s.epoch != epoch
+		s.mu.Unlock()
+		if stale {
+			return nil, GoDBError{IllegalTransactionError, "iterator used after its transaction ended"}
+		}
+		t, err := iter()
+		if err != nil {
+			s.fail(epoch)
+			return nil, err
+		}
+		return t, nil
+	}, nil
+}
+
+// Query parses query against the session's catalog and executes it.
+func (s *Session) Query(query string) (func() (*Tuple, error), error) {
+	plan, err := ParseQuery(s.catalog, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.Execute(plan)
+}
+
+// Insert adds t to table under the session's transaction.
+func (s *Session) Insert(table string, t *Tuple) error {
+	return s.write(table, t, InsertTuple)
+}
+
+// Delete removes t (as returned by a session iterator) from table under
+// the session's transaction.
+func (s *Session) Delete(table string, t *Tuple) error {
+	return s.write(table, t, DeleteTuple)
+}
+
+func (s *Session) write(table string, t *Tuple, apply func(DBFile, *Tuple, TransactionID) error) error {
+	tid, epoch, err := s.current()
+	if err != nil {
+		return err
+	}
+	f, err := s.catalog.GetTable(table)
+	if err != nil {
+		return err
+	}
+	if err := apply(f, t, tid); err != nil {
+		s.fail(epoch)
+		return err
+	}
+	return nil
+}
+
+// fail aborts the transaction that was running at epoch, unless it has
+// already ended — a stale iterator's error must not abort its successor.
+func (s *Session) fail(epoch int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.epoch == epoch {
+		s.abortLocked()
+	}
+}
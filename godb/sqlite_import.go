@@ -0,0 +1,408 @@
+package godb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SQLite import. ImportSQLite reads a SQLite database file directly — a
+// small pure-Go decoder of the documented file format, keeping the module
+// dependency-free — and creates one heap table in the catalog per SQLite
+// table, so GoDB can be seeded from existing small databases.
+//
+// Supported: rollback-journal databases (not WAL), UTF-8 text encoding,
+// table b-trees with in-cell payloads. Column types map by SQLite's
+// affinity rules collapsed onto GoDB's two types: declarations containing
+// INT become int columns, everything else (TEXT, REAL, BLOB, untyped)
+// becomes a string column with values rendered as text. INTEGER PRIMARY
+// KEY columns read their values from the rowid, as SQLite stores them.
+// Cells spilling to overflow pages are rejected with a descriptive error;
+// GoDB truncates strings to StringLength anyway, so databases needing
+// overflow rarely make sense to import.
+
+const sqliteHeaderSize = 100
+
+// sqliteFile is an open database file plus its page geometry.
+type sqliteFile struct {
+	data     []byte
+	pageSize int
+}
+
+// sqliteColumn is one column of a table being imported.
+type sqliteColumn struct {
+	name  string
+	ftype DBType
+	// rowidAlias marks an INTEGER PRIMARY KEY column, whose value is the
+	// cell's rowid rather than a record field.
+	rowidAlias bool
+}
+
+// ImportSQLite creates and fills one catalog table per table in the
+// SQLite database at path, returning the names created. Tables whose
+// names collide with existing catalog tables fail the import.
+func (c *Catalog) ImportSQLite(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	sf, err := openSQLite(data)
+	if err != nil {
+		return nil, err
+	}
+	masters, err := sf.readMaster()
+	if err != nil {
+		return nil, err
+	}
+	var created []string
+	for _, m := range masters {
+		cols, err := parseSQLiteSchema(m.sql, m.name)
+		if err != nil {
+			return nil, err
+		}
+		fields := make([]FieldType, len(cols))
+		for i, col := range cols {
+			fields[i] = FieldType{Fname: col.name, TableQualifier: m.name, Ftype: col.ftype}
+		}
+		f, err := c.AddTable(m.name, TupleDesc{Fields: fields})
+		if err != nil {
+			return nil, err
+		}
+		tid := NewTID()
+		if err := c.bufPool.BeginTransaction(tid); err != nil {
+			return nil, err
+		}
+		insertErr := sf.walkTable(m.rootPage, func(rowid int64, rec []sqliteValue) error {
+			if len(rec) < len(cols) {
+				// Rows written before columns were added read as short
+				// records; missing trailing columns are NULL.
+				padded := make([]sqliteValue, len(cols))
+				copy(padded, rec)
+				rec = padded
+			}
+			vals := make([]DBValue, len(cols))
+			for i, col := range cols {
+				v, err := col.toDBValue(rec[i], rowid, m.name)
+				if err != nil {
+					return err
+				}
+				vals[i] = v
+			}
+			return f.insertTuple(&Tuple{Desc: TupleDesc{Fields: fields}, Fields: vals}, tid)
+		})
+		if insertErr != nil {
+			c.bufPool.AbortTransaction(tid)
+			return nil, insertErr
+		}
+		c.bufPool.CommitTransaction(tid)
+		created = append(created, m.name)
+	}
+	return created, nil
+}
+
+// toDBValue converts one decoded SQLite value for this column.
+func (col sqliteColumn) toDBValue(v sqliteValue, rowid int64, table string) (DBValue, error) {
+	if col.rowidAlias {
+		return IntField{rowid}, nil
+	}
+	if col.ftype == IntType {
+		switch v.kind {
+		case sqliteNull:
+			return IntField{0}, nil
+		case sqliteInt:
+			return IntField{v.i}, nil
+		case sqliteFloat:
+			return IntField{int64(v.f)}, nil
+		case sqliteText:
+			n, err := strconv.ParseInt(strings.TrimSpace(v.s), 10, 64)
+			if err != nil {
+				return nil, GoDBError{MalformedDataError, fmt.Sprintf("table %s column %s: text value %q in int column", table, col.name, v.s)}
+			}
+			return IntField{n}, nil
+		}
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("table %s column %s: blob value in int column", table, col.name)}
+	}
+	var s string
+	switch v.kind {
+	case sqliteNull:
+		s = ""
+	case sqliteInt:
+		s = strconv.FormatInt(v.i, 10)
+	case sqliteFloat:
+		s = strconv.FormatFloat(v.f, 'g', -1, 64)
+	case sqliteText, sqliteBlob:
+		s = v.s
+	}
+	if len(s) > StringLength {
+		s = s[:StringLength]
+	}
+	return StringField{s}, nil
+}
+
+// openSQLite validates the file header.
+func openSQLite(data []byte) (*sqliteFile, error) {
+	if len(data) < sqliteHeaderSize || !bytes.Equal(data[:16], []byte("SQLite format 3\x00")) {
+		return nil, GoDBError{MalformedDataError, "not a SQLite 3 database"}
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if data[18] > 1 {
+		return nil, GoDBError{IllegalOperationError, "WAL-mode SQLite databases are not supported; run PRAGMA journal_mode=DELETE first"}
+	}
+	if enc := binary.BigEndian.Uint32(data[56:60]); enc != 1 && enc != 0 {
+		return nil, GoDBError{IllegalOperationError, "only UTF-8 SQLite databases are supported"}
+	}
+	return &sqliteFile{data: data, pageSize: pageSize}, nil
+}
+
+// page returns page n (1-based) of the file.
+func (sf *sqliteFile) page(n int) ([]byte, error) {
+	off := (n - 1) * sf.pageSize
+	if n < 1 || off+sf.pageSize > len(sf.data) {
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("SQLite page %d out of range", n)}
+	}
+	return sf.data[off : off+sf.pageSize], nil
+}
+
+// sqliteMaster is one row of sqlite_master describing a table.
+type sqliteMaster struct {
+	name     string
+	rootPage int
+	sql      string
+}
+
+// readMaster walks the schema table rooted at page 1, returning the user
+// tables.
+func (sf *sqliteFile) readMaster() ([]sqliteMaster, error) {
+	var masters []sqliteMaster
+	err := sf.walkTable(1, func(rowid int64, rec []sqliteValue) error {
+		// sqlite_master: type, name, tbl_name, rootpage, sql.
+		if len(rec) < 5 || rec[0].kind != sqliteText || rec[0].s != "table" {
+			return nil
+		}
+		name := rec[1].s
+		if strings.HasPrefix(name, "sqlite_") {
+			return nil
+		}
+		if rec[3].kind != sqliteInt || rec[4].kind != sqliteText {
+			return GoDBError{MalformedDataError, fmt.Sprintf("malformed sqlite_master row for %s", name)}
+		}
+		masters = append(masters, sqliteMaster{name: name, rootPage: int(rec[3].i), sql: rec[4].s})
+		return nil
+	})
+	return masters, err
+}
+
+// walkTable visits every row of the table b-tree rooted at page root in
+// rowid order.
+func (sf *sqliteFile) walkTable(root int, visit func(rowid int64, rec []sqliteValue) error) error {
+	pg, err := sf.page(root)
+	if err != nil {
+		return err
+	}
+	// Page 1 carries the file header before its page header.
+	hdr := 0
+	if root == 1 {
+		hdr = sqliteHeaderSize
+	}
+	typ := pg[hdr]
+	cells := int(binary.BigEndian.Uint16(pg[hdr+3 : hdr+5]))
+	switch typ {
+	case 0x05: // interior table page
+		ptrs := hdr + 12
+		for i := 0; i < cells; i++ {
+			off := int(binary.BigEndian.Uint16(pg[ptrs+2*i:]))
+			child := int(binary.BigEndian.Uint32(pg[off:]))
+			if err := sf.walkTable(child, visit); err != nil {
+				return err
+			}
+		}
+		right := int(binary.BigEndian.Uint32(pg[hdr+8:]))
+		return sf.walkTable(right, visit)
+	case 0x0d: // leaf table page
+		ptrs := hdr + 8
+		for i := 0; i < cells; i++ {
+			off := int(binary.BigEndian.Uint16(pg[ptrs+2*i:]))
+			payloadLen, n := sqliteVarint(pg[off:])
+			off += n
+			rowid, n := sqliteVarint(pg[off:])
+			off += n
+			// Payloads at or under the page's full-cell threshold are
+			// stored entirely in the cell; larger ones spill to overflow
+			// pages, which this importer does not follow.
+			if int(payloadLen) > sf.pageSize-35 {
+				return GoDBError{IllegalOperationError, fmt.Sprintf("row %d uses SQLite overflow pages, which the importer does not support", rowid)}
+			}
+			if off+int(payloadLen) > len(pg) {
+				return GoDBError{MalformedDataError, fmt.Sprintf("row %d cell extends past page end", rowid)}
+			}
+			rec, err := decodeSQLiteRecord(pg[off : off+int(payloadLen)])
+			if err != nil {
+				return err
+			}
+			if err := visit(rowid, rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return GoDBError{MalformedDataError, fmt.Sprintf("unexpected SQLite page type %#x in table b-tree", typ)}
+}
+
+// Kinds of decoded SQLite values.
+const (
+	sqliteNull = iota
+	sqliteInt
+	sqliteFloat
+	sqliteText
+	sqliteBlob
+)
+
+type sqliteValue struct {
+	kind int
+	i    int64
+	f    float64
+	s    string
+}
+
+// decodeSQLiteRecord decodes one record: a varint header length, serial
+// type varints, then the value bodies.
+func decodeSQLiteRecord(rec []byte) ([]sqliteValue, error) {
+	hdrLen, n := sqliteVarint(rec)
+	if int(hdrLen) > len(rec) {
+		return nil, GoDBError{MalformedDataError, "SQLite record header overruns record"}
+	}
+	var serials []int64
+	for off := n; off < int(hdrLen); {
+		s, n := sqliteVarint(rec[off:])
+		serials = append(serials, s)
+		off += n
+	}
+	vals := make([]sqliteValue, 0, len(serials))
+	body := rec[hdrLen:]
+	for _, s := range serials {
+		switch {
+		case s == 0:
+			vals = append(vals, sqliteValue{kind: sqliteNull})
+		case s >= 1 && s <= 6:
+			width := map[int64]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 6, 6: 8}[s]
+			if len(body) < width {
+				return nil, GoDBError{MalformedDataError, "SQLite record body truncated"}
+			}
+			var v int64
+			for _, b := range body[:width] {
+				v = v<<8 | int64(b)
+			}
+			// Sign-extend from the value's width.
+			shift := uint(64 - 8*width)
+			v = v << shift >> shift
+			vals = append(vals, sqliteValue{kind: sqliteInt, i: v})
+			body = body[width:]
+		case s == 7:
+			if len(body) < 8 {
+				return nil, GoDBError{MalformedDataError, "SQLite record body truncated"}
+			}
+			bits := binary.BigEndian.Uint64(body)
+			vals = append(vals, sqliteValue{kind: sqliteFloat, f: math.Float64frombits(bits)})
+			body = body[8:]
+		case s == 8:
+			vals = append(vals, sqliteValue{kind: sqliteInt, i: 0})
+		case s == 9:
+			vals = append(vals, sqliteValue{kind: sqliteInt, i: 1})
+		case s >= 12:
+			size := int((s - 12) / 2)
+			kind := sqliteBlob
+			if s%2 == 1 {
+				size = int((s - 13) / 2)
+				kind = sqliteText
+			}
+			if len(body) < size {
+				return nil, GoDBError{MalformedDataError, "SQLite record body truncated"}
+			}
+			vals = append(vals, sqliteValue{kind: kind, s: string(body[:size])})
+			body = body[size:]
+		default:
+			return nil, GoDBError{MalformedDataError, fmt.Sprintf("reserved SQLite serial type %d", s)}
+		}
+	}
+	return vals, nil
+}
+
+// sqliteVarint decodes SQLite's big-endian varint, returning the value
+// and encoded length.
+func sqliteVarint(b []byte) (int64, int) {
+	var v int64
+	for i := 0; i < 8 && i < len(b); i++ {
+		if b[i] < 0x80 {
+			return v<<7 | int64(b[i]), i + 1
+		}
+		v = v<<7 | int64(b[i]&0x7f)
+	}
+	if len(b) >= 9 {
+		return v<<8 | int64(b[8]), 9
+	}
+	return v, len(b)
+}
+
+// parseSQLiteSchema extracts column names and GoDB types from a CREATE
+// TABLE statement.
+func parseSQLiteSchema(sql, table string) ([]sqliteColumn, error) {
+	open := strings.IndexByte(sql, '(')
+	close := strings.LastIndexByte(sql, ')')
+	if open < 0 || close < open {
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("cannot parse CREATE TABLE for %s", table)}
+	}
+	var cols []sqliteColumn
+	for _, def := range splitTopLevel(sql[open+1 : close]) {
+		def = strings.TrimSpace(def)
+		if def == "" {
+			continue
+		}
+		fields := strings.Fields(def)
+		switch strings.ToUpper(fields[0]) {
+		case "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "CONSTRAINT":
+			continue // table-level constraint, not a column
+		}
+		name := strings.Trim(fields[0], "\"'`[]")
+		rest := strings.ToUpper(strings.Join(fields[1:], " "))
+		col := sqliteColumn{name: name, ftype: StringType}
+		if strings.Contains(rest, "INT") {
+			col.ftype = IntType
+			if strings.Contains(rest, "PRIMARY KEY") {
+				col.rowidAlias = true
+			}
+		}
+		cols = append(cols, col)
+	}
+	if len(cols) == 0 {
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("no columns found for table %s", table)}
+	}
+	return cols, nil
+}
+
+// splitTopLevel splits on commas outside parentheses (so CHECK(...) and
+// similar stay whole).
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,91 @@
+package godb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Pluggable storage engines. A storage engine is anything implementing
+// DBFile; the registry maps engine names to constructors so the catalog —
+// and third-party code — can create tables on alternative storage (an
+// S3-backed file, say) without touching core code. The built-in engines
+// "heap", "column", and "memory" are registered at init.
+
+// EngineConstructor creates a table's DBFile. path is where the engine
+// should keep its data (ignored by purely in-memory engines), desc the
+// table schema, and bp the buffer pool page access must go through.
+type EngineConstructor func(path string, desc *TupleDesc, bp *BufferPool) (DBFile, error)
+
+var (
+	engineMu sync.RWMutex
+	engines  = make(map[string]EngineConstructor)
+)
+
+// RegisterStorageEngine makes ctor available under name; names are
+// case-insensitive and re-registering replaces the previous constructor.
+func RegisterStorageEngine(name string, ctor EngineConstructor) {
+	engineMu.Lock()
+	defer engineMu.Unlock()
+	engines[strings.ToLower(name)] = ctor
+}
+
+// StorageEngines returns the names of all registered engines.
+func StorageEngines() []string {
+	engineMu.RLock()
+	defer engineMu.RUnlock()
+	names := make([]string, 0, len(engines))
+	for n := range engines {
+		names = append(names, n)
+	}
+	return names
+}
+
+// storageEngine looks up a registered constructor.
+func storageEngine(name string) (EngineConstructor, error) {
+	engineMu.RLock()
+	defer engineMu.RUnlock()
+	ctor, ok := engines[strings.ToLower(name)]
+	if !ok {
+		return nil, GoDBError{NoSuchTableError, fmt.Sprintf("no storage engine named %s", name)}
+	}
+	return ctor, nil
+}
+
+func init() {
+	RegisterStorageEngine("heap", func(path string, desc *TupleDesc, bp *BufferPool) (DBFile, error) {
+		return NewHeapFile(path, desc, bp)
+	})
+	RegisterStorageEngine("column", func(path string, desc *TupleDesc, bp *BufferPool) (DBFile, error) {
+		return NewColumnFile(strings.TrimSuffix(path, ".dat"), desc, bp)
+	})
+	RegisterStorageEngine("memory", func(path string, desc *TupleDesc, bp *BufferPool) (DBFile, error) {
+		return NewMemFile(desc), nil
+	})
+}
+
+// AddTableWithEngine creates a table like AddTable but stored by the named
+// engine instead of the default heap file.
+func (c *Catalog) AddTableWithEngine(name string, desc TupleDesc, engine string) (DBFile, error) {
+	if _, ok := c.tables[name]; ok {
+		return nil, GoDBError{DuplicateNameError, fmt.Sprintf("table %s already exists", name)}
+	}
+	ctor, err := storageEngine(engine)
+	if err != nil {
+		return nil, err
+	}
+	for i := range desc.Fields {
+		desc.Fields[i].TableQualifier = name
+	}
+	f, err := ctor(c.tableFilePath(name), desc.copy(), c.bufPool)
+	if err != nil {
+		return nil, err
+	}
+	if hf, ok := f.(*HeapFile); ok && c.cipherSecret != "" {
+		if err := hf.EnableEncryption(c.cipherSecret); err != nil {
+			return nil, err
+		}
+	}
+	c.tables[name] = f
+	return f, nil
+}
@@ -0,0 +1,381 @@
+package godb
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Incrementally maintained table statistics. Once EnableTableStats is
+// called for a table, the catalog keeps its row count and per-column
+// min/max up to date on every committed insert and delete, so COUNT(*),
+// MIN, and MAX over the whole table can be answered without a scan. Stats
+// are persisted to <table>.stats under the catalog root after every
+// committing writer, and reloaded on the next EnableTableStats after a
+// restart. Like the change stream, deltas are buffered per transaction
+// and applied only on commit, so aborted writers never skew the counts.
+
+// ColumnStats is the running min/max of one column, ignoring nulls. Valid
+// is false while the bounds are unknown — before any non-null value has
+// been inserted, or after a delete removed the current extreme (the
+// bounds of the survivors cannot be recovered without a scan, so they are
+// rebuilt lazily by the next TableStats call).
+type ColumnStats struct {
+	Min, Max DBValue
+	Valid    bool
+}
+
+// TableStats is a snapshot of one table's maintained statistics; Columns
+// is indexed by column position in the table's descriptor.
+type TableStats struct {
+	RowCount int64
+	Columns  []ColumnStats
+}
+
+// statsDelta is one buffered tuple-level change awaiting its
+// transaction's outcome.
+type statsDelta struct {
+	table  string
+	insert bool
+	fields []DBValue
+}
+
+// statsTracker holds the live stats for every tracked table plus the
+// per-transaction pending deltas.
+type statsTracker struct {
+	mu      sync.Mutex
+	tables  map[string]*TableStats
+	pending map[TransactionID][]statsDelta
+	catalog *Catalog
+}
+
+// EnableTableStats begins maintaining statistics for table. Existing
+// stats are reloaded from the table's stats file if one survives from an
+// earlier run; otherwise they are built with one full scan and persisted.
+func (c *Catalog) EnableTableStats(table string) error {
+	f, err := c.GetTable(table)
+	if err != nil {
+		return err
+	}
+	// Deltas are recorded on the catalog's constrained wrapper; make sure
+	// the table has one even if it declares no constraints.
+	if _, err := c.constrained(table); err != nil {
+		return err
+	}
+	st := c.statsTracker()
+	st.mu.Lock()
+	_, tracked := st.tables[table]
+	st.mu.Unlock()
+	if tracked {
+		return nil
+	}
+	ts, err := c.loadStats(table, len(f.Descriptor().Fields))
+	if err != nil {
+		return err
+	}
+	if ts == nil {
+		if ts, err = c.scanStats(table, f); err != nil {
+			return err
+		}
+		if err := c.persistStats(table, ts); err != nil {
+			return err
+		}
+	}
+	st.mu.Lock()
+	st.tables[table] = ts
+	st.mu.Unlock()
+	return nil
+}
+
+// TableStats returns a copy of table's maintained statistics. Columns
+// whose bounds were invalidated by a delete are rebuilt here with one
+// scan before being returned.
+func (c *Catalog) TableStats(table string) (*TableStats, error) {
+	if c.stats == nil {
+		return nil, GoDBError{IllegalOperationError, fmt.Sprintf("stats not enabled for table %s", table)}
+	}
+	st := c.stats
+	st.mu.Lock()
+	ts, ok := st.tables[table]
+	st.mu.Unlock()
+	if !ok {
+		return nil, GoDBError{IllegalOperationError, fmt.Sprintf("stats not enabled for table %s", table)}
+	}
+	stale := false
+	st.mu.Lock()
+	for i := range ts.Columns {
+		if !ts.Columns[i].Valid && ts.RowCount > 0 {
+			stale = true
+		}
+	}
+	st.mu.Unlock()
+	if stale {
+		f, err := c.GetTable(table)
+		if err != nil {
+			return nil, err
+		}
+		fresh, err := c.scanStats(table, f)
+		if err != nil {
+			return nil, err
+		}
+		st.mu.Lock()
+		// Keep the maintained row count — it may have moved since the scan
+		// began — and adopt only the rebuilt bounds.
+		ts.Columns = fresh.Columns
+		st.mu.Unlock()
+		if err := c.persistStats(table, ts); err != nil {
+			return nil, err
+		}
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	out := &TableStats{RowCount: ts.RowCount, Columns: append([]ColumnStats(nil), ts.Columns...)}
+	return out, nil
+}
+
+// statsTracker lazily creates the catalog's tracker and hooks it into
+// transaction completion.
+func (c *Catalog) statsTracker() *statsTracker {
+	if c.stats == nil {
+		c.stats = &statsTracker{
+			tables:  make(map[string]*TableStats),
+			pending: make(map[TransactionID][]statsDelta),
+			catalog: c,
+		}
+		c.bufPool.RegisterCommitHook(c.stats.transactionDone)
+	}
+	return c.stats
+}
+
+// recordStatsChange buffers one tuple-level change by tid for application
+// at commit. Called from the constrained wrapper's insert and delete
+// paths.
+func (c *Catalog) recordStatsChange(tid TransactionID, table string, kind ChangeKind, t *Tuple) {
+	if c.stats == nil {
+		return
+	}
+	st := c.stats
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, ok := st.tables[table]; !ok {
+		return
+	}
+	st.pending[tid] = append(st.pending[tid], statsDelta{
+		table:  table,
+		insert: kind == ChangeInsert,
+		fields: append([]DBValue(nil), t.Fields...),
+	})
+}
+
+// transactionDone applies (on commit) or discards (on abort) the deltas
+// buffered for tid, then persists the stats of every touched table.
+func (st *statsTracker) transactionDone(tid TransactionID, committed bool) {
+	st.mu.Lock()
+	deltas := st.pending[tid]
+	delete(st.pending, tid)
+	if !committed || len(deltas) == 0 {
+		st.mu.Unlock()
+		return
+	}
+	touched := make(map[string]bool)
+	for _, d := range deltas {
+		ts := st.tables[d.table]
+		if ts == nil {
+			continue
+		}
+		touched[d.table] = true
+		if d.insert {
+			ts.RowCount++
+			for i, v := range d.fields {
+				if i >= len(ts.Columns) || isNull(v) {
+					continue
+				}
+				cs := &ts.Columns[i]
+				if !cs.Valid {
+					// Bounds of the pre-existing rows are unknown; a new
+					// value cannot repair them.
+					if ts.RowCount == 1 {
+						cs.Min, cs.Max, cs.Valid = v, v, true
+					}
+					continue
+				}
+				if less, err := compareValues(v, cs.Min, OpLt); err == nil && less {
+					cs.Min = v
+				}
+				if more, err := compareValues(v, cs.Max, OpGt); err == nil && more {
+					cs.Max = v
+				}
+			}
+		} else {
+			ts.RowCount--
+			for i, v := range d.fields {
+				if i >= len(ts.Columns) || !ts.Columns[i].Valid || isNull(v) {
+					continue
+				}
+				// Deleting the extreme leaves the survivors' bound unknown.
+				if v == ts.Columns[i].Min || v == ts.Columns[i].Max {
+					ts.Columns[i] = ColumnStats{}
+				}
+			}
+		}
+	}
+	st.mu.Unlock()
+	for table := range touched {
+		st.mu.Lock()
+		ts := st.tables[table]
+		st.mu.Unlock()
+		if err := st.catalog.persistStats(table, ts); err != nil {
+			logger.Debugf("stats: persisting %s failed: %v", table, err)
+		}
+	}
+}
+
+// scanStats builds table's statistics with one full scan under its own
+// transaction.
+func (c *Catalog) scanStats(table string, f DBFile) (*TableStats, error) {
+	tid := NewTID()
+	if err := c.bufPool.BeginTransaction(tid); err != nil {
+		return nil, err
+	}
+	defer c.bufPool.CommitTransaction(tid)
+	iter, err := f.Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	ts := &TableStats{Columns: make([]ColumnStats, len(f.Descriptor().Fields))}
+	for {
+		t, err := iter()
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			return ts, nil
+		}
+		ts.RowCount++
+		for i, v := range t.Fields {
+			if i >= len(ts.Columns) || isNull(v) {
+				continue
+			}
+			cs := &ts.Columns[i]
+			if !cs.Valid {
+				cs.Min, cs.Max, cs.Valid = v, v, true
+				continue
+			}
+			if less, err := compareValues(v, cs.Min, OpLt); err == nil && less {
+				cs.Min = v
+			}
+			if more, err := compareValues(v, cs.Max, OpGt); err == nil && more {
+				cs.Max = v
+			}
+		}
+	}
+}
+
+func (c *Catalog) statsFilePath(table string) string {
+	return filepath.Join(c.rootPath, table+".stats")
+}
+
+// persistStats writes table's statistics to its stats file: a "rows N"
+// line followed by "min <idx> <value>" and "max <idx> <value>" lines for
+// every column with valid bounds. String bounds are quoted (one value per
+// line, so embedded spaces survive the round trip).
+func (c *Catalog) persistStats(table string, ts *TableStats) error {
+	st := c.stats
+	st.mu.Lock()
+	var b strings.Builder
+	fmt.Fprintf(&b, "rows %d\n", ts.RowCount)
+	for i, cs := range ts.Columns {
+		if !cs.Valid {
+			continue
+		}
+		fmt.Fprintf(&b, "min %d %s\n", i, quoteStatValue(cs.Min))
+		fmt.Fprintf(&b, "max %d %s\n", i, quoteStatValue(cs.Max))
+	}
+	st.mu.Unlock()
+	if err := os.WriteFile(c.statsFilePath(table), []byte(b.String()), 0644); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
+
+// loadStats reads table's persisted statistics, returning nil (no error)
+// when no stats file exists.
+func (c *Catalog) loadStats(table string, numCols int) (*TableStats, error) {
+	f, err := os.Open(c.statsFilePath(table))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	defer f.Close()
+	ts := &TableStats{Columns: make([]ColumnStats, numCols)}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, " ", 3)
+		switch {
+		case parts[0] == "rows" && len(parts) == 2:
+			n, err := strconv.ParseInt(parts[1], 10, 64)
+			if err != nil {
+				return nil, GoDBError{MalformedDataError, fmt.Sprintf("bad row count for table %s: %v", table, err)}
+			}
+			ts.RowCount = n
+		case (parts[0] == "min" || parts[0] == "max") && len(parts) == 3:
+			idx, err := strconv.Atoi(parts[1])
+			if err != nil || idx < 0 || idx >= numCols {
+				return nil, GoDBError{MalformedDataError, fmt.Sprintf("bad column index in stats for table %s", table)}
+			}
+			v, err := unquoteStatValue(parts[2])
+			if err != nil {
+				return nil, err
+			}
+			if parts[0] == "min" {
+				ts.Columns[idx].Min = v
+			} else {
+				ts.Columns[idx].Max = v
+			}
+			ts.Columns[idx].Valid = ts.Columns[idx].Min != nil && ts.Columns[idx].Max != nil
+		default:
+			return nil, GoDBError{MalformedDataError, fmt.Sprintf("bad stats line %q for table %s", line, table)}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	return ts, nil
+}
+
+// quoteStatValue renders a bound for the stats file: ints as decimal,
+// strings quoted so embedded spaces survive the round trip.
+func quoteStatValue(v DBValue) string {
+	switch x := v.(type) {
+	case IntField:
+		return strconv.FormatInt(x.Value, 10)
+	case StringField:
+		return strconv.Quote(x.Value)
+	}
+	return strconv.Quote("")
+}
+
+func unquoteStatValue(s string) (DBValue, error) {
+	if strings.HasPrefix(s, `"`) {
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, GoDBError{MalformedDataError, fmt.Sprintf("bad stats value %s: %v", s, err)}
+		}
+		return StringField{v}, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("bad stats value %s: %v", s, err)}
+	}
+	return IntField{v}, nil
+}
@@ -0,0 +1,99 @@
+package godb
+
+// ThetaJoin joins its two children on an arbitrary comparison predicate,
+// for join conditions NewJoin's equality hashing cannot express (e.g.
+// a.salary > b.bonus * 2). Both sides of the predicate are evaluated
+// against the joined tuple, so either may reference columns from both
+// inputs. The implementation is a block nested loop: the left child is
+// buffered in batches of maxBufferSize tuples so the right child is
+// rescanned once per batch rather than once per left tuple.
+type ThetaJoin struct {
+	predLeft, predRight Expr
+	op                  BoolOp
+	left, right         *Operator
+	maxBufferSize       int
+}
+
+// NewThetaJoin returns a theta join of left and right emitting joined
+// tuples for which predLeft op predRight holds.
+func NewThetaJoin(left Operator, predLeft Expr, op BoolOp, predRight Expr, right Operator, maxBufferSize int) (*ThetaJoin, error) {
+	return &ThetaJoin{predLeft, predRight, op, &left, &right, maxBufferSize}, nil
+}
+
+func (j *ThetaJoin) Descriptor() *TupleDesc {
+	return (*j.left).Descriptor().merge((*j.right).Descriptor())
+}
+
+func (j *ThetaJoin) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	leftIter, err := (*j.left).Iterator(tid)
+	if err != nil {
+		return nil, err
+	}
+	var batch []*Tuple
+	var rightIter func() (*Tuple, error)
+	var matches []*Tuple
+	leftDone := false
+
+	loadBatch := func() error {
+		batch = batch[:0]
+		for n := 0; n < j.maxBufferSize; n++ {
+			t, err := leftIter()
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				leftDone = true
+				break
+			}
+			if err := chargeMaterialized(tid, t); err != nil {
+				return err
+			}
+			batch = append(batch, t)
+		}
+		var err error
+		rightIter, err = (*j.right).Iterator(tid)
+		return err
+	}
+
+	return func() (*Tuple, error) {
+		for {
+			if len(matches) > 0 {
+				t := matches[0]
+				matches = matches[1:]
+				return t, nil
+			}
+			if rightIter == nil {
+				if leftDone {
+					return nil, nil
+				}
+				if err := loadBatch(); err != nil {
+					return nil, err
+				}
+				if len(batch) == 0 {
+					return nil, nil
+				}
+			}
+			rt, err := rightIter()
+			if err != nil {
+				return nil, err
+			}
+			if rt == nil {
+				rightIter = nil
+				if leftDone {
+					return nil, nil
+				}
+				continue
+			}
+			for _, lt := range batch {
+				joined := joinTuples(lt, rt)
+				match, err := evalPred(joined, j.predLeft, joined, j.predRight, j.op)
+				if err != nil {
+					return nil, err
+				}
+				if match {
+					matches = append(matches, joined)
+				}
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,299 @@
+package godb
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tiered storage for column files. Old row groups rarely change hands —
+// the store is append-only — so their pages can move to an ObjectStore
+// and stop occupying local disk. A sidecar file (<base>.tier) records,
+// per column, how many leading pages have been offloaded; the local
+// column file then holds only the pages after that watermark, and every
+// local offset is shifted accordingly. Reads of offloaded pages fetch
+// them from the store through a size-capped local page cache, so a warm
+// historical scan costs one download per page, not one per access.
+//
+// Offloading commits through the sidecar: pages are first uploaded
+// (idempotently) and the trimmed local file written under an epoch-named
+// temp file, then the sidecar is atomically replaced, then the temp file
+// renamed into place. AttachObjectStore replays the final rename if a
+// crash interrupted it, and discards temp files from offloads that never
+// committed, so no ordering of crashes loses data.
+
+// defaultTierCacheBytes caps the local page cache for offloaded pages.
+const defaultTierCacheBytes = 64 << 20
+
+// columnTier is the tiering state of one column file.
+type columnTier struct {
+	store      ObjectStore
+	cacheDir   string
+	cacheLimit int64
+	// cold[i] is the number of leading pages of column i that live in
+	// the store; epoch[i] counts offloads, naming temp files.
+	cold  []int
+	epoch []int
+}
+
+// AttachObjectStore enables tiering for the column file, loading (or
+// creating) its sidecar and recovering any interrupted offload. cacheDir
+// holds locally cached copies of offloaded pages. The file must be
+// quiesced: attach before serving transactions.
+func (f *ColumnFile) AttachObjectStore(store ObjectStore, cacheDir string) error {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	t := &columnTier{
+		store:      store,
+		cacheDir:   cacheDir,
+		cacheLimit: defaultTierCacheBytes,
+		cold:       make([]int, len(f.desc.Fields)),
+		epoch:      make([]int, len(f.desc.Fields)),
+	}
+	if err := t.load(f.tierPath()); err != nil {
+		return err
+	}
+	f.tier = t
+	return f.recoverOffload()
+}
+
+func (f *ColumnFile) tierPath() string {
+	return f.baseName + ".tier"
+}
+
+// coldPages returns the number of leading pages of column colNo held in
+// the object store (zero when tiering is not attached).
+func (f *ColumnFile) coldPages(colNo int) int {
+	if f.tier == nil {
+		return 0
+	}
+	return f.tier.cold[colNo]
+}
+
+// tierKey names one offloaded page in the store.
+func (f *ColumnFile) tierKey(colNo, pageNo int) string {
+	return fmt.Sprintf("%s.%s/%08d", filepath.Base(f.baseName), f.desc.Fields[colNo].Fname, pageNo)
+}
+
+// OffloadRowGroups moves every page fully covered by row groups before
+// beforeGroup to the object store and trims the local column files. Only
+// completely written groups may move, so beforeGroup is clamped to the
+// current row count. Like RepairFile, this works on the files directly:
+// the table must be quiesced and its pages absent from the buffer pool.
+func (f *ColumnFile) OffloadRowGroups(beforeGroup int) error {
+	if f.tier == nil {
+		return GoDBError{IllegalOperationError, "no object store attached; call AttachObjectStore first"}
+	}
+	total, err := f.rawNumRows()
+	if err != nil {
+		return err
+	}
+	lastRow := beforeGroup * f.rowGroupSize()
+	if lastRow > total {
+		lastRow = total
+	}
+	type trim struct {
+		colNo int
+		tmp   string
+	}
+	var trims []trim
+	for colNo := range f.desc.Fields {
+		spp := f.slotsPerPage(colNo)
+		target := lastRow / spp
+		cold := f.tier.cold[colNo]
+		if target <= cold {
+			continue
+		}
+		path := f.columnPath(colNo)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return GoDBError{OsError, err.Error()}
+		}
+		for p := cold; p < target; p++ {
+			off := (p - cold) * PageSize
+			if off+PageSize > len(data) {
+				return GoDBError{MalformedDataError, fmt.Sprintf("column %d of %s ends before page %d", colNo, f.baseName, p)}
+			}
+			if err := f.tier.store.Put(f.tierKey(colNo, p), data[off:off+PageSize]); err != nil {
+				return err
+			}
+		}
+		f.tier.epoch[colNo]++
+		tmp := fmt.Sprintf("%s.tier.%d.tmp", path, f.tier.epoch[colNo])
+		if err := os.WriteFile(tmp, data[(target-cold)*PageSize:], 0644); err != nil {
+			return GoDBError{OsError, err.Error()}
+		}
+		f.tier.cold[colNo] = target
+		trims = append(trims, trim{colNo, tmp})
+	}
+	if len(trims) == 0 {
+		return nil
+	}
+	// Commit point: after this rename the offload is durable, and
+	// recoverOffload finishes the file swaps below if we crash first.
+	if err := f.tier.save(f.tierPath()); err != nil {
+		return err
+	}
+	for _, tr := range trims {
+		if err := os.Rename(tr.tmp, f.columnPath(tr.colNo)); err != nil {
+			return GoDBError{OsError, err.Error()}
+		}
+	}
+	return nil
+}
+
+// recoverOffload completes or discards the temp files of an offload that
+// was interrupted between upload and the final renames.
+func (f *ColumnFile) recoverOffload() error {
+	for colNo := range f.desc.Fields {
+		path := f.columnPath(colNo)
+		matches, err := filepath.Glob(path + ".tier.*.tmp")
+		if err != nil {
+			return GoDBError{OsError, err.Error()}
+		}
+		for _, tmp := range matches {
+			var epoch int
+			if _, err := fmt.Sscanf(strings.TrimPrefix(tmp, path+".tier."), "%d.tmp", &epoch); err != nil {
+				continue
+			}
+			if epoch == f.tier.epoch[colNo] {
+				// The sidecar committed this offload; finish the swap.
+				if err := os.Rename(tmp, path); err != nil {
+					return GoDBError{OsError, err.Error()}
+				}
+			} else {
+				os.Remove(tmp)
+			}
+		}
+	}
+	return nil
+}
+
+// tierRead fetches one offloaded page, consulting the local cache first.
+func (f *ColumnFile) tierRead(colNo, pageNo int) ([]byte, error) {
+	key := f.tierKey(colNo, pageNo)
+	cached := filepath.Join(f.tier.cacheDir, filepath.FromSlash(key))
+	if data, err := os.ReadFile(cached); err == nil && len(data) == PageSize {
+		return data, nil
+	}
+	data, err := f.tier.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != PageSize {
+		return nil, GoDBError{MalformedDataError, fmt.Sprintf("object %s is %d bytes, not a page", key, len(data))}
+	}
+	// Cache best-effort: a failed write just means a re-download later.
+	if os.MkdirAll(filepath.Dir(cached), 0755) == nil {
+		if os.WriteFile(cached+".tmp", data, 0644) == nil {
+			os.Rename(cached+".tmp", cached)
+		}
+		f.tier.evictCache()
+	}
+	return data, nil
+}
+
+// evictCache deletes the least recently modified cached pages until the
+// cache fits its budget.
+func (t *columnTier) evictCache() {
+	type entry struct {
+		path string
+		size int64
+		mod  int64
+	}
+	var entries []entry
+	var total int64
+	filepath.WalkDir(t.cacheDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			entries = append(entries, entry{path, info.Size(), info.ModTime().UnixNano()})
+			total += info.Size()
+		}
+		return nil
+	})
+	if total <= t.cacheLimit {
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].mod < entries[j].mod })
+	for _, e := range entries {
+		if total <= t.cacheLimit {
+			return
+		}
+		if os.Remove(e.path) == nil {
+			total -= e.size
+		}
+	}
+}
+
+// rawNumRows computes the row count from column 0's files directly,
+// without going through the buffer pool; callers hold the quiesced-file
+// guarantee of OffloadRowGroups.
+func (f *ColumnFile) rawNumRows() (int, error) {
+	n := f.numColPages(0)
+	if n == 0 {
+		return 0, nil
+	}
+	pg, err := f.readPage(f.pageNoFor(0, n-1))
+	if err != nil {
+		return 0, err
+	}
+	return (n-1)*f.slotsPerPage(0) + pg.(*columnPage).numValues(), nil
+}
+
+// load reads the sidecar, tolerating its absence.
+func (t *columnTier) load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return GoDBError{OsError, err.Error()}
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) != 4 || parts[0] != "col" {
+			return GoDBError{MalformedDataError, fmt.Sprintf("bad tier sidecar line %q", line)}
+		}
+		colNo, err1 := strconv.Atoi(parts[1])
+		cold, err2 := strconv.Atoi(parts[2])
+		epoch, err3 := strconv.Atoi(parts[3])
+		if err1 != nil || err2 != nil || err3 != nil || colNo < 0 || colNo >= len(t.cold) {
+			return GoDBError{MalformedDataError, fmt.Sprintf("bad tier sidecar line %q", line)}
+		}
+		t.cold[colNo] = cold
+		t.epoch[colNo] = epoch
+	}
+	if err := scanner.Err(); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
+
+// save atomically replaces the sidecar.
+func (t *columnTier) save(path string) error {
+	var b strings.Builder
+	for colNo := range t.cold {
+		fmt.Fprintf(&b, "col %d %d %d\n", colNo, t.cold[colNo], t.epoch[colNo])
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	return nil
+}
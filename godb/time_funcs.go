@@ -0,0 +1,101 @@
+package godb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Built-in date/time functions, registered alongside user-defined scalar
+// functions. Until a dedicated timestamp type lands, timestamps are int
+// fields holding Unix seconds in UTC, which composes with the existing
+// aggregation pipeline: GROUP BY date_trunc('day', ts) gives time-series
+// roll-ups, and date arithmetic is plain int arithmetic on the results of
+// these functions.
+
+func init() {
+	RegisterFunction("now", func(args ...DBValue) (DBValue, error) {
+		return IntField{time.Now().Unix()}, nil
+	}, []DBType{}, IntType)
+	RegisterFunction("date_trunc", dateTrunc, []DBType{StringType, IntType}, IntType)
+	RegisterFunction("extract", extractPart, []DBType{StringType, IntType}, IntType)
+	// date_add advances a timestamp by a count of a named unit; negative
+	// counts subtract.
+	RegisterFunction("date_add", dateAdd, []DBType{IntType, StringType, IntType}, IntType)
+}
+
+// dateTrunc truncates a Unix timestamp to the start of the named unit
+// ("year", "month", "day", "hour", "minute") in UTC.
+func dateTrunc(args ...DBValue) (DBValue, error) {
+	unit := strings.ToLower(args[0].(StringField).Value)
+	ts := time.Unix(args[1].(IntField).Value, 0).UTC()
+	var out time.Time
+	switch unit {
+	case "year":
+		out = time.Date(ts.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	case "month":
+		out = time.Date(ts.Year(), ts.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "day":
+		out = time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+	case "hour":
+		out = ts.Truncate(time.Hour)
+	case "minute":
+		out = ts.Truncate(time.Minute)
+	default:
+		return nil, fmt.Errorf("unknown date_trunc unit %q", unit)
+	}
+	return IntField{out.Unix()}, nil
+}
+
+// extractPart pulls the named component ("year", "month", "day", "hour",
+// "minute", "second", "dow") out of a Unix timestamp in UTC.
+func extractPart(args ...DBValue) (DBValue, error) {
+	part := strings.ToLower(args[0].(StringField).Value)
+	ts := time.Unix(args[1].(IntField).Value, 0).UTC()
+	var n int
+	switch part {
+	case "year":
+		n = ts.Year()
+	case "month":
+		n = int(ts.Month())
+	case "day":
+		n = ts.Day()
+	case "hour":
+		n = ts.Hour()
+	case "minute":
+		n = ts.Minute()
+	case "second":
+		n = ts.Second()
+	case "dow":
+		n = int(ts.Weekday())
+	default:
+		return nil, fmt.Errorf("unknown extract part %q", part)
+	}
+	return IntField{int64(n)}, nil
+}
+
+// dateAdd adds n units to a Unix timestamp; calendar units go through
+// time.AddDate so month lengths and leap years are respected.
+func dateAdd(args ...DBValue) (DBValue, error) {
+	ts := time.Unix(args[0].(IntField).Value, 0).UTC()
+	unit := strings.ToLower(args[1].(StringField).Value)
+	n := int(args[2].(IntField).Value)
+	var out time.Time
+	switch unit {
+	case "year":
+		out = ts.AddDate(n, 0, 0)
+	case "month":
+		out = ts.AddDate(0, n, 0)
+	case "day":
+		out = ts.AddDate(0, 0, n)
+	case "hour":
+		out = ts.Add(time.Duration(n) * time.Hour)
+	case "minute":
+		out = ts.Add(time.Duration(n) * time.Minute)
+	case "second":
+		out = ts.Add(time.Duration(n) * time.Second)
+	default:
+		return nil, fmt.Errorf("unknown date_add unit %q", unit)
+	}
+	return IntField{out.Unix()}, nil
+}
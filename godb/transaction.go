@@ -0,0 +1,15 @@
+package godb
+
+import "sync/atomic"
+
+// TransactionID identifies a running transaction. Ids are assigned from a
+// process-wide counter and are never reused within a process.
+type TransactionID *int64
+
+var nextTid int64
+
+// NewTID returns a fresh transaction id.
+func NewTID() TransactionID {
+	id := atomic.AddInt64(&nextTid, 1)
+	return &id
+}
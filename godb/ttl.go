@@ -0,0 +1,229 @@
+package godb
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Row-level TTL. A table's TTL names an int column holding Unix-second
+// timestamps; rows whose timestamp is at least the TTL old are expired.
+// The sweeper deletes expired rows in small batches, each batch its own
+// transaction, so expiry never holds long write locks against foreground
+// work — the natural fit for log and event tables. Between sweeps,
+// SetTTLScanFilter can additionally hide expired-but-not-yet-swept rows
+// from scans of the table. Deletes go through the table's registered
+// file, so constraints, indexes, and change subscribers all observe them;
+// append-only column tables therefore cannot be swept.
+
+// tableTTL is one table's expiry configuration.
+type tableTTL struct {
+	column      string
+	colIdx      int
+	ttl         time.Duration
+	filterScans bool
+}
+
+// SetTableTTL configures expiry for table: rows whose int column holds a
+// Unix-second timestamp older than ttl are considered expired. Calling
+// again replaces the configuration.
+func (c *Catalog) SetTableTTL(table, column string, ttl time.Duration) error {
+	f, err := c.GetTable(table)
+	if err != nil {
+		return err
+	}
+	idx, err := findFieldInTd(FieldType{Fname: column}, f.Descriptor())
+	if err != nil {
+		return err
+	}
+	if f.Descriptor().Fields[idx].Ftype != IntType {
+		return GoDBError{TypeMismatchError, fmt.Sprintf("TTL column %s of table %s is not an int", column, table)}
+	}
+	if ttl <= 0 {
+		return GoDBError{IllegalOperationError, "TTL must be positive"}
+	}
+	// Wrap the table so scans can consult the filter.
+	if _, err := c.constrained(table); err != nil {
+		return err
+	}
+	c.ttlMu.Lock()
+	defer c.ttlMu.Unlock()
+	if c.ttls == nil {
+		c.ttls = make(map[string]*tableTTL)
+	}
+	c.ttls[table] = &tableTTL{column: column, colIdx: idx, ttl: ttl}
+	return nil
+}
+
+// ClearTableTTL removes table's expiry configuration.
+func (c *Catalog) ClearTableTTL(table string) {
+	c.ttlMu.Lock()
+	defer c.ttlMu.Unlock()
+	delete(c.ttls, table)
+}
+
+// SetTTLScanFilter controls whether scans of table skip expired rows that
+// the sweeper has not reached yet.
+func (c *Catalog) SetTTLScanFilter(table string, enabled bool) error {
+	c.ttlMu.Lock()
+	defer c.ttlMu.Unlock()
+	t, ok := c.ttls[table]
+	if !ok {
+		return GoDBError{IllegalOperationError, fmt.Sprintf("table %s has no TTL configured", table)}
+	}
+	t.filterScans = enabled
+	return nil
+}
+
+// ttlFor returns a snapshot of table's TTL configuration, or nil.
+func (c *Catalog) ttlFor(table string) *tableTTL {
+	c.ttlMu.Lock()
+	defer c.ttlMu.Unlock()
+	t, ok := c.ttls[table]
+	if !ok {
+		return nil
+	}
+	cp := *t
+	return &cp
+}
+
+// expired reports whether t's timestamp column is past its TTL at now.
+func (tt *tableTTL) expired(t *Tuple, now time.Time) bool {
+	if tt.colIdx >= len(t.Fields) {
+		return false
+	}
+	ts, ok := t.Fields[tt.colIdx].(IntField)
+	if !ok {
+		return false
+	}
+	return ts.Value <= now.Add(-tt.ttl).Unix()
+}
+
+// applyTTLFilter wraps iter to skip expired rows when table's scan filter
+// is enabled.
+func (c *Catalog) applyTTLFilter(table string, iter func() (*Tuple, error)) func() (*Tuple, error) {
+	tt := c.ttlFor(table)
+	if tt == nil || !tt.filterScans {
+		return iter
+	}
+	now := time.Now()
+	return func() (*Tuple, error) {
+		for {
+			t, err := iter()
+			if err != nil || t == nil {
+				return t, err
+			}
+			if !tt.expired(t, now) {
+				return t, nil
+			}
+		}
+	}
+}
+
+// SweepExpired deletes expired rows from every TTL-configured table,
+// batch rows per transaction, and returns the number deleted. It stops at
+// the first error, leaving already committed batches in place.
+func (c *Catalog) SweepExpired(batch int) (int, error) {
+	if batch <= 0 {
+		batch = 128
+	}
+	c.ttlMu.Lock()
+	tables := make([]string, 0, len(c.ttls))
+	for name := range c.ttls {
+		tables = append(tables, name)
+	}
+	c.ttlMu.Unlock()
+	sort.Strings(tables)
+	total := 0
+	for _, table := range tables {
+		n, err := c.sweepTable(table, batch)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// sweepTable deletes table's expired rows in batches until none remain.
+func (c *Catalog) sweepTable(table string, batch int) (int, error) {
+	f, err := c.GetTable(table)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for {
+		tt := c.ttlFor(table)
+		if tt == nil {
+			return total, nil
+		}
+		now := time.Now()
+		tid := NewTID()
+		if err := c.bufPool.BeginTransaction(tid); err != nil {
+			return total, err
+		}
+		deleted := 0
+		err := func() error {
+			iter, err := f.Iterator(tid)
+			if err != nil {
+				return err
+			}
+			// Collect first: deleting from under a live iterator is
+			// undefined for some storage engines.
+			var doomed []*Tuple
+			for len(doomed) < batch {
+				t, err := iter()
+				if err != nil {
+					return err
+				}
+				if t == nil {
+					break
+				}
+				if tt.expired(t, now) {
+					doomed = append(doomed, t)
+				}
+			}
+			for _, t := range doomed {
+				if err := f.deleteTuple(t, tid); err != nil {
+					return err
+				}
+				deleted++
+			}
+			return nil
+		}()
+		if err != nil {
+			c.bufPool.AbortTransaction(tid)
+			return total, err
+		}
+		c.bufPool.CommitTransaction(tid)
+		total += deleted
+		if deleted < batch {
+			return total, nil
+		}
+	}
+}
+
+// StartTTLSweeper sweeps every interval until the returned stop function
+// is called. Sweep errors are logged and the sweeper keeps running.
+func (c *Catalog) StartTTLSweeper(interval time.Duration, batch int) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if n, err := c.SweepExpired(batch); err != nil {
+					logger.Debugf("ttl sweep: %v (after %d deletes)", err, n)
+				} else if n > 0 {
+					logger.Debugf("ttl sweep: deleted %d expired rows", n)
+				}
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
@@ -0,0 +1,279 @@
+package godb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// DBValue is the value of a single field of a tuple.
+type DBValue interface {
+	isDBValue()
+}
+
+// IntField is an int64-valued field.
+type IntField struct {
+	Value int64
+}
+
+func (IntField) isDBValue() {}
+
+// StringField is a string-valued field, truncated to StringLength bytes when
+// written to disk.
+type StringField struct {
+	Value string
+}
+
+func (StringField) isDBValue() {}
+
+// Tuple is one row of a table, along with the record id identifying where it
+// is stored, if it came from a file. Record ids are RecordID values
+// assigned by the storage layer.
+type Tuple struct {
+	Desc   TupleDesc
+	Fields []DBValue
+	Rid    any
+}
+
+// writeTo serializes t to b using a fixed-width binary encoding: 8 bytes per
+// int or decimal field, StringLength bytes per string field. Decimals are
+// written as their unscaled integer at the column's declared scale, so a
+// value at a smaller scale is rescaled (exactly) first.
+func (t *Tuple) writeTo(b *bytes.Buffer) error {
+	for i, f := range t.Desc.Fields {
+		switch f.Ftype {
+		case IntType:
+			v, ok := t.Fields[i].(IntField)
+			if !ok {
+				return GoDBError{TypeMismatchError, "expected int field"}
+			}
+			if err := binary.Write(b, binary.LittleEndian, v.Value); err != nil {
+				return err
+			}
+		case DecimalType:
+			v, ok := t.Fields[i].(DecimalField)
+			if !ok {
+				return GoDBError{TypeMismatchError, "expected decimal field"}
+			}
+			d, err := v.rescale(f.Scale)
+			if err != nil {
+				return err
+			}
+			if err := binary.Write(b, binary.LittleEndian, d.Unscaled); err != nil {
+				return err
+			}
+		case StringType:
+			v, ok := t.Fields[i].(StringField)
+			if !ok {
+				return GoDBError{TypeMismatchError, "expected string field"}
+			}
+			s := v.Value
+			if len(s) > StringLength {
+				s = s[:StringLength]
+			}
+			buf := make([]byte, StringLength)
+			copy(buf, s)
+			if _, err := b.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readTupleFrom reads one tuple with the supplied descriptor from b, undoing
+// the encoding of writeTo. Trailing zero bytes are stripped from strings.
+func readTupleFrom(b *bytes.Buffer, desc *TupleDesc) (*Tuple, error) {
+	fields := make([]DBValue, 0, len(desc.Fields))
+	for _, f := range desc.Fields {
+		switch f.Ftype {
+		case IntType:
+			var v int64
+			if err := binary.Read(b, binary.LittleEndian, &v); err != nil {
+				return nil, err
+			}
+			fields = append(fields, IntField{v})
+		case DecimalType:
+			var v int64
+			if err := binary.Read(b, binary.LittleEndian, &v); err != nil {
+				return nil, err
+			}
+			fields = append(fields, DecimalField{Unscaled: v, Scale: f.Scale})
+		case StringType:
+			buf := make([]byte, StringLength)
+			if _, err := b.Read(buf); err != nil {
+				return nil, err
+			}
+			fields = append(fields, StringField{strings.TrimRight(string(buf), "\x00")})
+		}
+	}
+	return &Tuple{Desc: *desc.copy(), Fields: fields}, nil
+}
+
+// readTupleFieldsFrom reads only the fields at the supplied indexes from one
+// fixed-width record in b, skipping over the bytes of every other field.
+// fields must be sorted ascending; narrow is the descriptor of the resulting
+// tuple (desc restricted to fields).
+func readTupleFieldsFrom(b *bytes.Buffer, desc, narrow *TupleDesc, fields []int) (*Tuple, error) {
+	out := make([]DBValue, 0, len(fields))
+	next := 0
+	for i, f := range desc.Fields {
+		width := 8
+		if f.Ftype == StringType {
+			width = StringLength
+		}
+		if next >= len(fields) || fields[next] != i {
+			b.Next(width)
+			continue
+		}
+		next++
+		switch f.Ftype {
+		case IntType:
+			var v int64
+			if err := binary.Read(b, binary.LittleEndian, &v); err != nil {
+				return nil, err
+			}
+			out = append(out, IntField{v})
+		case DecimalType:
+			var v int64
+			if err := binary.Read(b, binary.LittleEndian, &v); err != nil {
+				return nil, err
+			}
+			out = append(out, DecimalField{Unscaled: v, Scale: f.Scale})
+		case StringType:
+			buf := make([]byte, StringLength)
+			if _, err := b.Read(buf); err != nil {
+				return nil, err
+			}
+			out = append(out, StringField{strings.TrimRight(string(buf), "\x00")})
+		}
+	}
+	return &Tuple{Desc: *narrow.copy(), Fields: out}, nil
+}
+
+// equals returns true if t and t2 have the same schema and field values.
+// Record ids are not compared.
+func (t *Tuple) equals(t2 *Tuple) bool {
+	if t == nil || t2 == nil {
+		return t == t2
+	}
+	if !t.Desc.equals(&t2.Desc) || len(t.Fields) != len(t2.Fields) {
+		return false
+	}
+	for i, f := range t.Fields {
+		if f != t2.Fields[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// joinTuples concatenates two tuples into one, merging their descriptors.
+// Either argument may be nil.
+func joinTuples(t1 *Tuple, t2 *Tuple) *Tuple {
+	if t1 == nil {
+		return t2
+	}
+	if t2 == nil {
+		return t1
+	}
+	fields := make([]DBValue, 0, len(t1.Fields)+len(t2.Fields))
+	fields = append(fields, t1.Fields...)
+	fields = append(fields, t2.Fields...)
+	return &Tuple{Desc: *t1.Desc.merge(&t2.Desc), Fields: fields}
+}
+
+type orderByState int
+
+const (
+	OrderedLessThan orderByState = iota
+	OrderedEqual
+	OrderedGreaterThan
+)
+
+// compareField compares t and t2 on the value of expression field, returning
+// OrderedLessThan, OrderedEqual, or OrderedGreaterThan.
+func (t *Tuple) compareField(t2 *Tuple, field Expr) (orderByState, error) {
+	v1, err := field.EvalExpr(t)
+	if err != nil {
+		return OrderedEqual, err
+	}
+	v2, err := field.EvalExpr(t2)
+	if err != nil {
+		return OrderedEqual, err
+	}
+	switch x1 := v1.(type) {
+	case IntField:
+		x2, ok := v2.(IntField)
+		if !ok {
+			return OrderedEqual, GoDBError{TypeMismatchError, "cannot compare int to non-int"}
+		}
+		switch {
+		case x1.Value < x2.Value:
+			return OrderedLessThan, nil
+		case x1.Value > x2.Value:
+			return OrderedGreaterThan, nil
+		}
+		return OrderedEqual, nil
+	case DecimalField:
+		x2, ok := v2.(DecimalField)
+		if !ok {
+			return OrderedEqual, GoDBError{TypeMismatchError, "cannot compare decimal to non-decimal"}
+		}
+		switch compareDecimals(x1, x2) {
+		case -1:
+			return OrderedLessThan, nil
+		case 1:
+			return OrderedGreaterThan, nil
+		}
+		return OrderedEqual, nil
+	case StringField:
+		x2, ok := v2.(StringField)
+		if !ok {
+			return OrderedEqual, GoDBError{TypeMismatchError, "cannot compare string to non-string"}
+		}
+		switch cmp := collate().Compare(x1.Value, x2.Value); {
+		case cmp < 0:
+			return OrderedLessThan, nil
+		case cmp > 0:
+			return OrderedGreaterThan, nil
+		}
+		return OrderedEqual, nil
+	}
+	return OrderedEqual, GoDBError{TypeMismatchError, "unknown field type"}
+}
+
+// project returns a new tuple containing only the named fields, in order.
+func (t *Tuple) project(fields []FieldType) (*Tuple, error) {
+	out := make([]DBValue, 0, len(fields))
+	outFields := make([]FieldType, 0, len(fields))
+	for _, f := range fields {
+		idx, err := findFieldInTd(f, &t.Desc)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t.Fields[idx])
+		outFields = append(outFields, t.Desc.Fields[idx])
+	}
+	return &Tuple{Desc: TupleDesc{Fields: outFields}, Fields: out, Rid: t.Rid}, nil
+}
+
+// PrettyPrintString formats the tuple for debugging output.
+func (t *Tuple) PrettyPrintString() string {
+	var sb strings.Builder
+	for i, f := range t.Fields {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		switch v := f.(type) {
+		case IntField:
+			sb.WriteString(fmt.Sprintf("%d", v.Value))
+		case DecimalField:
+			sb.WriteString(v.String())
+		case StringField:
+			sb.WriteString(v.Value)
+		}
+	}
+	return sb.String()
+}
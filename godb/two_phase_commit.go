@@ -0,0 +1,250 @@
+package godb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Two-phase commit for transactions spanning several buffer pools (and
+// hence catalogs or databases). The coordinator first collects undo images
+// of every page the transaction dirtied and persists them in a prepare
+// record, then asks each pool to force its dirty pages to disk while
+// keeping all locks (phase one). Once every pool has prepared, a commit
+// marker is persisted and locks are released (phase two). If the process
+// dies between the phases, Recover consults the records on disk: a
+// transaction with a commit marker is already durable, and one without is
+// rolled back by writing its undo images back.
+
+// undoRecord is the pre-prepare disk image of one page.
+type undoRecord struct {
+	Path   string `json:"path"`
+	PageNo int    `json:"pageNo"`
+	Image  []byte `json:"image"`
+}
+
+// prepareRecord is the persistent state written before phase one flushes.
+type prepareRecord struct {
+	Tid  int64        `json:"tid"`
+	Undo []undoRecord `json:"undo"`
+}
+
+// TxCoordinator drives two-phase commit, journaling prepare and commit
+// records under its directory so in-doubt transactions can be resolved
+// after a crash.
+type TxCoordinator struct {
+	dir string
+}
+
+// NewTxCoordinator returns a coordinator journaling under dir.
+func NewTxCoordinator(dir string) (*TxCoordinator, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, GoDBError{OsError, err.Error()}
+	}
+	return &TxCoordinator{dir: dir}, nil
+}
+
+func (tc *TxCoordinator) preparePath(tid int64) string {
+	return filepath.Join(tc.dir, fmt.Sprintf("%d.prepare", tid))
+}
+
+func (tc *TxCoordinator) commitPath(tid int64) string {
+	return filepath.Join(tc.dir, fmt.Sprintf("%d.commit", tid))
+}
+
+// CommitAll atomically commits tid across pools: either every pool's
+// changes become durable or none do. On any prepare failure the
+// transaction is rolled back everywhere and the error returned.
+func (tc *TxCoordinator) CommitAll(tid TransactionID, pools ...*BufferPool) error {
+	rec := prepareRecord{Tid: *tid}
+	for _, bp := range pools {
+		undo, err := bp.collectUndo(tid)
+		if err != nil {
+			for _, p := range pools {
+				p.AbortTransaction(tid)
+			}
+			return err
+		}
+		rec.Undo = append(rec.Undo, undo...)
+	}
+	buf, err := json.Marshal(&rec)
+	if err != nil {
+		return err
+	}
+	if err := writeFileSynced(tc.preparePath(rec.Tid), buf); err != nil {
+		for _, p := range pools {
+			p.AbortTransaction(tid)
+		}
+		return err
+	}
+	// Phase one: force every pool's dirty pages while keeping locks.
+	for i, bp := range pools {
+		if err := bp.flushPrepared(tid); err != nil {
+			applyUndo(rec.Undo)
+			for j, p := range pools {
+				if j <= i {
+					p.abortPrepared(tid)
+				} else {
+					p.AbortTransaction(tid)
+				}
+			}
+			os.Remove(tc.preparePath(rec.Tid))
+			return err
+		}
+	}
+	// Phase two: the commit marker is the decision; once durable, the
+	// transaction commits even if we crash before releasing locks.
+	if err := writeFileSynced(tc.commitPath(rec.Tid), nil); err != nil {
+		applyUndo(rec.Undo)
+		for _, p := range pools {
+			p.abortPrepared(tid)
+		}
+		os.Remove(tc.preparePath(rec.Tid))
+		return err
+	}
+	for _, bp := range pools {
+		bp.finishPrepared(tid)
+	}
+	os.Remove(tc.commitPath(rec.Tid))
+	os.Remove(tc.preparePath(rec.Tid))
+	return nil
+}
+
+// Recover resolves transactions left in doubt by a crash: prepared
+// transactions without a commit marker are rolled back by restoring their
+// undo images; those with a marker are already durable. Call before
+// opening the database for new work.
+func (tc *TxCoordinator) Recover() error {
+	matches, err := filepath.Glob(filepath.Join(tc.dir, "*.prepare"))
+	if err != nil {
+		return err
+	}
+	for _, path := range matches {
+		commitPath := strings.TrimSuffix(path, ".prepare") + ".commit"
+		if _, err := os.Stat(commitPath); err == nil {
+			// Decision was commit; phase-one flushes are already on disk.
+			os.Remove(commitPath)
+			os.Remove(path)
+			continue
+		}
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return GoDBError{OsError, err.Error()}
+		}
+		var rec prepareRecord
+		if err := json.Unmarshal(buf, &rec); err != nil {
+			return GoDBError{MalformedDataError, fmt.Sprintf("bad prepare record %s: %v", path, err)}
+		}
+		if err := applyUndo(rec.Undo); err != nil {
+			return err
+		}
+		os.Remove(path)
+	}
+	return nil
+}
+
+// applyUndo writes pre-prepare page images back to their files.
+func applyUndo(undo []undoRecord) error {
+	for _, u := range undo {
+		f, err := os.OpenFile(u.Path, os.O_RDWR, 0644)
+		if err != nil {
+			return GoDBError{OsError, err.Error()}
+		}
+		err = writePageAt(f, u.Path, int64(u.PageNo)*int64(PageSize), u.Image)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFileSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return GoDBError{OsError, err.Error()}
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return GoDBError{OsError, err.Error()}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return GoDBError{OsError, err.Error()}
+	}
+	return f.Close()
+}
+
+// collectUndo returns the current on-disk images of every heap page tid
+// has dirtied in this pool, read before phase one overwrites them.
+func (bp *BufferPool) collectUndo(tid TransactionID) ([]undoRecord, error) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	var undo []undoRecord
+	for key := range bp.pagesHeld[tid] {
+		pg, ok := bp.cachedPage(key)
+		if !ok || !pg.isDirty() {
+			continue
+		}
+		hp, ok := pg.(*heapPage)
+		if !ok {
+			continue
+		}
+		hf := hp.getFile().(*HeapFile)
+		file, err := os.Open(hf.fileName)
+		if err != nil {
+			return nil, GoDBError{OsError, err.Error()}
+		}
+		image := make([]byte, PageSize)
+		_, err = file.ReadAt(image, int64(hp.pageNo)*int64(PageSize))
+		file.Close()
+		if err != nil {
+			return nil, GoDBError{OsError, err.Error()}
+		}
+		undo = append(undo, undoRecord{Path: hf.fileName, PageNo: hp.pageNo, Image: image})
+	}
+	return undo, nil
+}
+
+// flushPrepared forces tid's dirty pages to disk without releasing any
+// locks; the transaction stays prepared until finishPrepared or
+// abortPrepared.
+func (bp *BufferPool) flushPrepared(tid TransactionID) error {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	for key := range bp.pagesHeld[tid] {
+		if pg, ok := bp.cachedPage(key); ok && pg.isDirty() {
+			if err := pg.getFile().flushPage(pg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// finishPrepared completes a prepared transaction: its pages are already
+// on disk, so only locks and bookkeeping remain.
+func (bp *BufferPool) finishPrepared(tid TransactionID) {
+	bp.mu.Lock()
+	bp.releaseLocks(tid)
+	delete(bp.running, tid)
+	bp.mu.Unlock()
+	metrics.TransactionCommitted()
+	bp.runCommitHooks(tid, true)
+}
+
+// abortPrepared rolls back a prepared transaction. The caller has already
+// restored undo images on disk; the stale cached copies are dropped here.
+func (bp *BufferPool) abortPrepared(tid TransactionID) {
+	bp.mu.Lock()
+	for key := range bp.pagesHeld[tid] {
+		bp.removeCached(key)
+	}
+	bp.releaseLocks(tid)
+	delete(bp.running, tid)
+	bp.mu.Unlock()
+	metrics.TransactionAborted()
+	bp.runCommitHooks(tid, false)
+}
@@ -0,0 +1,76 @@
+package godb
+
+import (
+	"sort"
+	"time"
+)
+
+// TransactionInfo is a snapshot of one running transaction, as reported by
+// ActiveTransactions. Transaction ids are exposed as their int64 values so
+// callers (and the HTTP server) can serialize and echo them back to
+// KillTransaction's lookup by id.
+type TransactionInfo struct {
+	// ID is the transaction's id value.
+	ID int64
+	// Started is when BeginTransaction was called.
+	Started time.Time
+	// PagesLocked and FilesLocked count the locks currently held.
+	PagesLocked int
+	FilesLocked int
+	// PagesDirtied counts cached pages this transaction has modified.
+	PagesDirtied int
+	// WaitingOn lists the ids of transactions this one is blocked behind,
+	// empty if it is runnable.
+	WaitingOn []int64
+	// Doomed reports that the transaction has been chosen as a deadlock
+	// victim or killed, and will abort at its next page access.
+	Doomed bool
+}
+
+// ActiveTransactions returns a snapshot of every running transaction,
+// ordered by id (and therefore by start order).
+func (bp *BufferPool) ActiveTransactions() []TransactionInfo {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	infos := make([]TransactionInfo, 0, len(bp.running))
+	for tid, started := range bp.running {
+		info := TransactionInfo{
+			ID:          *tid,
+			Started:     started,
+			PagesLocked: len(bp.pagesHeld[tid]),
+			FilesLocked: len(bp.filesHeld[tid]),
+		}
+		for key := range bp.pagesHeld[tid] {
+			if pg, ok := bp.cachedPage(key); ok && pg.isDirty() {
+				info.PagesDirtied++
+			}
+		}
+		for blocker := range bp.waitsFor[tid] {
+			info.WaitingOn = append(info.WaitingOn, *blocker)
+		}
+		sort.Slice(info.WaitingOn, func(i, j int) bool { return info.WaitingOn[i] < info.WaitingOn[j] })
+		_, info.Doomed = bp.doomed[tid]
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// KillTransaction marks the running transaction with the given id for
+// abort: its next page access (or its current lock wait) fails with an
+// IllegalTransactionError, at which point the application's normal error
+// handling aborts it and releases its locks. A transaction that never
+// touches another page is not interrupted. Returns an error if no such
+// transaction is running.
+func (bp *BufferPool) KillTransaction(id int64) error {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	for tid := range bp.running {
+		if *tid == id {
+			bp.doomed[tid] = GoDBError{IllegalTransactionError, "transaction killed by administrator"}
+			bp.lockCond.Broadcast()
+			return nil
+		}
+	}
+	return GoDBError{IllegalTransactionError, "no running transaction with that id"}
+}
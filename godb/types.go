@@ -0,0 +1,164 @@
+package godb
+
+import "fmt"
+
+// PageSize is the number of bytes in a single on-disk page.
+const PageSize int = 4096
+
+// StringLength is the fixed number of bytes used to serialize a string field.
+const StringLength int = 32
+
+// DBType is the type of a field in a tuple.
+type DBType int
+
+const (
+	IntType DBType = iota
+	StringType
+	DecimalType
+)
+
+func (t DBType) String() string {
+	switch t {
+	case IntType:
+		return "int"
+	case StringType:
+		return "string"
+	case DecimalType:
+		return "decimal"
+	}
+	return "unknown"
+}
+
+// FieldType describes one column of a table: its name, the name of the table
+// it belongs to (possibly empty), and its type. Decimal columns additionally
+// carry a precision (total significant digits, advisory) and a scale
+// (fractional digits, which fixes the column's representation); both are
+// zero for other types.
+type FieldType struct {
+	Fname          string
+	TableQualifier string
+	Ftype          DBType
+	Precision      int
+	Scale          int
+}
+
+// TupleDesc describes the schema of a tuple as an ordered list of fields.
+type TupleDesc struct {
+	Fields []FieldType
+}
+
+// equals returns true if the two descriptors have the same fields, in the
+// same order, with the same names and types.
+func (d *TupleDesc) equals(d2 *TupleDesc) bool {
+	if len(d.Fields) != len(d2.Fields) {
+		return false
+	}
+	for i, f := range d.Fields {
+		f2 := d2.Fields[i]
+		if f.Fname != f2.Fname || f.Ftype != f2.Ftype || f.Scale != f2.Scale {
+			return false
+		}
+	}
+	return true
+}
+
+// copy returns a deep copy of the descriptor.
+func (d *TupleDesc) copy() *TupleDesc {
+	fields := make([]FieldType, len(d.Fields))
+	copy(fields, d.Fields)
+	return &TupleDesc{Fields: fields}
+}
+
+// merge returns a new descriptor containing the fields of desc followed by
+// the fields of desc2.
+func (d *TupleDesc) merge(d2 *TupleDesc) *TupleDesc {
+	fields := make([]FieldType, 0, len(d.Fields)+len(d2.Fields))
+	fields = append(fields, d.Fields...)
+	fields = append(fields, d2.Fields...)
+	return &TupleDesc{Fields: fields}
+}
+
+// findFieldInTd locates the position of field f in desc, matching on name and
+// (if supplied) table qualifier. It returns an error if the field is
+// ambiguous or absent.
+func findFieldInTd(field FieldType, desc *TupleDesc) (int, error) {
+	found := -1
+	for i, f := range desc.Fields {
+		if f.Fname != field.Fname {
+			continue
+		}
+		if field.TableQualifier != "" && f.TableQualifier != "" && f.TableQualifier != field.TableQualifier {
+			continue
+		}
+		if found != -1 {
+			return 0, GoDBError{AmbiguousNameError, fmt.Sprintf("field %s is ambiguous", field.Fname)}
+		}
+		found = i
+	}
+	if found == -1 {
+		return 0, GoDBError{FieldNotFoundError, fmt.Sprintf("field %s not found", field.Fname)}
+	}
+	return found, nil
+}
+
+// bytesPerTuple returns the number of bytes used to serialize one tuple with
+// this descriptor.
+func (d *TupleDesc) bytesPerTuple() int {
+	sz := 0
+	for _, f := range d.Fields {
+		switch f.Ftype {
+		case IntType, DecimalType:
+			sz += 8
+		case StringType:
+			sz += StringLength
+		}
+	}
+	return sz
+}
+
+// GoDBErrorCode identifies the category of a GoDBError.
+type GoDBErrorCode int
+
+const (
+	TupleNotFoundError GoDBErrorCode = iota
+	PageFullError
+	IncompatibleTypesError
+	TypeMismatchError
+	MalformedDataError
+	BufferPoolFullError
+	ParseError
+	DuplicateNameError
+	NoSuchTableError
+	AmbiguousNameError
+	IllegalOperationError
+	DeadlockError
+	IllegalTransactionError
+	FieldNotFoundError
+	OsError
+	ResourceExceededError
+	TimeoutError
+)
+
+// GoDBError is the error type returned by most GoDB operations; the code
+// lets callers distinguish classes of failure without string matching.
+type GoDBError struct {
+	code      GoDBErrorCode
+	errString string
+}
+
+func (e GoDBError) Error() string {
+	return fmt.Sprintf("err: %s; code: %d", e.errString, e.code)
+}
+
+// Code returns the error's category, for callers outside the package.
+func (e GoDBError) Code() GoDBErrorCode {
+	return e.code
+}
+
+// RWPerm is the permission with which a transaction requests a page.
+type RWPerm int
+
+const (
+	ReadPerm RWPerm = iota
+	WritePerm
+)
@@ -0,0 +1,186 @@
+package godb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ValidatePlan checks a plan's internal consistency before execution:
+// every field reference must resolve in its operator's input descriptor,
+// comparison and join-key types must match, projected output names must be
+// unique, and SUM/AVG inputs must be int. All problems found are returned
+// in one error (joined with errors.Join) rather than surfacing one at a
+// time mid-iteration; a nil result means the plan is safe to run as far as
+// static checks can tell.
+func ValidatePlan(op Operator) error {
+	v := &planValidator{}
+	v.plan(op)
+	return errors.Join(v.errs...)
+}
+
+// planValidator accumulates every problem found while walking a plan.
+type planValidator struct {
+	errs []error
+}
+
+func (v *planValidator) addf(format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+// plan validates one operator and recurses into its children. Operators
+// not listed (scans, views, and other pass-throughs) introduce no field
+// references of their own and terminate the walk.
+func (v *planValidator) plan(op Operator) {
+	switch o := op.(type) {
+	case *Filter:
+		desc := o.child.Descriptor()
+		v.expr("filter", o.left, desc)
+		v.expr("filter", o.right, desc)
+		v.comparable("filter", o.left, o.right, desc)
+		v.plan(o.child)
+	case *ConjunctiveFilter:
+		desc := o.child.Descriptor()
+		for _, c := range o.conjuncts {
+			v.expr("filter", c.Left, desc)
+			v.expr("filter", c.Right, desc)
+			v.comparable("filter", c.Left, c.Right, desc)
+		}
+		v.plan(o.child)
+	case *Project:
+		desc := o.child.Descriptor()
+		for _, e := range o.selectFields {
+			v.expr("projection", e, desc)
+		}
+		seen := make(map[string]bool)
+		for _, name := range o.outputNames {
+			if seen[name] {
+				v.addf("projection: duplicate output name %s", name)
+			}
+			seen[name] = true
+		}
+		v.plan(o.child)
+	case *EqualityJoin:
+		leftDesc := (*o.left).Descriptor()
+		rightDesc := (*o.right).Descriptor()
+		v.expr("join", o.leftField, leftDesc)
+		v.expr("join", o.rightField, rightDesc)
+		lt, lok := resolvedType(o.leftField, leftDesc)
+		rt, rok := resolvedType(o.rightField, rightDesc)
+		if lok && rok && lt != rt {
+			v.addf("join: key types differ: %s vs %s", lt, rt)
+		}
+		v.plan(*o.left)
+		v.plan(*o.right)
+	case *ThetaJoin:
+		joined := (*o.left).Descriptor().merge((*o.right).Descriptor())
+		v.expr("join", o.predLeft, joined)
+		v.expr("join", o.predRight, joined)
+		v.comparable("join", o.predLeft, o.predRight, joined)
+		v.plan(*o.left)
+		v.plan(*o.right)
+	case *Aggregator:
+		desc := o.child.Descriptor()
+		for _, e := range o.groupByFields {
+			v.expr("group by", e, desc)
+		}
+		for _, st := range o.newAggState {
+			e := aggStateExpr(st)
+			if e != nil {
+				v.expr("aggregate", e, desc)
+			}
+			name := ""
+			switch st.(type) {
+			case *SumAggState:
+				name = "SUM"
+			case *AvgAggState:
+				name = "AVG"
+			}
+			if name == "" || e == nil {
+				continue
+			}
+			if t, ok := resolvedType(e, desc); ok && t != IntType && t != DecimalType {
+				v.addf("aggregate: %s requires a numeric input, got %s", name, t)
+			}
+		}
+		v.plan(o.child)
+	case *OrderBy:
+		desc := o.child.Descriptor()
+		for _, e := range o.orderBy {
+			v.expr("order by", e, desc)
+		}
+		v.plan(o.child)
+	case *LimitOp:
+		desc := o.child.Descriptor()
+		v.expr("limit", o.limitTups, desc)
+		if t, ok := resolvedType(o.limitTups, desc); ok && t != IntType {
+			v.addf("limit: count must be an int, got %s", t)
+		}
+		v.plan(o.child)
+	}
+}
+
+// expr checks that every field reference inside e resolves in desc,
+// recursing through the composite expression kinds.
+func (v *planValidator) expr(node string, e Expr, desc *TupleDesc) {
+	switch x := e.(type) {
+	case *FieldExpr:
+		if _, err := findFieldInTd(x.selectField, desc); err != nil {
+			v.addf("%s: %w", node, err)
+		}
+	case *ArithExpr:
+		v.expr(node, x.left, desc)
+		v.expr(node, x.right, desc)
+		for _, side := range []Expr{x.left, x.right} {
+			if t, ok := resolvedType(side, desc); ok && t != IntType && t != DecimalType {
+				v.addf("%s: arithmetic on %s operand", node, t)
+			}
+		}
+	case *CaseExpr:
+		for _, w := range x.whens {
+			v.expr(node, w.Left, desc)
+			v.expr(node, w.Right, desc)
+			v.expr(node, w.Then, desc)
+			v.comparable(node, w.Left, w.Right, desc)
+		}
+		v.expr(node, x.elseExpr, desc)
+	case *FuncExpr:
+		for _, a := range x.args {
+			v.expr(node, a, desc)
+		}
+	case *CastExpr:
+		v.expr(node, x.child, desc)
+	case *CoalesceExpr:
+		for _, a := range x.args {
+			v.expr(node, a, desc)
+		}
+	case *NullIfExpr:
+		v.expr(node, x.a, desc)
+		v.expr(node, x.b, desc)
+	}
+}
+
+// comparable reports a mismatch when both sides of a comparison resolve to
+// different types. Unresolvable sides are skipped — expr has already
+// reported those.
+func (v *planValidator) comparable(node string, left, right Expr, desc *TupleDesc) {
+	lt, lok := resolvedType(left, desc)
+	rt, rok := resolvedType(right, desc)
+	if lok && rok && lt != rt {
+		v.addf("%s: cannot compare %s to %s", node, lt, rt)
+	}
+}
+
+// resolvedType reports the type e produces against desc. Unlike
+// GetExprType it looks field references up in the descriptor, so a
+// reference built from a bare name reports the table's declared type; it
+// returns ok=false when the reference does not resolve.
+func resolvedType(e Expr, desc *TupleDesc) (DBType, bool) {
+	if fe, ok := e.(*FieldExpr); ok {
+		idx, err := findFieldInTd(fe.selectField, desc)
+		if err != nil {
+			return 0, false
+		}
+		return desc.Fields[idx].Ftype, true
+	}
+	return e.GetExprType().Ftype, true
+}
@@ -0,0 +1,51 @@
+package godb
+
+import "fmt"
+
+// ValuesOp emits a fixed in-memory list of tuples with a declared schema,
+// so INSERT ... VALUES and small test inputs need no backing file or CSV.
+type ValuesOp struct {
+	desc TupleDesc
+	rows [][]DBValue
+}
+
+// NewValuesOp returns an operator emitting one tuple of desc per row. Every
+// row must have one value of the declared type per field.
+func NewValuesOp(desc TupleDesc, rows [][]DBValue) (*ValuesOp, error) {
+	for i, row := range rows {
+		if len(row) != len(desc.Fields) {
+			return nil, GoDBError{MalformedDataError, fmt.Sprintf("row %d has %d values, expected %d", i, len(row), len(desc.Fields))}
+		}
+		for j, v := range row {
+			var got DBType
+			switch v.(type) {
+			case IntField:
+				got = IntType
+			case StringField:
+				got = StringType
+			default:
+				return nil, GoDBError{TypeMismatchError, fmt.Sprintf("row %d value %d has unknown type", i, j)}
+			}
+			if got != desc.Fields[j].Ftype {
+				return nil, GoDBError{TypeMismatchError, fmt.Sprintf("row %d value %d does not match field %s", i, j, desc.Fields[j].Fname)}
+			}
+		}
+	}
+	return &ValuesOp{desc: desc, rows: rows}, nil
+}
+
+func (v *ValuesOp) Descriptor() *TupleDesc {
+	return &v.desc
+}
+
+func (v *ValuesOp) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	i := 0
+	return func() (*Tuple, error) {
+		if i >= len(v.rows) {
+			return nil, nil
+		}
+		t := &Tuple{Desc: *v.desc.copy(), Fields: v.rows[i]}
+		i++
+		return t, nil
+	}, nil
+}
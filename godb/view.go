@@ -0,0 +1,85 @@
+package godb
+
+import "fmt"
+
+// View is a named query stored in the catalog. Scanning a view compiles its
+// SQL text against the current catalog, so views always reflect the latest
+// table contents and schemas.
+type View struct {
+	catalog *Catalog
+	name    string
+	query   string
+}
+
+// AddView registers query under name. The query is compiled once up front
+// to catch errors early, and recompiled on each use. View names share a
+// namespace with tables.
+func (c *Catalog) AddView(name string, query string) error {
+	if _, ok := c.tables[name]; ok {
+		return GoDBError{DuplicateNameError, fmt.Sprintf("table %s already exists", name)}
+	}
+	if _, ok := c.views[name]; ok {
+		return GoDBError{DuplicateNameError, fmt.Sprintf("view %s already exists", name)}
+	}
+	if _, err := ParseQuery(c, query); err != nil {
+		return err
+	}
+	if c.views == nil {
+		c.views = make(map[string]string)
+	}
+	c.views[name] = query
+	return nil
+}
+
+// DropView removes the named view.
+func (c *Catalog) DropView(name string) error {
+	if _, ok := c.views[name]; !ok {
+		return GoDBError{NoSuchTableError, fmt.Sprintf("no view %s", name)}
+	}
+	delete(c.views, name)
+	return nil
+}
+
+// GetView returns an operator scanning the named view.
+func (c *Catalog) GetView(name string) (*View, error) {
+	query, ok := c.views[name]
+	if !ok {
+		return nil, GoDBError{NoSuchTableError, fmt.Sprintf("no view %s", name)}
+	}
+	return &View{catalog: c, name: name, query: query}, nil
+}
+
+// plan compiles the view's stored query.
+func (v *View) plan() (Operator, error) {
+	return ParseQuery(v.catalog, v.query)
+}
+
+// Descriptor reports the schema of the view's underlying plan. If the
+// underlying tables have changed incompatibly the error surfaces on
+// iteration instead.
+func (v *View) Descriptor() *TupleDesc {
+	plan, err := v.plan()
+	if err != nil {
+		return &TupleDesc{}
+	}
+	return plan.Descriptor()
+}
+
+func (v *View) Iterator(tid TransactionID) (func() (*Tuple, error), error) {
+	plan, err := v.plan()
+	if err != nil {
+		return nil, err
+	}
+	return plan.Iterator(tid)
+}
+
+// tableOrView resolves name as a table first, then as a view.
+func (c *Catalog) tableOrView(name string) (Operator, error) {
+	if f, err := c.GetTable(name); err == nil {
+		return f, nil
+	}
+	if v, err := c.GetView(name); err == nil {
+		return v, nil
+	}
+	return nil, GoDBError{NoSuchTableError, fmt.Sprintf("no table or view %s", name)}
+}
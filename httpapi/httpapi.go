@@ -0,0 +1,242 @@
+// Package httpapi exposes a GoDB catalog over HTTP for debugging and
+// administration. It is designed to be mounted into an existing service's
+// mux:
+//
+//	http.Handle("/godb/", http.StripPrefix("/godb", httpapi.NewHandler(cat, bp)))
+//
+// Endpoints:
+//
+//	GET  /tables                  list table names
+//	GET  /tables/{name}/schema    fetch a table's schema
+//	POST /tables/{name}/import    bulk load CSV from the request body
+//	POST /tables/{name}/compact   trigger compaction, if supported
+//	POST /query                   run a read-only query, JSON results
+package httpapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// Compactor is implemented by storage backends that can reclaim space for a
+// table on demand.
+type Compactor interface {
+	CompactTable(name string) error
+}
+
+// Handler serves the REST API for one catalog.
+type Handler struct {
+	catalog *godb.Catalog
+	bufPool *godb.BufferPool
+	// compactor, if non-nil, handles POST /tables/{name}/compact.
+	compactor Compactor
+}
+
+// NewHandler returns a handler over c and bp.
+func NewHandler(c *godb.Catalog, bp *godb.BufferPool) *Handler {
+	return &Handler{catalog: c, bufPool: bp}
+}
+
+// SetCompactor installs the backend used by the compact endpoint.
+func (h *Handler) SetCompactor(c Compactor) {
+	h.compactor = c
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case path == "tables" && r.Method == http.MethodGet:
+		h.listTables(w)
+	case len(parts) == 3 && parts[0] == "tables" && parts[2] == "schema" && r.Method == http.MethodGet:
+		h.tableSchema(w, parts[1])
+	case len(parts) == 3 && parts[0] == "tables" && parts[2] == "import" && r.Method == http.MethodPost:
+		h.importTable(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "tables" && parts[2] == "compact" && r.Method == http.MethodPost:
+		h.compactTable(w, parts[1])
+	case path == "query" && r.Method == http.MethodPost:
+		h.query(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeErr(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (h *Handler) listTables(w http.ResponseWriter) {
+	names := h.catalog.TableNames()
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, map[string]any{"tables": names})
+}
+
+type schemaField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+func (h *Handler) tableSchema(w http.ResponseWriter, name string) {
+	table, err := h.catalog.GetTable(name)
+	if err != nil {
+		writeErr(w, http.StatusNotFound, err)
+		return
+	}
+	desc := table.Descriptor()
+	fields := make([]schemaField, len(desc.Fields))
+	for i, f := range desc.Fields {
+		fields[i] = schemaField{Name: f.Fname, Type: f.Ftype.String()}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"table": name, "fields": fields})
+}
+
+// importTable bulk inserts CSV rows from the request body. The CSV must
+// have no header and match the table schema.
+func (h *Handler) importTable(w http.ResponseWriter, r *http.Request, name string) {
+	table, err := h.catalog.GetTable(name)
+	if err != nil {
+		writeErr(w, http.StatusNotFound, err)
+		return
+	}
+	desc := table.Descriptor()
+	tid := godb.NewTID()
+	if err := h.bufPool.BeginTransaction(tid); err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	count, err := importCSV(r, table, desc, tid)
+	if err != nil {
+		h.bufPool.AbortTransaction(tid)
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	h.bufPool.CommitTransaction(tid)
+	writeJSON(w, http.StatusOK, map[string]any{"inserted": count})
+}
+
+func importCSV(r *http.Request, table godb.DBFile, desc *godb.TupleDesc, tid godb.TransactionID) (int, error) {
+	count := 0
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		t, err := tupleFromCSVLine(line, desc)
+		if err != nil {
+			return count, fmt.Errorf("line %d: %w", count+1, err)
+		}
+		if err := godb.InsertTuple(table, t, tid); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, scanner.Err()
+}
+
+func tupleFromCSVLine(line string, desc *godb.TupleDesc) (*godb.Tuple, error) {
+	fields := strings.Split(line, ",")
+	if len(fields) != len(desc.Fields) {
+		return nil, fmt.Errorf("got %d fields, expected %d", len(fields), len(desc.Fields))
+	}
+	values := make([]godb.DBValue, len(fields))
+	for i, raw := range fields {
+		raw = strings.TrimSpace(raw)
+		switch desc.Fields[i].Ftype {
+		case godb.IntType:
+			var v int64
+			if _, err := fmt.Sscanf(raw, "%d", &v); err != nil {
+				return nil, fmt.Errorf("field %d: %v", i, err)
+			}
+			values[i] = godb.IntField{Value: v}
+		case godb.StringType:
+			values[i] = godb.StringField{Value: raw}
+		}
+	}
+	return &godb.Tuple{Desc: *desc, Fields: values}, nil
+}
+
+func (h *Handler) compactTable(w http.ResponseWriter, name string) {
+	if h.compactor == nil {
+		writeErr(w, http.StatusNotImplemented, errors.New("no compactor configured"))
+		return
+	}
+	if err := h.compactor.CompactTable(name); err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+type queryRequest struct {
+	Query string `json:"query"`
+}
+
+type queryResponse struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+}
+
+// query runs one read-only query and returns all rows as JSON. The parser
+// only accepts SELECT statements, so the endpoint cannot modify data.
+func (h *Handler) query(w http.ResponseWriter, r *http.Request) {
+	var req queryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	plan, err := godb.ParseQuery(h.catalog, req.Query)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	tid := godb.NewTID()
+	if err := h.bufPool.BeginTransaction(tid); err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer h.bufPool.CommitTransaction(tid)
+	iter, err := plan.Iterator(tid)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	resp := queryResponse{Rows: [][]any{}}
+	for _, f := range plan.Descriptor().Fields {
+		resp.Columns = append(resp.Columns, f.Fname)
+	}
+	for {
+		t, err := iter()
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, err)
+			return
+		}
+		if t == nil {
+			break
+		}
+		row := make([]any, len(t.Fields))
+		for i, f := range t.Fields {
+			switch v := f.(type) {
+			case godb.IntField:
+				row[i] = v.Value
+			case godb.StringField:
+				row[i] = v.Value
+			}
+		}
+		resp.Rows = append(resp.Rows, row)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,77 @@
+// Package metrics provides a bundled Prometheus collector implementing the
+// godb.Metrics interface. It keeps counters in process and serves them in
+// the Prometheus text exposition format, so no client library dependency is
+// needed; mount the collector on any mux and point a scraper at it.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// PrometheusCollector accumulates godb metrics and serves them over HTTP.
+// The zero value is ready to use.
+type PrometheusCollector struct {
+	bufferPoolHits      atomic.Int64
+	bufferPoolMisses    atomic.Int64
+	bufferPoolEvictions atomic.Int64
+	pagesFlushed        atomic.Int64
+	txCommitted         atomic.Int64
+	txAborted           atomic.Int64
+	lockWaits           atomic.Int64
+
+	mu            sync.Mutex
+	tuplesScanned map[string]int64
+}
+
+// NewPrometheusCollector returns an empty collector.
+func NewPrometheusCollector() *PrometheusCollector {
+	return &PrometheusCollector{tuplesScanned: make(map[string]int64)}
+}
+
+func (c *PrometheusCollector) BufferPoolHit()        { c.bufferPoolHits.Add(1) }
+func (c *PrometheusCollector) BufferPoolMiss()       { c.bufferPoolMisses.Add(1) }
+func (c *PrometheusCollector) BufferPoolEviction()   { c.bufferPoolEvictions.Add(1) }
+func (c *PrometheusCollector) PageFlushed()          { c.pagesFlushed.Add(1) }
+func (c *PrometheusCollector) TransactionCommitted() { c.txCommitted.Add(1) }
+func (c *PrometheusCollector) TransactionAborted()   { c.txAborted.Add(1) }
+func (c *PrometheusCollector) LockWait()             { c.lockWaits.Add(1) }
+
+func (c *PrometheusCollector) TuplesScanned(op string, n int) {
+	c.mu.Lock()
+	if c.tuplesScanned == nil {
+		c.tuplesScanned = make(map[string]int64)
+	}
+	c.tuplesScanned[op] += int64(n)
+	c.mu.Unlock()
+}
+
+// ServeHTTP writes all counters in the Prometheus text exposition format.
+func (c *PrometheusCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	counter := func(name, help string, v int64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+	}
+	counter("godb_buffer_pool_hits_total", "Pages served from the buffer pool cache.", c.bufferPoolHits.Load())
+	counter("godb_buffer_pool_misses_total", "Pages read from disk on a cache miss.", c.bufferPoolMisses.Load())
+	counter("godb_buffer_pool_evictions_total", "Pages evicted from the buffer pool.", c.bufferPoolEvictions.Load())
+	counter("godb_pages_flushed_total", "Pages written back to disk.", c.pagesFlushed.Load())
+	counter("godb_transactions_committed_total", "Transactions committed.", c.txCommitted.Load())
+	counter("godb_transactions_aborted_total", "Transactions aborted.", c.txAborted.Load())
+	counter("godb_lock_waits_total", "Times a transaction blocked on a page lock.", c.lockWaits.Load())
+
+	c.mu.Lock()
+	ops := make([]string, 0, len(c.tuplesScanned))
+	for op := range c.tuplesScanned {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	fmt.Fprintf(w, "# HELP godb_tuples_scanned_total Tuples produced by scan operators.\n# TYPE godb_tuples_scanned_total counter\n")
+	for _, op := range ops {
+		fmt.Fprintf(w, "godb_tuples_scanned_total{op=%q} %d\n", op, c.tuplesScanned[op])
+	}
+	c.mu.Unlock()
+}
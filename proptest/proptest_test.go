@@ -0,0 +1,29 @@
+package proptest
+
+import "testing"
+
+// Fixed seeds keep the runs reproducible; bump steps or seeds locally
+// when hunting for a bug.
+
+func runSeeds(t *testing.T, engine string, seeds []int64, steps int) {
+	t.Helper()
+	for _, seed := range seeds {
+		if err := Run(Config{Engine: engine, Seed: seed, Steps: steps, Dir: t.TempDir()}); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestHeapModel(t *testing.T) {
+	runSeeds(t, "heap", []int64{1, 2, 3, 4, 5}, 150)
+}
+
+func TestColumnModel(t *testing.T) {
+	runSeeds(t, "column", []int64{1, 2, 3, 4, 5}, 150)
+}
+
+func TestUnknownEngine(t *testing.T) {
+	if err := Run(Config{Engine: "nope", Seed: 1, Steps: 1, Dir: t.TempDir()}); err == nil {
+		t.Fatal("expected error for unknown engine")
+	}
+}
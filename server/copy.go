@@ -0,0 +1,141 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// COPY command handling for the simple query protocol: "COPY <table>
+// FROM STDIN" drives the copy-in sub-protocol (CopyInResponse, CopyData
+// messages, CopyDone/CopyFail) and "COPY <table> TO STDOUT" the copy-out
+// one. Appending BINARY (or WITH (FORMAT BINARY)) selects the binary
+// COPY format; the default is text.
+
+// isCopyQuery reports whether query is a COPY statement.
+func isCopyQuery(query string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "COPY ")
+}
+
+// handleCopy runs one COPY statement, speaking the copy sub-protocol on
+// r and w.
+func (s *Server) handleCopy(r *bufio.Reader, w *bufio.Writer, query string) error {
+	table, toStdout, binFmt, err := parseCopy(query)
+	if err != nil {
+		return err
+	}
+	f, err := s.Catalog.GetTable(table)
+	if err != nil {
+		return err
+	}
+	numCols := len(f.Descriptor().Fields)
+	tid := godb.NewTID()
+	if err := s.BufPool.BeginTransaction(tid); err != nil {
+		return err
+	}
+	if toStdout {
+		defer s.BufPool.CommitTransaction(tid)
+		writeCopyResponse(w, 'H', binFmt, numCols)
+		var buf bytes.Buffer
+		if binFmt {
+			err = godb.CopyToBinary(&buf, f, tid)
+		} else {
+			err = godb.CopyTo(&buf, f, tid)
+		}
+		if err != nil {
+			return err
+		}
+		writeMessage(w, 'd', func(b *[]byte) {
+			*b = append(*b, buf.Bytes()...)
+		})
+		writeMessage(w, 'c', func(b *[]byte) {})
+		writeMessage(w, 'C', func(b *[]byte) {
+			appendCString(b, "COPY")
+		})
+		return nil
+	}
+
+	writeCopyResponse(w, 'G', binFmt, numCols)
+	if err := w.Flush(); err != nil {
+		s.BufPool.AbortTransaction(tid)
+		return err
+	}
+	var data bytes.Buffer
+	for {
+		msgType, payload, err := readMessage(r)
+		if err != nil {
+			s.BufPool.AbortTransaction(tid)
+			return err
+		}
+		if msgType == 'd' {
+			data.Write(payload)
+			continue
+		}
+		if msgType == 'c' {
+			break
+		}
+		if msgType == 'f' {
+			s.BufPool.AbortTransaction(tid)
+			return fmt.Errorf("COPY failed by client: %s", cstring(payload))
+		}
+		// Flush/Sync from extended-protocol clients are harmless here.
+	}
+	var count int
+	if binFmt {
+		count, err = godb.CopyFromBinary(f, &data, tid)
+	} else {
+		count, err = godb.CopyFrom(f, &data, tid)
+	}
+	if err != nil {
+		s.BufPool.AbortTransaction(tid)
+		return err
+	}
+	s.BufPool.CommitTransaction(tid)
+	writeMessage(w, 'C', func(b *[]byte) {
+		appendCString(b, fmt.Sprintf("COPY %d", count))
+	})
+	return nil
+}
+
+// parseCopy extracts the table, direction, and format from a COPY
+// statement.
+func parseCopy(query string) (table string, toStdout, binFmt bool, err error) {
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	upper := make([]string, len(fields))
+	for i, f := range fields {
+		upper[i] = strings.ToUpper(f)
+	}
+	if len(fields) < 4 || upper[0] != "COPY" {
+		return "", false, false, fmt.Errorf("unsupported COPY syntax; use COPY <table> FROM STDIN or COPY <table> TO STDOUT")
+	}
+	table = fields[1]
+	switch {
+	case upper[2] == "FROM" && upper[3] == "STDIN":
+	case upper[2] == "TO" && upper[3] == "STDOUT":
+		toStdout = true
+	default:
+		return "", false, false, fmt.Errorf("unsupported COPY syntax; use COPY <table> FROM STDIN or COPY <table> TO STDOUT")
+	}
+	rest := strings.Join(upper[4:], " ")
+	binFmt = strings.Contains(rest, "BINARY")
+	return table, toStdout, binFmt, nil
+}
+
+// writeCopyResponse writes a CopyInResponse ('G') or CopyOutResponse
+// ('H').
+func writeCopyResponse(w *bufio.Writer, msgType byte, binFmt bool, numCols int) {
+	writeMessage(w, msgType, func(b *[]byte) {
+		format := byte(0)
+		if binFmt {
+			format = 1
+		}
+		*b = append(*b, format)
+		appendInt16(b, int16(numCols))
+		for i := 0; i < numCols; i++ {
+			appendInt16(b, int16(format))
+		}
+	})
+}
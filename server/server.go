@@ -0,0 +1,300 @@
+// Package server exposes a GoDB catalog over the PostgreSQL v3 wire
+// protocol. It implements the minimum needed for psql and standard drivers
+// to connect and run simple queries: startup (with SSL politely declined),
+// the simple query flow (RowDescription, DataRow, CommandComplete), and
+// error responses. Authentication is trust-only and the extended query
+// protocol is not supported.
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// Postgres type OIDs used in RowDescription messages.
+const (
+	oidInt8 = 20
+	oidText = 25
+)
+
+const (
+	sslRequestCode  = 80877103
+	protocolVersion = 196608 // 3.0
+)
+
+// maxMessageSize caps the declared length of a client frame. The length
+// field is client-controlled and each frame is read fully into memory, so
+// without a cap a single bogus header could demand gigabytes; 16 MB is far
+// beyond any simple query or COPY row this server handles.
+const maxMessageSize = 16 << 20
+
+// Server serves the tables of a catalog to Postgres clients.
+type Server struct {
+	Catalog *godb.Catalog
+	BufPool *godb.BufferPool
+}
+
+// NewServer returns a server over c and bp.
+func NewServer(c *godb.Catalog, bp *godb.BufferPool) *Server {
+	return &Server{Catalog: c, BufPool: bp}
+}
+
+// ListenAndServe accepts connections on addr until the listener fails.
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts connections from ln, handling each on its own goroutine.
+func (s *Server) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	if err := s.startup(r, w); err != nil {
+		return
+	}
+	for {
+		msgType, payload, err := readMessage(r)
+		if err != nil {
+			return
+		}
+		switch msgType {
+		case 'Q':
+			query := cstring(payload)
+			if isCopyQuery(query) {
+				if err := s.handleCopy(r, w, query); err != nil {
+					writeError(w, err)
+				}
+			} else if err := s.runQuery(w, query); err != nil {
+				writeError(w, err)
+			}
+			writeReadyForQuery(w)
+		case 'X':
+			return
+		default:
+			writeError(w, fmt.Errorf("unsupported message type %q; only the simple query protocol is implemented", msgType))
+			writeReadyForQuery(w)
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// startup consumes the startup message (retrying after declining an SSL
+// request) and completes the handshake.
+func (s *Server) startup(r *bufio.Reader, w *bufio.Writer) error {
+	for {
+		payload, err := readPayload(r)
+		if err != nil {
+			return err
+		}
+		if len(payload) < 4 {
+			return fmt.Errorf("startup message too short")
+		}
+		code := int32(binary.BigEndian.Uint32(payload[:4]))
+		if code == sslRequestCode {
+			if _, err := w.Write([]byte{'N'}); err != nil {
+				return err
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		if code != protocolVersion {
+			return fmt.Errorf("unsupported protocol version %d", code)
+		}
+		break
+	}
+	// AuthenticationOk
+	writeMessage(w, 'R', func(b *[]byte) {
+		appendInt32(b, 0)
+	})
+	writeParameterStatus(w, "server_version", "14.0 (GoDB)")
+	writeParameterStatus(w, "client_encoding", "UTF8")
+	writeReadyForQuery(w)
+	return w.Flush()
+}
+
+// runQuery parses and executes one simple query, streaming results back in
+// text format.
+func (s *Server) runQuery(w *bufio.Writer, query string) error {
+	plan, err := godb.ParseQuery(s.Catalog, query)
+	if err != nil {
+		return err
+	}
+	tid := godb.NewTID()
+	if err := s.BufPool.BeginTransaction(tid); err != nil {
+		return err
+	}
+	defer s.BufPool.CommitTransaction(tid)
+	iter, err := plan.Iterator(tid)
+	if err != nil {
+		return err
+	}
+	writeRowDescription(w, plan.Descriptor())
+	count := 0
+	for {
+		t, err := iter()
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			break
+		}
+		writeDataRow(w, t)
+		count++
+	}
+	writeMessage(w, 'C', func(b *[]byte) {
+		appendCString(b, fmt.Sprintf("SELECT %d", count))
+	})
+	return nil
+}
+
+func writeRowDescription(w *bufio.Writer, desc *godb.TupleDesc) {
+	writeMessage(w, 'T', func(b *[]byte) {
+		appendInt16(b, int16(len(desc.Fields)))
+		for _, f := range desc.Fields {
+			appendCString(b, f.Fname)
+			appendInt32(b, 0) // table OID
+			appendInt16(b, 0) // attribute number
+			if f.Ftype == godb.IntType {
+				appendInt32(b, oidInt8)
+				appendInt16(b, 8)
+			} else {
+				appendInt32(b, oidText)
+				appendInt16(b, -1)
+			}
+			appendInt32(b, -1) // type modifier
+			appendInt16(b, 0)  // text format
+		}
+	})
+}
+
+func writeDataRow(w *bufio.Writer, t *godb.Tuple) {
+	writeMessage(w, 'D', func(b *[]byte) {
+		appendInt16(b, int16(len(t.Fields)))
+		for _, f := range t.Fields {
+			var s string
+			switch v := f.(type) {
+			case godb.IntField:
+				s = fmt.Sprintf("%d", v.Value)
+			case godb.StringField:
+				s = v.Value
+			}
+			appendInt32(b, int32(len(s)))
+			*b = append(*b, s...)
+		}
+	})
+}
+
+func writeError(w *bufio.Writer, err error) {
+	writeMessage(w, 'E', func(b *[]byte) {
+		*b = append(*b, 'S')
+		appendCString(b, "ERROR")
+		*b = append(*b, 'C')
+		appendCString(b, "42000")
+		*b = append(*b, 'M')
+		appendCString(b, err.Error())
+		*b = append(*b, 0)
+	})
+}
+
+func writeReadyForQuery(w *bufio.Writer) {
+	writeMessage(w, 'Z', func(b *[]byte) {
+		*b = append(*b, 'I')
+	})
+}
+
+func writeParameterStatus(w *bufio.Writer, key, value string) {
+	writeMessage(w, 'S', func(b *[]byte) {
+		appendCString(b, key)
+		appendCString(b, value)
+	})
+}
+
+// writeMessage frames one protocol message: type byte, int32 length
+// (including itself), then the payload built by fill.
+func writeMessage(w *bufio.Writer, msgType byte, fill func(*[]byte)) {
+	var payload []byte
+	fill(&payload)
+	w.WriteByte(msgType)
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(payload)+4))
+	w.Write(hdr[:])
+	w.Write(payload)
+}
+
+// readMessage reads one framed message from the client.
+func readMessage(r *bufio.Reader) (byte, []byte, error) {
+	msgType, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	payload, err := readPayload(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return msgType, payload, nil
+}
+
+// readPayload reads a frame's int32 length (which includes itself) and
+// returns the remaining payload. The length is client-controlled, so it is
+// validated before sizing the buffer: below 4 it would underflow, and
+// beyond maxMessageSize it is treated as a corrupt or hostile frame.
+func readPayload(r *bufio.Reader) ([]byte, error) {
+	var length int32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	if length < 4 || length > maxMessageSize {
+		return nil, fmt.Errorf("invalid message length %d", length)
+	}
+	payload := make([]byte, length-4)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// cstring returns the bytes of b up to the first NUL as a string.
+func cstring(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+func appendInt16(b *[]byte, v int16) {
+	*b = append(*b, byte(uint16(v)>>8), byte(uint16(v)))
+}
+
+func appendInt32(b *[]byte, v int32) {
+	*b = append(*b, byte(uint32(v)>>24), byte(uint32(v)>>16), byte(uint32(v)>>8), byte(uint32(v)))
+}
+
+func appendCString(b *[]byte, s string) {
+	*b = append(*b, s...)
+	*b = append(*b, 0)
+}
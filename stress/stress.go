@@ -0,0 +1,236 @@
+// Package stress drives configurable mixes of reader and writer
+// transactions against one shared table to validate the lock manager and
+// buffer pool under contention. Writers transfer balance between random
+// account rows; readers sum every balance. Under a serializable history
+// the total balance and the row count are invariant, so any reader
+// observing a different sum has caught a real isolation bug. The result
+// reports committed throughput and how many transactions were aborted as
+// deadlock victims — under two-phase locking some always are.
+package stress
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/josephinelee1234/GoDB/godb"
+)
+
+// Config describes one stress run.
+type Config struct {
+	// Readers and Writers are the number of concurrent workers of each kind.
+	Readers int
+	Writers int
+	// Duration is how long the workers run.
+	Duration time.Duration
+	// Rows is the number of account rows; fewer rows means more contention.
+	Rows int
+	// PoolPages is the buffer pool size. Defaults to 64.
+	PoolPages int
+	// Dir is the directory holding the table file.
+	Dir string
+	// Seed makes the workers' choices reproducible (up to scheduling).
+	Seed int64
+}
+
+// Result summarizes a run.
+type Result struct {
+	// ReadTxns and WriteTxns count committed transactions of each kind.
+	ReadTxns  int64
+	WriteTxns int64
+	// DeadlockAborts counts transactions aborted as deadlock victims.
+	DeadlockAborts int64
+	// Violations counts reads that observed a non-serializable state; any
+	// nonzero value also fails the run.
+	Violations int64
+	// Elapsed is the measured wall time, and Throughput the committed
+	// transactions per second over it.
+	Elapsed    time.Duration
+	Throughput float64
+}
+
+// initialBalance is every account's starting balance; the conserved
+// total is Rows * initialBalance.
+const initialBalance = 100
+
+func accountsDesc() godb.TupleDesc {
+	return godb.TupleDesc{Fields: []godb.FieldType{
+		{Fname: "id", Ftype: godb.IntType},
+		{Fname: "balance", Ftype: godb.IntType},
+	}}
+}
+
+// Run executes the configured workload and returns its summary. A
+// serializability violation is returned as an error along with the
+// counts gathered up to that point.
+func Run(cfg Config) (*Result, error) {
+	if cfg.PoolPages == 0 {
+		cfg.PoolPages = 64
+	}
+	bp := godb.NewBufferPool(cfg.PoolPages)
+	desc := accountsDesc()
+	hf, err := godb.NewHeapFile(filepath.Join(cfg.Dir, "accounts.dat"), &desc, bp)
+	if err != nil {
+		return nil, err
+	}
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		return nil, err
+	}
+	for i := 0; i < cfg.Rows; i++ {
+		t := &godb.Tuple{Desc: desc, Fields: []godb.DBValue{
+			godb.IntField{Value: int64(i)},
+			godb.IntField{Value: initialBalance},
+		}}
+		if err := godb.InsertTuple(hf, t, tid); err != nil {
+			bp.AbortTransaction(tid)
+			return nil, err
+		}
+	}
+	bp.CommitTransaction(tid)
+
+	res := &Result{}
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < cfg.Writers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for !stop.Load() {
+				writerTxn(bp, hf, rng, cfg.Rows, res)
+			}
+		}(cfg.Seed + int64(w))
+	}
+	for r := 0; r < cfg.Readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			want := int64(cfg.Rows) * initialBalance
+			for !stop.Load() {
+				readerTxn(bp, hf, cfg.Rows, want, res)
+			}
+		}()
+	}
+	time.Sleep(cfg.Duration)
+	stop.Store(true)
+	wg.Wait()
+	res.Elapsed = time.Since(start)
+	if secs := res.Elapsed.Seconds(); secs > 0 {
+		res.Throughput = float64(res.ReadTxns+res.WriteTxns) / secs
+	}
+	if v := atomic.LoadInt64(&res.Violations); v > 0 {
+		return res, fmt.Errorf("%d reads observed a non-serializable state", v)
+	}
+	return res, nil
+}
+
+// countAbort classifies an aborted transaction's error.
+func countAbort(err error, res *Result) {
+	var ge godb.GoDBError
+	if errors.As(err, &ge) && ge.Code() == godb.DeadlockError {
+		atomic.AddInt64(&res.DeadlockAborts, 1)
+	}
+}
+
+// writerTxn transfers a random amount between two random accounts by
+// rewriting both rows in one transaction.
+func writerTxn(bp *godb.BufferPool, hf *godb.HeapFile, rng *rand.Rand, rows int, res *Result) {
+	from := rng.Int63n(int64(rows))
+	to := rng.Int63n(int64(rows))
+	if from == to {
+		to = (to + 1) % int64(rows)
+	}
+	amount := rng.Int63n(10) + 1
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		return
+	}
+	err := func() error {
+		iter, err := hf.Iterator(tid)
+		if err != nil {
+			return err
+		}
+		var fromRow, toRow *godb.Tuple
+		for fromRow == nil || toRow == nil {
+			t, err := iter()
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return fmt.Errorf("account rows missing")
+			}
+			switch t.Fields[0].(godb.IntField).Value {
+			case from:
+				fromRow = t
+			case to:
+				toRow = t
+			}
+		}
+		for _, mv := range []struct {
+			row   *godb.Tuple
+			delta int64
+		}{{fromRow, -amount}, {toRow, amount}} {
+			if err := godb.DeleteTuple(hf, mv.row, tid); err != nil {
+				return err
+			}
+			updated := &godb.Tuple{Desc: mv.row.Desc, Fields: []godb.DBValue{
+				mv.row.Fields[0],
+				godb.IntField{Value: mv.row.Fields[1].(godb.IntField).Value + mv.delta},
+			}}
+			if err := godb.InsertTuple(hf, updated, tid); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if err != nil {
+		bp.AbortTransaction(tid)
+		countAbort(err, res)
+		return
+	}
+	bp.CommitTransaction(tid)
+	atomic.AddInt64(&res.WriteTxns, 1)
+}
+
+// readerTxn scans every account and checks the conserved invariants.
+func readerTxn(bp *godb.BufferPool, hf *godb.HeapFile, rows int, want int64, res *Result) {
+	tid := godb.NewTID()
+	if err := bp.BeginTransaction(tid); err != nil {
+		return
+	}
+	sum := int64(0)
+	n := 0
+	err := func() error {
+		iter, err := hf.Iterator(tid)
+		if err != nil {
+			return err
+		}
+		for {
+			t, err := iter()
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				return nil
+			}
+			sum += t.Fields[1].(godb.IntField).Value
+			n++
+		}
+	}()
+	if err != nil {
+		bp.AbortTransaction(tid)
+		countAbort(err, res)
+		return
+	}
+	bp.CommitTransaction(tid)
+	if sum != want || n != rows {
+		atomic.AddInt64(&res.Violations, 1)
+	}
+	atomic.AddInt64(&res.ReadTxns, 1)
+}
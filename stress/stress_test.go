@@ -0,0 +1,44 @@
+package stress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadWriteMix(t *testing.T) {
+	res, err := Run(Config{
+		Readers:  4,
+		Writers:  4,
+		Duration: 500 * time.Millisecond,
+		Rows:     16,
+		Dir:      t.TempDir(),
+		Seed:     1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.WriteTxns == 0 || res.ReadTxns == 0 {
+		t.Fatalf("expected both kinds of committed transactions: %+v", res)
+	}
+	if res.Violations != 0 {
+		t.Fatalf("serializability violations: %+v", res)
+	}
+	t.Logf("reads=%d writes=%d deadlocks=%d throughput=%.0f tx/s",
+		res.ReadTxns, res.WriteTxns, res.DeadlockAborts, res.Throughput)
+}
+
+func TestWriterOnly(t *testing.T) {
+	res, err := Run(Config{
+		Writers:  2,
+		Duration: 200 * time.Millisecond,
+		Rows:     8,
+		Dir:      t.TempDir(),
+		Seed:     7,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.WriteTxns == 0 {
+		t.Fatalf("expected committed writes: %+v", res)
+	}
+}